@@ -80,6 +80,27 @@ const parseAllowedTools = (allowedTools: string): string[] => {
     .filter((t) => t);
 };
 
+/**
+ * 将元数据键值对格式化为表单中的JSON文本
+ */
+const formatMetadata = (metadata: Record<string, string> | null): string => {
+  if (!metadata || Object.keys(metadata).length === 0) return "";
+  return JSON.stringify(metadata, null, 2);
+};
+
+/**
+ * 将表单中的JSON文本解析为元数据键值对，值统一转为字符串
+ */
+const parseMetadata = (text?: string): Record<string, string> | null => {
+  if (!text || text.trim() === "") return null;
+  const parsed = JSON.parse(text) as Record<string, unknown>;
+  const metadata: Record<string, string> = {};
+  Object.entries(parsed).forEach(([key, value]) => {
+    metadata[key] = typeof value === "string" ? value : JSON.stringify(value);
+  });
+  return metadata;
+};
+
 /**
  * 编辑器视图模式
  */
@@ -122,7 +143,7 @@ const SkillModal: React.FC = () => {
             ...editingSkill,
             tags: tagIds,
             description: editingSkill.description || "",
-            metadata: editingSkill.metadata || "",
+            metadata: formatMetadata(editingSkill.metadata),
           });
           updateDocStats(editingSkill.detail || "");
         }, 0);
@@ -167,7 +188,7 @@ const SkillModal: React.FC = () => {
           detail: submitData.detail,
           license: submitData.license,
           compatibility: submitData.compatibility,
-          metadata: submitData.metadata,
+          metadata: parseMetadata(submitData.metadata),
           allowedTools: submitData.allowedTools,
           tags: submitData.tags,
         });
@@ -189,7 +210,7 @@ const SkillModal: React.FC = () => {
           detail: submitData.detail,
           license: submitData.license,
           compatibility: submitData.compatibility,
-          metadata: submitData.metadata,
+          metadata: parseMetadata(submitData.metadata),
           allowedTools: submitData.allowedTools,
           tags: submitData.tags,
         });
@@ -601,7 +622,10 @@ const SkillModal: React.FC = () => {
                       return Promise.resolve();
                     }
                     try {
-                      JSON.parse(value);
+                      const parsed = JSON.parse(value);
+                      if (parsed === null || typeof parsed !== 'object' || Array.isArray(parsed)) {
+                        return Promise.reject(new Error('元数据必须是JSON对象'));
+                      }
                       return Promise.resolve();
                     } catch {
                       return Promise.reject(new Error('请输入有效的JSON格式'));
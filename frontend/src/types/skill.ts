@@ -22,8 +22,8 @@ export interface Skill {
   license: string;
   /** 兼容性说明 */
   compatibility: string;
-  /** 元数据 */
-  metadata: string;
+  /** 元数据键值对 */
+  metadata: Record<string, string> | null;
   /** 允许的工具列表 */
   allowedTools: string;
   /** 关联标签 */
@@ -52,8 +52,8 @@ export interface SkillRequest {
   license: string;
   /** 兼容性说明 */
   compatibility: string;
-  /** 元数据 */
-  metadata: string;
+  /** 元数据键值对 */
+  metadata: Record<string, string> | null;
   /** 允许的工具列表 */
   allowedTools: string;
   /** 关联标签ID列表 */
@@ -10,6 +10,10 @@ export interface Tag {
   id: number;
   /** 标签名称 */
   name: string;
+  /** 父标签ID，0表示顶层标签 */
+  parentId: number;
+  /** 合并到该标签的原标签名称，仅获取单个标签时返回 */
+  aliases?: string[];
   /** 创建时间戳（毫秒） */
   createdAt: number;
   /** 更新时间戳（毫秒） */
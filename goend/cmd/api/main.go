@@ -4,31 +4,44 @@ import (
 	"aiflow/internal/api"
 	"aiflow/internal/api/handlers"
 	"aiflow/internal/config"
+	"aiflow/internal/embedding"
 	"aiflow/internal/mcp"
 	"aiflow/internal/repositories"
+	"aiflow/internal/services"
+	"aiflow/internal/storage"
 	"aiflow/internal/utils"
 	"aiflow/internal/utils/logx"
+	"context"
 	"embed"
 	"flag"
+	"fmt"
 	"net/http"
+	"os"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/mark3labs/mcp-go/server"
 )
 
-// todo: 后台提供重新分词按钮，点击后重新对所有技能描述进行分词
-// todo: 后台提供目录导入skill能力
-// todo: 提供zip包导入skill能力
-// todo: 提供skill基准目录注入，确保脚本能正常运行
 // todo: 添加平台参数，细化跟踪信息
-// todo: 编号生成，先大写处理英文
+
+// MCP传输方式
+const (
+	TransportStdio = "stdio" // 通过标准输入输出提供MCP服务，不启动托盘和Web后台
+	TransportHTTP  = "http"  // 通过HTTP提供MCP服务和Web后台
+	TransportBoth  = "both"  // 同时提供stdio和HTTP服务，不启动托盘
+)
 
 var (
 	// httpAddr 定义HTTP服务器监听地址
 	httpAddr = flag.String("http", "localhost:9900", "HTTP服务器监听地址")
+	// transport 定义MCP传输方式
+	transport = flag.String("transport", TransportHTTP, "MCP传输方式: stdio|http|both")
 	// configPath 定义配置文件路径
 	configPath = flag.String("config", "./config.yml", "配置文件路径")
+	// reindex 启动时在后台重建所有技能的搜索索引
+	reindex = flag.Bool("reindex", false, "启动时在后台重建所有技能的分词索引和全文检索索引")
 	// config 全局配置实例
 	appConfig config.Config
 )
@@ -39,11 +52,22 @@ var (
 //go:embed static
 var staticFiles embed.FS
 
-// main 函数是程序入口点，初始化并启动MCP HTTP服务器
+// main 函数是程序入口点，初始化并启动MCP服务器
 // 功能：加载配置、初始化日志、创建服务器实例、配置路由、初始化数据库连接
+// 根据--transport参数以stdio、HTTP或两者同时的方式提供MCP服务
 func main() {
 	flag.Parse()
 
+	if *transport != TransportStdio && *transport != TransportHTTP && *transport != TransportBoth {
+		fmt.Fprintf(os.Stderr, "无效的传输方式 '%s'，有效值: stdio, http, both\n", *transport)
+		os.Exit(2)
+	}
+	// stdio方式下标准输出用于MCP协议通信，日志只能写到标准错误或文件
+	useStdio := *transport != TransportHTTP
+	if useStdio {
+		logx.SetStdOutput(os.Stderr)
+	}
+
 	// 加载配置文件
 	var err error
 	appConfig, err = config.LoadConfig(*configPath)
@@ -64,7 +88,62 @@ func main() {
 	logx.InitLogger(appConfig.Log.Level, appConfig.Log.OutputType, appConfig.Log.FilePath)
 
 	// 创建MCP服务器实例
-	mcpServer := server.NewMCPServer(appConfig.Server.Name, appConfig.Server.Version)
+	// 技能作为资源提供，技能变更时通知客户端资源列表已变化
+	mcpServer := server.NewMCPServer(appConfig.Server.Name, appConfig.Server.Version,
+		server.WithResourceCapabilities(false, true),
+	)
+
+	// 初始化数据库连接
+	// 多个实例共享同一数据库时依赖WAL模式和忙等待保证并发安全
+	dbPath := appConfig.DB.Path
+	if dbPath == "" {
+		dbPath = config.DBPath
+	}
+	utils.CreateIfNotExist(dbPath)
+	// 加载搜索分词配置，必须在创建仓库前完成（创建仓库时可能重建全文检索索引）
+	if err := repositories.ConfigureTextAnalyzer(appConfig.Search); err != nil {
+		logx.Error("加载搜索分词配置失败: %v", err)
+	}
+	repo, err := repositories.NewRepository(dbPath)
+	if err != nil {
+		logx.Error("初始化数据库失败: %v", err)
+		// 即使数据库初始化失败，也创建一个空的repo用于注册路由
+		// 这样API会返回错误而不是404
+		repo = repositories.NewEmptyRepository()
+	}
+	// 按配置设置技能语义搜索的向量化方式
+	if repo.GetDB() != nil {
+		if embedder, err := embedding.New(appConfig.Embedding); err != nil {
+			logx.Error("创建向量化失败，使用本地向量化: %v", err)
+		} else {
+			repo.SetEmbedder(embedder)
+		}
+	}
+	// 退出前等待后台的技能向量计算完成，最多等待5秒，未完成时下次启动重建索引
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := repo.WaitSkillEmbeddings(ctx); err != nil {
+			logx.Warn("等待技能向量计算完成超时: %v", err)
+		}
+	}()
+	// 按启动参数、分词配置或向量化方式变化在后台重建技能搜索索引
+	rebuildIndex := repo.GetDB() != nil && (*reindex || repo.SearchIndexOutdated(context.Background()))
+
+	// 添加基础工具
+	mcp.InitTools(mcpServer, repo, &appConfig)
+
+	// 仅stdio方式：不启动托盘和Web后台，标准输入关闭后退出
+	if *transport == TransportStdio {
+		if rebuildIndex {
+			if _, err := services.NewSkillReindexService(repo).Start(context.Background(), 0); err != nil {
+				logx.Error("启动技能索引重建失败: %v", err)
+			}
+		}
+		logx.Info("MCP服务: %s v%s (stdio)", appConfig.Server.Name, appConfig.Server.Version)
+		serveStdio(mcpServer)
+		return
+	}
 
 	// 创建HTTP服务器
 	httpServer := server.NewStreamableHTTPServer(mcpServer)
@@ -75,7 +154,12 @@ func main() {
 	// 添加中间件
 	r.Use(middleware.RequestID) // 请求ID中间件
 	if appConfig.Log.Level == "debug" {
-		r.Use(middleware.Logger) // 开启调试日志
+		if useStdio {
+			// 默认请求日志写标准输出，会破坏stdio协议，改用调试日志输出
+			r.Use(middleware.RequestLogger(&middleware.DefaultLogFormatter{Logger: logx.DebugLogger, NoColor: true}))
+		} else {
+			r.Use(middleware.Logger) // 开启调试日志
+		}
 	}
 	r.Use(middleware.Recoverer) // 恢复中间件，防止服务器崩溃
 	// 添加CORS中间件
@@ -105,21 +189,19 @@ func main() {
 	r.HandleFunc("/web", handlers.WebHandler)
 	r.HandleFunc("/web/*", handlers.WebHandler)
 
-	// 初始化数据库连接
-	utils.CreateIfNotExist(config.DBPath)
-	repo, err := repositories.NewRepository(config.DBPath)
-	if err != nil {
-		logx.Error("初始化数据库失败: %v", err)
-		// 即使数据库初始化失败，也创建一个空的repo用于注册路由
-		// 这样API会返回错误而不是404
-		repo = repositories.NewEmptyRepository()
-	}
-
-	// 添加基础工具
-	mcp.InitTools(mcpServer, repo)
 	// 注册API路由（无论数据库是否初始化成功都注册）
-	apiRouter := api.NewRouter(repo)
+	store := storage.NewSkillFileStore(appConfig.Skill.BaseDir, appConfig.Skill.MaxFileSize)
+	apiRouter := api.NewRouter(repo, store, appConfig.Sync, appConfig.Trash)
 	apiRouter.RegisterRoutes(r)
+	// 启动技能目录同步（仅HTTP实例执行，避免多个进程同时写同一目录）
+	apiRouter.StartSync(context.Background())
+	// 启动回收站过期项清理（仅HTTP实例执行）
+	apiRouter.StartTrashSweep(context.Background())
+	if rebuildIndex {
+		if err := apiRouter.StartReindex(context.Background()); err != nil {
+			logx.Error("启动技能索引重建失败: %v", err)
+		}
+	}
 
 	// 确定最终使用的监听地址
 	listenAddr := appConfig.Server.Addr
@@ -138,9 +220,13 @@ func main() {
 	if appConfig.Log.FilePath != "" && appConfig.Log.OutputType == "file" {
 		logx.Info("  文件路径: %s", appConfig.Log.FilePath)
 	}
-
-	// 初始化系统托盘
-	utils.InitTray(&appConfig)
+	logx.Info("技能基准目录: %s", store.BaseDir())
+	if appConfig.Trash.RetentionDays > 0 {
+		logx.Info("回收站保留天数: %d", appConfig.Trash.RetentionDays)
+	}
+	if appConfig.Sync.Dir != "" {
+		logx.Info("技能同步目录: %s (轮询间隔: %d秒, 写回: %v)", appConfig.Sync.Dir, appConfig.Sync.PollInterval, appConfig.Sync.WriteBack)
+	}
 
 	// 启动HTTP服务器（在后台运行）
 	go func() {
@@ -149,8 +235,25 @@ func main() {
 		}
 	}()
 
+	// 同时提供stdio服务时由MCP客户端管理进程生命周期，不启动托盘
+	if *transport == TransportBoth {
+		serveStdio(mcpServer)
+		return
+	}
+
+	// 初始化系统托盘
+	utils.InitTray(&appConfig)
+
 	// 等待退出信号
 	utils.WaitForExit()
 
 	logx.Info("AiFlow服务已成功退出")
 }
+
+// serveStdio 通过标准输入输出提供MCP服务，阻塞直到标准输入关闭或收到退出信号
+func serveStdio(mcpServer *server.MCPServer) {
+	if err := server.ServeStdio(mcpServer, server.WithErrorLogger(logx.ErrorLogger)); err != nil {
+		logx.Error("stdio服务异常退出: %v", err)
+	}
+	logx.Info("AiFlow服务已成功退出")
+}
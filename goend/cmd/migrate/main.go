@@ -255,7 +255,7 @@ func migrateSkills(oldDB, newDB *gorm.DB) {
 			Description:   old.Description,
 			License:       old.License,
 			Compatibility: old.Compatibility,
-			Metadata:      old.Metadata,
+			Metadata:      models.ParseSkillMetadata(old.Metadata),
 			AllowedTools:  old.AllowedTools,
 			Detail:        old.Detail,
 			CreatedAt:     old.CreatedAt,
@@ -286,15 +286,10 @@ func exportJobTasksAsCSV(w http.ResponseWriter, req *http.Request, jobTasks []mo
 			passAcceptStd = "已通过"
 		}
 
-		// 解析执行记录获取技能列表
+		// 获取最新执行记录的技能列表
 		var skillsStr string
-		if task.ExecutionRecords != "" {
-			var records []models.ExecutionRecord
-			if err := json.Unmarshal([]byte(task.ExecutionRecords), &records); err == nil && len(records) > 0 {
-				// 获取最新执行记录的技能
-				latestRecord := records[len(records)-1]
-				skillsStr = strings.Join(latestRecord.Skills, ", ")
-			}
+		if records := task.Executions; len(records) > 0 {
+			skillsStr = strings.Join(records[len(records)-1].Skills, ", ")
 		}
 
 		record := []string{
@@ -333,14 +328,10 @@ func exportJobTasksAsJSON(w http.ResponseWriter, req *http.Request, jobTasks []m
 
 	exportData := make([]ExportJobTask, 0, len(jobTasks))
 	for _, task := range jobTasks {
-		// 解析执行记录获取技能列表
+		// 获取最新执行记录的技能列表
 		var skills []string
-		if task.ExecutionRecords != "" {
-			var records []models.ExecutionRecord
-			if err := json.Unmarshal([]byte(task.ExecutionRecords), &records); err == nil && len(records) > 0 {
-				// 获取最新执行记录的技能
-				skills = records[len(records)-1].Skills
-			}
+		if records := task.Executions; len(records) > 0 {
+			skills = records[len(records)-1].Skills
 		}
 
 		exportData = append(exportData, ExportJobTask{
@@ -386,37 +377,29 @@ func exportJobTasksAsMD(w http.ResponseWriter, req *http.Request, jobTasks []mod
 		mdContent.WriteString(fmt.Sprintf("- **验收状态**: %s\n", passAcceptStd))
 		mdContent.WriteString(fmt.Sprintf("- **完成阶段**: %s\n", task.Status))
 
-		// 解析执行记录获取技能列表（收集所有记录中的技能并去重）
-		if task.ExecutionRecords != "" {
-			var records []models.ExecutionRecord
-			if err := json.Unmarshal([]byte(task.ExecutionRecords), &records); err == nil && len(records) > 0 {
-				skillSet := make(map[string]struct{})
-				for _, record := range records {
-					for _, skill := range record.Skills {
-						skillSet[skill] = struct{}{}
-					}
-				}
-				if len(skillSet) > 0 {
-					skills := make([]string, 0, len(skillSet))
-					for skill := range skillSet {
-						skills = append(skills, skill)
-					}
-					mdContent.WriteString(fmt.Sprintf("- **使用技能**: %s\n", strings.Join(skills, ", ")))
-				}
+		// 收集所有执行记录中的技能并去重
+		skillSet := make(map[string]struct{})
+		for _, record := range task.Executions {
+			for _, skill := range record.Skills {
+				skillSet[skill] = struct{}{}
+			}
+		}
+		if len(skillSet) > 0 {
+			skills := make([]string, 0, len(skillSet))
+			for skill := range skillSet {
+				skills = append(skills, skill)
 			}
+			mdContent.WriteString(fmt.Sprintf("- **使用技能**: %s\n", strings.Join(skills, ", ")))
 		}
 
 		mdContent.WriteString(fmt.Sprintf("- **创建时间**: %s\n", formatTimestamp(task.CreatedAt)))
 		mdContent.WriteString(fmt.Sprintf("- **更新时间**: %s\n", formatTimestamp(task.UpdatedAt)))
 
-		// 解析执行记录
-		if task.ExecutionRecords != "" {
+		// 执行记录
+		if len(task.Executions) > 0 {
 			mdContent.WriteString("- **执行记录**:\n")
-			var records []models.ExecutionRecord
-			if err := json.Unmarshal([]byte(task.ExecutionRecords), &records); err == nil {
-				for _, record := range records {
-					mdContent.WriteString(fmt.Sprintf("  - 序号 %d: %s (%s)\n", record.Sequence, record.Status, record.Result))
-				}
+			for _, record := range task.Executions {
+				mdContent.WriteString(fmt.Sprintf("  - 序号 %d: %s (%s)\n", record.Sequence, record.Status, record.Result))
 			}
 		}
 
@@ -3,27 +3,32 @@ package handlers
 import (
 	"aiflow/internal/api/helpers"
 	"aiflow/internal/errors"
+	"aiflow/internal/models"
 	"aiflow/internal/services"
+	"aiflow/internal/utils/logx"
 	"context"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
 	"gorm.io/gorm"
 )
 
 // SkillRequest 技能请求结构
 type SkillRequest struct {
-	Name          string `json:"name"`
-	ResourceDir   string `json:"resourceDir"`
-	Description   string `json:"description"`
-	Version       string `json:"version"`
-	Detail        string `json:"detail"`
-	License       string `json:"license"`
-	Compatibility string `json:"compatibility"`
-	Metadata      string `json:"metadata"`
-	AllowedTools  string `json:"allowedTools"`
-	Tags          []uint `json:"tags"`
+	Name          string               `json:"name"`
+	ResourceDir   string               `json:"resourceDir"`
+	Description   string               `json:"description"`
+	Version       string               `json:"version"`
+	Detail        string               `json:"detail"`
+	License       string               `json:"license"`
+	Compatibility string               `json:"compatibility"`
+	Metadata      models.SkillMetadata `json:"metadata"`
+	AllowedTools  string               `json:"allowedTools"`
+	Tags          []uint               `json:"tags"`
 }
 
 // SkillHandler 技能处理器
@@ -36,10 +41,11 @@ func NewSkillHandler(service *services.SkillService) *SkillHandler {
 	return &SkillHandler{service: service}
 }
 
-// ListSkills 获取所有技能（支持分页、标签筛选和日期范围筛选）
+// ListSkills 获取所有技能（支持分页、标签筛选、日期范围筛选和元数据筛选）
 func (h *SkillHandler) ListSkills(w http.ResponseWriter, req *http.Request) {
-	// 获取标签筛选参数
+	// 获取标签筛选参数，includeDescendants=true 时包含所有子标签的技能
 	tagIDStr := req.URL.Query().Get("tagId")
+	includeDescendants := helpers.ParseBoolParam(req, "includeDescendants", false)
 	// 解析分页参数
 	pagination := helpers.ParsePagination(req)
 
@@ -58,13 +64,26 @@ func (h *SkillHandler) ListSkills(w http.ResponseWriter, req *http.Request) {
 		tagID = id
 	}
 
+	// 解析元数据筛选参数，可重复传入，如 metadata=author:alice&metadata=team
+	var metadataFilters []models.SkillMetadataFilter
+	for _, value := range req.URL.Query()["metadata"] {
+		filter, err := models.ParseSkillMetadataFilter(value)
+		if err != nil {
+			helpers.RenderError(w, req, errors.NewInvalidParamError(errors.ErrCodeBadRequestParam, err.Error(), err))
+			return
+		}
+		metadataFilters = append(metadataFilters, filter)
+	}
+
 	// 调用service层
 	result, err := h.service.ListSkills(context.Background(), services.ListSkillsRequest{
-		TagID:     tagID,
-		Page:      pagination.Page,
-		PageSize:  pagination.PageSize,
-		StartDate: startDate,
-		EndDate:   endDate,
+		TagID:              tagID,
+		IncludeDescendants: includeDescendants,
+		Page:               pagination.Page,
+		PageSize:           pagination.PageSize,
+		StartDate:          startDate,
+		EndDate:            endDate,
+		Metadata:           metadataFilters,
 	})
 
 	if err != nil {
@@ -97,6 +116,10 @@ func (h *SkillHandler) CreateSkill(w http.ResponseWriter, req *http.Request) {
 	})
 
 	if err != nil {
+		if _, ok := errors.IsAppError(err); ok {
+			helpers.RenderError(w, req, err)
+			return
+		}
 		helpers.RenderError(w, req, errors.NewSkillError(errors.ErrCodeSkillCreate, "创建技能失败", err))
 		return
 	}
@@ -150,6 +173,10 @@ func (h *SkillHandler) UpdateSkill(w http.ResponseWriter, req *http.Request) {
 	})
 
 	if err != nil {
+		if _, ok := errors.IsAppError(err); ok {
+			helpers.RenderError(w, req, err)
+			return
+		}
 		helpers.RenderError(w, req, errors.NewSkillError(errors.ErrCodeSkillUpdate, "更新技能失败", err))
 		return
 	}
@@ -219,6 +246,10 @@ func (h *SkillHandler) PermanentDeleteSkill(w http.ResponseWriter, req *http.Req
 			helpers.RenderError(w, req, errors.NewNotFoundError(errors.ErrCodeSkillNotFound, "技能不存在或不在回收站中", err))
 			return
 		}
+		if _, ok := errors.IsAppError(err); ok {
+			helpers.RenderError(w, req, err)
+			return
+		}
 		helpers.RenderError(w, req, errors.NewSkillError(errors.ErrCodeSkillDelete, "彻底删除技能失败", err))
 		return
 	}
@@ -226,12 +257,15 @@ func (h *SkillHandler) PermanentDeleteSkill(w http.ResponseWriter, req *http.Req
 	helpers.RenderSuccessWithMessage(w, req, "技能已彻底删除", nil)
 }
 
-// ExportSkills 导出技能为MD格式
+// ExportSkills 导出技能
+// 指定技能ID（路径参数或id查询参数）时导出单个技能为MD格式，否则按筛选条件批量导出为zip包
 func (h *SkillHandler) ExportSkills(w http.ResponseWriter, req *http.Request) {
-	idStr := req.URL.Query().Get("id")
-
+	idStr := chi.URLParam(req, "id")
 	if idStr == "" {
-		helpers.RenderError(w, req, errors.NewInvalidParamError(errors.ErrCodeBadRequestParam, "必须提供技能ID参数", nil))
+		idStr = req.URL.Query().Get("id")
+	}
+	if idStr == "" {
+		h.exportSkillsZip(w, req)
 		return
 	}
 
@@ -254,3 +288,63 @@ func (h *SkillHandler) ExportSkills(w http.ResponseWriter, req *http.Request) {
 
 	w.Write([]byte(content))
 }
+
+// exportSkillsZip 批量导出技能为zip包
+// 支持按技能ID列表（ids）、标签ID列表（tagIds）和创建时间范围（startDate、endDate）筛选
+func (h *SkillHandler) exportSkillsZip(w http.ResponseWriter, req *http.Request) {
+	ids, err := helpers.ParseUintListParam(req, "ids")
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+	tagIDs, err := helpers.ParseUintListParam(req, "tagIds")
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+
+	skills, err := h.service.GetSkillsForExport(context.Background(), services.ExportSkillsRequest{
+		IDs:       ids,
+		TagIDs:    tagIDs,
+		StartDate: helpers.ParseIntParam(req, "startDate", 0),
+		EndDate:   helpers.ParseIntParam(req, "endDate", 0),
+	})
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+
+	// 边读取技能文件边写入响应，开始写入后出错只能中断响应
+	filename := fmt.Sprintf("skills-%s.zip", time.Now().Format("20060102150405"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", "attachment; filename="+filename)
+	if err = h.service.WriteSkillsZip(skills, w); err != nil {
+		logx.Error("批量导出技能失败: %v", err)
+	}
+}
+
+// LintSkills 检查所有技能是否符合Agent Skills规范，返回问题列表和修复建议
+func (h *SkillHandler) LintSkills(w http.ResponseWriter, req *http.Request) {
+	result, err := h.service.LintSkills(context.Background())
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+
+	helpers.RenderSuccess(w, req, result)
+}
+
+// SearchSkills 按关键词搜索技能，返回相关度得分和命中摘要
+// 查询参数: q 关键词（必填），limit 最多返回条数（默认20，最大100）
+func (h *SkillHandler) SearchSkills(w http.ResponseWriter, req *http.Request) {
+	keyword := req.URL.Query().Get("q")
+	limit := helpers.ParseIntParam(req, "limit", 20)
+
+	result, err := h.service.SearchSkills(context.Background(), keyword, int(limit))
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+
+	helpers.RenderSuccess(w, req, result)
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"aiflow/internal/api/helpers"
+	"aiflow/internal/errors"
+	"aiflow/internal/services"
+	"context"
+	"mime"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxSkillFileFormMemory 技能文件上传时表单在内存中保留的最大字节数，超出部分写入临时文件
+const maxSkillFileFormMemory = 32 << 20
+
+// SkillFileHandler 技能文件处理器
+type SkillFileHandler struct {
+	service *services.SkillFileService
+}
+
+// NewSkillFileHandler 创建技能文件处理器
+func NewSkillFileHandler(service *services.SkillFileService) *SkillFileHandler {
+	return &SkillFileHandler{service: service}
+}
+
+// ListSkillFiles 获取技能的文件列表
+func (h *SkillFileHandler) ListSkillFiles(w http.ResponseWriter, req *http.Request) {
+	id, err := helpers.ParseIDParam(req, "id")
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+
+	result, err := h.service.ListFiles(context.Background(), id)
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+
+	helpers.RenderSuccess(w, req, result)
+}
+
+// UploadSkillFile 上传技能文件
+// 表单字段file为文件内容，path为技能目录内的相对路径（为空时使用上传的文件名）
+func (h *SkillFileHandler) UploadSkillFile(w http.ResponseWriter, req *http.Request) {
+	id, err := helpers.ParseIDParam(req, "id")
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+
+	if err = req.ParseMultipartForm(maxSkillFileFormMemory); err != nil {
+		helpers.RenderError(w, req, errors.NewInvalidParamError(errors.ErrCodeBadRequest, "解析表单数据失败", err))
+		return
+	}
+
+	file, fileHeader, err := req.FormFile("file")
+	if err != nil {
+		helpers.RenderError(w, req, errors.NewInvalidParamError(errors.ErrCodeBadRequest, "获取上传文件失败", err))
+		return
+	}
+	defer file.Close()
+
+	relPath := req.FormValue("path")
+	if relPath == "" {
+		relPath = fileHeader.Filename
+	}
+
+	result, err := h.service.SaveFile(context.Background(), id, relPath, file)
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+
+	helpers.RenderCreated(w, req, "技能文件上传成功", result)
+}
+
+// DownloadSkillFile 下载技能文件
+func (h *SkillFileHandler) DownloadSkillFile(w http.ResponseWriter, req *http.Request) {
+	id, err := helpers.ParseIDParam(req, "id")
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+
+	file, info, err := h.service.OpenFile(context.Background(), id, chi.URLParam(req, "*"))
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": info.Name()}))
+	http.ServeContent(w, req, info.Name(), info.ModTime(), file)
+}
+
+// DeleteSkillFile 删除技能文件
+func (h *SkillFileHandler) DeleteSkillFile(w http.ResponseWriter, req *http.Request) {
+	id, err := helpers.ParseIDParam(req, "id")
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+
+	if err := h.service.DeleteFile(context.Background(), id, chi.URLParam(req, "*")); err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+
+	helpers.RenderSuccessWithMessage(w, req, "技能文件已删除", nil)
+}
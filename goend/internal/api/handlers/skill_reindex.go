@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"aiflow/internal/api/helpers"
+	"aiflow/internal/services"
+	"context"
+	"net/http"
+)
+
+// SkillReindexHandler 技能索引重建处理器
+type SkillReindexHandler struct {
+	service *services.SkillReindexService
+}
+
+// NewSkillReindexHandler 创建技能索引重建处理器
+func NewSkillReindexHandler(service *services.SkillReindexService) *SkillReindexHandler {
+	return &SkillReindexHandler{service: service}
+}
+
+// StartReindex 在后台开始重建所有技能的分词索引和全文检索索引
+// 查询参数: batchSize 每批重建的技能数（默认100，最大1000）
+func (h *SkillReindexHandler) StartReindex(w http.ResponseWriter, req *http.Request) {
+	batchSize := helpers.ParseIntParam(req, "batchSize", services.DefaultReindexBatchSize)
+
+	// 重建在后台执行，不随请求结束而取消
+	status, err := h.service.Start(context.Background(), int(batchSize))
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+
+	helpers.RenderSuccessWithMessage(w, req, "技能索引重建已开始", status)
+}
+
+// GetReindexStatus 获取最近一次索引重建的进度，从未重建过时返回null
+func (h *SkillReindexHandler) GetReindexStatus(w http.ResponseWriter, req *http.Request) {
+	helpers.RenderSuccess(w, req, h.service.Status())
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"aiflow/internal/api/helpers"
+	"context"
+	"net/http"
+)
+
+// ListSkillRevisions 获取技能的修订列表
+func (h *SkillHandler) ListSkillRevisions(w http.ResponseWriter, req *http.Request) {
+	id, err := helpers.ParseIDParam(req, "id")
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+
+	result, err := h.service.ListSkillRevisions(context.Background(), id)
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+
+	helpers.RenderSuccess(w, req, result)
+}
+
+// GetSkillRevision 获取技能的指定修订（含详细说明）
+func (h *SkillHandler) GetSkillRevision(w http.ResponseWriter, req *http.Request) {
+	id, err := helpers.ParseIDParam(req, "id")
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+	revision, err := helpers.ParseIDParam(req, "revision")
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+
+	result, err := h.service.GetSkillRevision(context.Background(), id, int(revision))
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+
+	helpers.RenderSuccess(w, req, result)
+}
+
+// DiffSkillRevisions 对比技能的两个修订
+// 查询参数to默认为最新修订，from默认为to的上一个修订
+func (h *SkillHandler) DiffSkillRevisions(w http.ResponseWriter, req *http.Request) {
+	id, err := helpers.ParseIDParam(req, "id")
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+	from := helpers.ParseIntParam(req, "from", 0)
+	to := helpers.ParseIntParam(req, "to", 0)
+
+	result, err := h.service.DiffSkillRevisions(context.Background(), id, int(from), int(to))
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+
+	helpers.RenderSuccess(w, req, result)
+}
+
+// RollbackSkill 将技能回滚到指定修订
+func (h *SkillHandler) RollbackSkill(w http.ResponseWriter, req *http.Request) {
+	id, err := helpers.ParseIDParam(req, "id")
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+	revision, err := helpers.ParseIDParam(req, "revision")
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+
+	result, err := h.service.RollbackSkill(context.Background(), id, int(revision))
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+
+	helpers.RenderSuccessWithMessage(w, req, "技能回滚成功", result)
+}
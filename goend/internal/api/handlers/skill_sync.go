@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"aiflow/internal/api/helpers"
+	"aiflow/internal/errors"
+	"aiflow/internal/services"
+	"context"
+	"net/http"
+
+	"github.com/go-chi/render"
+)
+
+// ResolveConflictRequest 处理同步冲突请求结构
+type ResolveConflictRequest struct {
+	Keep string `json:"keep"` // 保留哪一方：file（SKILL.md文件）或 db（数据库）
+}
+
+// SkillSyncHandler 技能目录同步处理器
+type SkillSyncHandler struct {
+	service *services.SkillSyncService
+}
+
+// NewSkillSyncHandler 创建技能目录同步处理器
+func NewSkillSyncHandler(service *services.SkillSyncService) *SkillSyncHandler {
+	return &SkillSyncHandler{service: service}
+}
+
+// GetSyncStatus 获取同步配置和上次同步结果
+func (h *SkillSyncHandler) GetSyncStatus(w http.ResponseWriter, req *http.Request) {
+	helpers.RenderSuccess(w, req, h.service.Status(context.Background()))
+}
+
+// RunSync 立即执行一次同步
+func (h *SkillSyncHandler) RunSync(w http.ResponseWriter, req *http.Request) {
+	result, err := h.service.Sync(context.Background())
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+
+	helpers.RenderSuccessWithMessage(w, req, "技能目录同步完成", result)
+}
+
+// ListSyncConflicts 获取同步冲突列表
+func (h *SkillSyncHandler) ListSyncConflicts(w http.ResponseWriter, req *http.Request) {
+	result, err := h.service.ListConflicts(context.Background())
+	if err != nil {
+		helpers.RenderError(w, req, errors.NewSkillError(errors.ErrCodeSkillSync, "获取同步冲突列表失败", err))
+		return
+	}
+
+	helpers.RenderSuccess(w, req, result)
+}
+
+// ResolveSyncConflict 处理同步冲突
+func (h *SkillSyncHandler) ResolveSyncConflict(w http.ResponseWriter, req *http.Request) {
+	id, err := helpers.ParseIDParam(req, "id")
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+
+	var reqBody ResolveConflictRequest
+	if err = render.DecodeJSON(req.Body, &reqBody); err != nil {
+		helpers.RenderError(w, req, errors.NewInvalidParamError(errors.ErrCodeBadRequest, "请求参数错误", err))
+		return
+	}
+
+	result, err := h.service.ResolveConflict(context.Background(), id, reqBody.Keep)
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+
+	helpers.RenderSuccessWithMessage(w, req, "同步冲突已处理", result)
+}
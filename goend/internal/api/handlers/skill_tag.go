@@ -13,6 +13,20 @@ import (
 // TagRequest 标签请求结构
 type TagRequest struct {
 	Name string `json:"name"`
+	// ParentID 父标签ID，只在创建时使用，0表示顶层标签
+	ParentID uint `json:"parentId"`
+}
+
+// MoveTagRequest 移动标签请求结构
+type MoveTagRequest struct {
+	// ParentID 新的父标签ID，0表示移到顶层
+	ParentID uint `json:"parentId"`
+}
+
+// MergeTagsRequest 合并标签请求结构
+type MergeTagsRequest struct {
+	// SourceIDs 要合并到当前标签的标签ID
+	SourceIDs []uint `json:"sourceIds"`
 }
 
 // TagHandler 标签处理器
@@ -53,7 +67,8 @@ func (h *TagHandler) CreateTag(w http.ResponseWriter, req *http.Request) {
 
 	// 调用service层
 	result, err := h.service.CreateTag(context.Background(), services.CreateTagRequest{
-		Name: reqBody.Name,
+		Name:     reqBody.Name,
+		ParentID: reqBody.ParentID,
 	})
 	if err != nil {
 		helpers.RenderError(w, req, err)
@@ -107,7 +122,7 @@ func (h *TagHandler) UpdateTag(w http.ResponseWriter, req *http.Request) {
 	helpers.RenderSuccessWithMessage(w, req, "标签更新成功", result)
 }
 
-// DeleteTag 删除标签
+// DeleteTag 删除标签（伪删除，进入回收站）
 func (h *TagHandler) DeleteTag(w http.ResponseWriter, req *http.Request) {
 	id, err := helpers.ParseIDParam(req, "id")
 	if err != nil {
@@ -120,5 +135,78 @@ func (h *TagHandler) DeleteTag(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	helpers.RenderSuccessWithMessage(w, req, "标签删除成功", nil)
+	helpers.RenderSuccessWithMessage(w, req, "标签已移至回收站", nil)
+}
+
+// GetTagTree 获取标签树，传 rootId 时只返回该标签的子树
+func (h *TagHandler) GetTagTree(w http.ResponseWriter, req *http.Request) {
+	var rootID uint
+	if req.URL.Query().Get("rootId") != "" {
+		id, err := helpers.ParseUintParam(req, "rootId")
+		if err != nil {
+			helpers.RenderError(w, req, err)
+			return
+		}
+		rootID = id
+	}
+
+	result, err := h.service.GetTagTree(context.Background(), rootID)
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+
+	helpers.RenderSuccess(w, req, result)
+}
+
+// MoveTag 将标签连同其子标签移动到新的父标签下
+func (h *TagHandler) MoveTag(w http.ResponseWriter, req *http.Request) {
+	id, err := helpers.ParseIDParam(req, "id")
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+
+	var reqBody MoveTagRequest
+	if err = render.DecodeJSON(req.Body, &reqBody); err != nil {
+		helpers.RenderError(w, req, errors.NewInvalidParamError(errors.ErrCodeBadRequest, "请求参数错误", err))
+		return
+	}
+
+	result, err := h.service.MoveTag(context.Background(), services.MoveTagRequest{
+		ID:       id,
+		ParentID: reqBody.ParentID,
+	})
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+
+	helpers.RenderSuccessWithMessage(w, req, "标签移动成功", result)
+}
+
+// MergeTags 将其他标签合并到当前标签
+func (h *TagHandler) MergeTags(w http.ResponseWriter, req *http.Request) {
+	id, err := helpers.ParseIDParam(req, "id")
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+
+	var reqBody MergeTagsRequest
+	if err = render.DecodeJSON(req.Body, &reqBody); err != nil {
+		helpers.RenderError(w, req, errors.NewInvalidParamError(errors.ErrCodeBadRequest, "请求参数错误", err))
+		return
+	}
+
+	result, err := h.service.MergeTags(context.Background(), services.MergeTagsRequest{
+		TargetID:  id,
+		SourceIDs: reqBody.SourceIDs,
+	})
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+
+	helpers.RenderSuccessWithMessage(w, req, "标签合并成功", result)
 }
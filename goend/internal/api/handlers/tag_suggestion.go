@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"aiflow/internal/api/helpers"
+	"aiflow/internal/errors"
+	"aiflow/internal/services"
+	"context"
+	"net/http"
+
+	"github.com/go-chi/render"
+)
+
+// TagSuggestionHandler 标签建议处理器
+type TagSuggestionHandler struct {
+	service *services.TagSuggestionService
+}
+
+// NewTagSuggestionHandler 创建标签建议处理器
+func NewTagSuggestionHandler(service *services.TagSuggestionService) *TagSuggestionHandler {
+	return &TagSuggestionHandler{service: service}
+}
+
+// ListSuggestions 获取标签建议列表
+// 查询参数: status 按状态筛选（pending、accepted、rejected），skillId 按技能筛选，page、pageSize 分页
+func (h *TagSuggestionHandler) ListSuggestions(w http.ResponseWriter, req *http.Request) {
+	pagination := helpers.ParsePagination(req)
+
+	var skillID uint
+	if req.URL.Query().Get("skillId") != "" {
+		id, err := helpers.ParseUintParam(req, "skillId")
+		if err != nil {
+			helpers.RenderError(w, req, err)
+			return
+		}
+		skillID = id
+	}
+
+	result, err := h.service.ListSuggestions(context.Background(), services.ListTagSuggestionsRequest{
+		Status:   req.URL.Query().Get("status"),
+		SkillID:  skillID,
+		Page:     pagination.Page,
+		PageSize: pagination.PageSize,
+	})
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+
+	helpers.RenderSuccess(w, req, result)
+}
+
+// GenerateSuggestions 为指定技能或所有没有标签的技能生成标签建议
+func (h *TagSuggestionHandler) GenerateSuggestions(w http.ResponseWriter, req *http.Request) {
+	// 请求体可为空，表示为所有没有标签的技能生成
+	var reqBody services.GenerateTagSuggestionsRequest
+	if req.ContentLength != 0 {
+		if err := render.DecodeJSON(req.Body, &reqBody); err != nil {
+			helpers.RenderError(w, req, errors.NewInvalidParamError(errors.ErrCodeBadRequest, "请求参数错误", err))
+			return
+		}
+	}
+
+	result, err := h.service.GenerateSuggestions(context.Background(), reqBody)
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+
+	helpers.RenderSuccess(w, req, result)
+}
+
+// AcceptSuggestion 采纳标签建议，将标签关联到技能
+func (h *TagSuggestionHandler) AcceptSuggestion(w http.ResponseWriter, req *http.Request) {
+	id, err := helpers.ParseIDParam(req, "id")
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+
+	result, err := h.service.AcceptSuggestion(context.Background(), id)
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+
+	helpers.RenderSuccessWithMessage(w, req, "已采纳标签建议", result)
+}
+
+// RejectSuggestion 拒绝标签建议
+func (h *TagSuggestionHandler) RejectSuggestion(w http.ResponseWriter, req *http.Request) {
+	id, err := helpers.ParseIDParam(req, "id")
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+
+	result, err := h.service.RejectSuggestion(context.Background(), id)
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+
+	helpers.RenderSuccessWithMessage(w, req, "已拒绝标签建议", result)
+}
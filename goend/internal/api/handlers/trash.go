@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"aiflow/internal/api/helpers"
+	"aiflow/internal/errors"
+	"aiflow/internal/services"
+	"context"
+	"net/http"
+
+	"github.com/go-chi/render"
+)
+
+// TrashHandler 回收站处理器
+type TrashHandler struct {
+	service *services.TrashService
+}
+
+// NewTrashHandler 创建回收站处理器
+func NewTrashHandler(service *services.TrashService) *TrashHandler {
+	return &TrashHandler{service: service}
+}
+
+// ListTrash 获取回收站列表
+// 查询参数: type 按类型筛选（skill、jobtask、tag），page、pageSize 分页
+func (h *TrashHandler) ListTrash(w http.ResponseWriter, req *http.Request) {
+	pagination := helpers.ParsePagination(req)
+
+	result, err := h.service.ListTrash(context.Background(), services.ListTrashRequest{
+		Type:     req.URL.Query().Get("type"),
+		Page:     pagination.Page,
+		PageSize: pagination.PageSize,
+	})
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+
+	helpers.RenderSuccess(w, req, result)
+}
+
+// RestoreTrash 批量恢复回收站中的项
+func (h *TrashHandler) RestoreTrash(w http.ResponseWriter, req *http.Request) {
+	var reqBody services.TrashBatchRequest
+	if err := render.DecodeJSON(req.Body, &reqBody); err != nil {
+		helpers.RenderError(w, req, errors.NewInvalidParamError(errors.ErrCodeBadRequest, "请求参数错误", err))
+		return
+	}
+
+	result, err := h.service.Restore(context.Background(), reqBody)
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+
+	helpers.RenderSuccess(w, req, result)
+}
+
+// PurgeTrash 批量彻底删除回收站中的项
+func (h *TrashHandler) PurgeTrash(w http.ResponseWriter, req *http.Request) {
+	var reqBody services.TrashBatchRequest
+	if err := render.DecodeJSON(req.Body, &reqBody); err != nil {
+		helpers.RenderError(w, req, errors.NewInvalidParamError(errors.ErrCodeBadRequest, "请求参数错误", err))
+		return
+	}
+
+	result, err := h.service.Purge(context.Background(), reqBody)
+	if err != nil {
+		helpers.RenderError(w, req, err)
+		return
+	}
+
+	helpers.RenderSuccess(w, req, result)
+}
@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"aiflow/internal/storage"
+)
+
+// zip包导入限制，防止解压炸弹
+const (
+	// maxZipEntries zip包最大条目数
+	maxZipEntries = 2000
+	// maxZipTotalSize zip包解压后的总大小上限
+	maxZipTotalSize = 200 << 20
+	// maxZipCompressionRatio 单个条目允许的最大压缩比
+	maxZipCompressionRatio = 100
+	// maxSkillMarkdownSize SKILL.md文件大小上限
+	maxSkillMarkdownSize = 1 << 20
+)
+
+// skillMainFile 技能主文件名，zip包中每个该文件所在目录视为一个技能
+const skillMainFile = "SKILL.md"
+
+// 技能导入结果状态
+const (
+	skillImportCreated = "created"
+	skillImportUpdated = "updated"
+	skillImportFailed  = "failed"
+)
+
+// skillImportResult 单个技能的导入结果
+type skillImportResult struct {
+	Path   string `json:"path"`             // SKILL.md在zip包内的路径
+	Name   string `json:"name,omitempty"`   // 技能名称
+	Status string `json:"status"`           // 导入状态：created、updated、failed
+	Files  int    `json:"files"`            // 导入的技能文件数
+	Reason string `json:"reason,omitempty"` // 失败原因
+}
+
+// zipSkillEntry zip包中的一个技能：SKILL.md及其所在目录下的其他文件
+type zipSkillEntry struct {
+	root     string      // 技能在zip包内的目录，根目录为空
+	markdown *zip.File   // SKILL.md
+	files    []*zip.File // 技能目录下的其他文件
+}
+
+// handleZipImport 处理zip包导入技能
+// zip包中每个SKILL.md所在的目录视为一个技能，目录下的其他文件导入技能资源目录
+// 嵌套的SKILL.md属于更深层的技能，文件归属离它最近的技能目录
+func (h *UploadHandler) handleZipImport(fileName string) ([]skillImportResult, error) {
+	reader, err := zip.OpenReader(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("打开zip文件失败: %v", err)
+	}
+	defer reader.Close()
+
+	skills, err := collectZipSkills(reader.File)
+	if err != nil {
+		return nil, err
+	}
+
+	budget := &zipBudget{remaining: maxZipTotalSize}
+	results := make([]skillImportResult, 0, len(skills))
+	for _, entry := range skills {
+		results = append(results, h.importZipSkill(entry, budget))
+	}
+	return results, nil
+}
+
+// collectZipSkills 校验zip条目并按SKILL.md所在目录分组
+func collectZipSkills(files []*zip.File) ([]*zipSkillEntry, error) {
+	if len(files) > maxZipEntries {
+		return nil, fmt.Errorf("zip包条目过多（%d），上限 %d", len(files), maxZipEntries)
+	}
+
+	var declaredSize uint64
+	var regularFiles []*zip.File
+	roots := map[string]*zipSkillEntry{}
+	for _, file := range files {
+		if !file.Mode().IsRegular() {
+			// 跳过目录、符号链接等非普通文件
+			continue
+		}
+		name, err := storage.CleanRelPath(file.Name)
+		if err != nil {
+			return nil, fmt.Errorf("zip包包含非法路径: %s", file.Name)
+		}
+		if isIgnoredZipPath(name) {
+			continue
+		}
+
+		declaredSize += file.UncompressedSize64
+		if declaredSize > maxZipTotalSize {
+			return nil, fmt.Errorf("zip包解压后超过 %d 字节", maxZipTotalSize)
+		}
+		if file.CompressedSize64 > 0 && file.UncompressedSize64/file.CompressedSize64 > maxZipCompressionRatio {
+			return nil, fmt.Errorf("zip包条目 %s 压缩比异常", name)
+		}
+
+		if path.Base(name) == skillMainFile {
+			root := path.Dir(name)
+			if root == "." {
+				root = ""
+			}
+			roots[root] = &zipSkillEntry{root: root, markdown: file}
+			continue
+		}
+		regularFiles = append(regularFiles, file)
+	}
+
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("zip包中未找到%s文件", skillMainFile)
+	}
+
+	// 文件归属离它最近的技能目录
+	for _, file := range regularFiles {
+		name, _ := storage.CleanRelPath(file.Name)
+		for dir := path.Dir(name); ; dir = path.Dir(dir) {
+			if dir == "." {
+				dir = ""
+			}
+			if entry, ok := roots[dir]; ok {
+				entry.files = append(entry.files, file)
+				break
+			}
+			if dir == "" {
+				break
+			}
+		}
+	}
+
+	skills := make([]*zipSkillEntry, 0, len(roots))
+	for _, entry := range roots {
+		skills = append(skills, entry)
+	}
+	sort.Slice(skills, func(i, j int) bool {
+		return skills[i].root < skills[j].root
+	})
+	return skills, nil
+}
+
+// importZipSkill 导入zip包中的一个技能，先保存技能再导入技能文件
+func (h *UploadHandler) importZipSkill(entry *zipSkillEntry, budget *zipBudget) skillImportResult {
+	result := skillImportResult{Path: entry.markdown.Name}
+
+	content, err := budget.readAll(entry.markdown, maxSkillMarkdownSize)
+	if err != nil {
+		result.Status = skillImportFailed
+		result.Reason = err.Error()
+		return result
+	}
+
+	skill, created, err := h.service.ImportSkillMarkdown(context.Background(), content)
+	if err != nil {
+		result.Status = skillImportFailed
+		result.Reason = err.Error()
+		return result
+	}
+	result.Name = skill.Name
+	result.Status = skillImportUpdated
+	if created {
+		result.Status = skillImportCreated
+	}
+
+	if _, err = h.store.SkillDir(skill.ResourceDir); err != nil && len(entry.files) > 0 {
+		result.Status = skillImportFailed
+		result.Reason = fmt.Sprintf("技能已保存，但资源目录非法，无法导入技能文件: %v", err)
+		return result
+	}
+
+	for _, file := range entry.files {
+		name, _ := storage.CleanRelPath(file.Name)
+		relPath := strings.TrimPrefix(strings.TrimPrefix(name, entry.root), "/")
+		if err = budget.save(h.store, skill.ResourceDir, relPath, file); err != nil {
+			result.Status = skillImportFailed
+			result.Reason = fmt.Sprintf("技能已保存，但导入文件 %s 失败: %v", relPath, err)
+			return result
+		}
+		result.Files++
+	}
+	return result
+}
+
+// isIgnoredZipPath 判断是否为压缩工具生成的无关文件
+func isIgnoredZipPath(name string) bool {
+	return strings.HasPrefix(name, "__MACOSX/") || path.Base(name) == ".DS_Store"
+}
+
+// zipBudget 记录zip包剩余可解压字节数
+// 按实际解压出的字节计数，不信任zip头中声明的大小
+type zipBudget struct {
+	remaining int64
+}
+
+// open 打开zip条目，读取超过剩余额度时返回错误
+func (b *zipBudget) open(file *zip.File) (io.ReadCloser, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	return &budgetReader{ReadCloser: rc, budget: b}, nil
+}
+
+// readAll 读取zip条目全部内容，超过limit字节时返回错误
+func (b *zipBudget) readAll(file *zip.File, limit int64) ([]byte, error) {
+	rc, err := b.open(file)
+	if err != nil {
+		return nil, fmt.Errorf("读取%s失败: %v", file.Name, err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("读取%s失败: %v", file.Name, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%s超过 %d 字节", file.Name, limit)
+	}
+	return data, nil
+}
+
+// save 将zip条目保存为技能文件
+func (b *zipBudget) save(store *storage.SkillFileStore, resourceDir, relPath string, file *zip.File) error {
+	rc, err := b.open(file)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	_, err = store.Save(resourceDir, relPath, rc)
+	return err
+}
+
+// budgetReader 读取时扣减zipBudget额度的Reader
+type budgetReader struct {
+	io.ReadCloser
+	budget *zipBudget
+}
+
+// Read 读取数据并扣减额度，额度耗尽时返回错误
+func (r *budgetReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.budget.remaining -= int64(n)
+	if r.budget.remaining < 0 {
+		return n, fmt.Errorf("zip包解压后超过 %d 字节", maxZipTotalSize)
+	}
+	return n, err
+}
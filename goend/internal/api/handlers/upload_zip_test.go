@@ -0,0 +1,305 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"aiflow/internal/config"
+	"aiflow/internal/models"
+	"aiflow/internal/repositories"
+	"aiflow/internal/services"
+	"aiflow/internal/storage"
+)
+
+// testZipEntry 测试zip包中的一个条目
+type testZipEntry struct {
+	name    string
+	content string
+	// store 为true时不压缩存储，默认Deflate压缩
+	store bool
+}
+
+// newTestUploadHandler 创建使用临时数据库和技能目录的上传处理器
+func newTestUploadHandler(t *testing.T) (*UploadHandler, *repositories.Repository, *storage.SkillFileStore) {
+	t.Helper()
+	repo, err := repositories.NewRepository(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("创建测试仓库失败: %v", err)
+	}
+	store := storage.NewSkillFileStore(filepath.Join(t.TempDir(), "skills"), config.DefaultSkillMaxFileSize)
+	return NewUploadHandler(services.NewSkillService(repo, store), store), repo, store
+}
+
+// writeTestZip 将条目写入临时zip文件，返回文件路径
+func writeTestZip(t *testing.T, entries []testZipEntry) string {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for _, entry := range entries {
+		header := &zip.FileHeader{Name: entry.name, Method: zip.Deflate}
+		if entry.store {
+			header.Method = zip.Store
+		}
+		w, err := writer.CreateHeader(header)
+		if err != nil {
+			t.Fatalf("创建zip条目失败: %v", err)
+		}
+		if _, err := w.Write([]byte(entry.content)); err != nil {
+			t.Fatalf("写入zip条目失败: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("关闭zip失败: %v", err)
+	}
+
+	fileName := filepath.Join(t.TempDir(), "skills.zip")
+	if err := os.WriteFile(fileName, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("保存zip失败: %v", err)
+	}
+	return fileName
+}
+
+// testSkillMarkdown 生成测试用的SKILL.md内容
+func testSkillMarkdown(name, description string) string {
+	return "---\nname: " + name + "\ndescription: " + description + "\n---\n# " + name + "\n"
+}
+
+// TestHandleZipImport 测试zip包导入多个技能及其资源文件
+func TestHandleZipImport(t *testing.T) {
+	handler, repo, store := newTestUploadHandler(t)
+	ctx := context.Background()
+
+	fileName := writeTestZip(t, []testZipEntry{
+		{name: "pdf/SKILL.md", content: testSkillMarkdown("pdf-processing", "Extract text from PDF files. Use when reading PDFs.")},
+		{name: "pdf/scripts/extract.py", content: "print('extract')"},
+		{name: "pdf/forms/SKILL.md", content: testSkillMarkdown("pdf-forms", "Fill PDF forms. Use when filling forms.")},
+		{name: "pdf/forms/template.txt", content: "form template", store: true},
+		{name: "office/SKILL.md", content: testSkillMarkdown("office-docs", "Edit office documents. Use when editing docx.")},
+		{name: "office/reference/README.md", content: "# reference"},
+		{name: "__MACOSX/pdf/._SKILL.md", content: "ignored"},
+		{name: "notes.txt", content: "不属于任何技能"},
+	})
+
+	results, err := handler.handleZipImport(fileName)
+	if err != nil {
+		t.Fatalf("导入zip包失败: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("应导入3个技能，实际: %+v", results)
+	}
+
+	files := map[string]map[string]string{
+		"office-docs":    {"reference/README.md": "# reference"},
+		"pdf-processing": {"scripts/extract.py": "print('extract')"},
+		"pdf-forms":      {"template.txt": "form template"},
+	}
+	for _, result := range results {
+		want, ok := files[result.Name]
+		if !ok || result.Status != skillImportCreated || result.Files != len(want) {
+			t.Errorf("导入结果不符合预期: %+v", result)
+			continue
+		}
+		skill, err := repo.GetSkillByName(ctx, result.Name)
+		if err != nil {
+			t.Fatalf("获取技能%s失败: %v", result.Name, err)
+		}
+		for relPath, content := range want {
+			data, err := store.Read(skill.ResourceDir, relPath, 1<<20)
+			if err != nil || string(data) != content {
+				t.Errorf("技能%s的文件%s内容不符: %q, %v", result.Name, relPath, data, err)
+			}
+		}
+		// 嵌套技能的文件不属于外层技能
+		if _, err := store.Read(skill.ResourceDir, "forms/template.txt", 1<<20); err == nil {
+			t.Errorf("技能%s不应包含嵌套技能的文件", result.Name)
+		}
+	}
+
+	// 再次导入时更新已有技能
+	results, err = handler.handleZipImport(fileName)
+	if err != nil || len(results) != 3 || results[0].Status != skillImportUpdated {
+		t.Errorf("再次导入应更新已有技能: %+v, %v", results, err)
+	}
+}
+
+// TestHandleZipImport_Limits 测试zip包导入的条目数、压缩比和路径限制
+func TestHandleZipImport_Limits(t *testing.T) {
+	handler, repo, store := newTestUploadHandler(t)
+	ctx := context.Background()
+	markdown := testZipEntry{name: "SKILL.md", content: testSkillMarkdown("pdf-processing", "Extract text from PDF files. Use when reading PDFs.")}
+
+	tooMany := []testZipEntry{markdown}
+	for i := range maxZipEntries {
+		tooMany = append(tooMany, testZipEntry{name: fmt.Sprintf("files/%04d.txt", i), content: "x", store: true})
+	}
+	bomb := testZipEntry{name: "data/zeros.bin", content: strings.Repeat("\x00", 1<<20)}
+
+	tests := []struct {
+		name    string
+		entries []testZipEntry
+		wantErr string
+	}{
+		{name: "条目过多", entries: tooMany, wantErr: "条目过多"},
+		{name: "压缩比超过上限", entries: []testZipEntry{markdown, bomb}, wantErr: "压缩比异常"},
+		{name: "上级目录路径", entries: []testZipEntry{markdown, {name: "../escape.txt", content: "x"}}, wantErr: "非法路径"},
+		{name: "技能内的上级目录路径", entries: []testZipEntry{markdown, {name: "scripts/../../escape.txt", content: "x"}}, wantErr: "非法路径"},
+		{name: "绝对路径", entries: []testZipEntry{markdown, {name: "/etc/escape.txt", content: "x"}}, wantErr: "非法路径"},
+		{name: "没有SKILL.md", entries: []testZipEntry{{name: "README.md", content: "x"}}, wantErr: "未找到SKILL.md"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := handler.handleZipImport(writeTestZip(t, tt.entries))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("应返回包含%q的错误，实际: %v, %+v", tt.wantErr, err, results)
+			}
+		})
+	}
+
+	// 被拒绝的zip包不导入任何技能，也不在技能目录外写入文件
+	if skills, _ := repo.ListAllSkills(ctx); len(skills) != 0 {
+		t.Errorf("被拒绝的zip包不应导入技能: %d", len(skills))
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(store.BaseDir()), "escape.txt")); !os.IsNotExist(err) {
+		t.Errorf("不应在技能目录外写入文件: %v", err)
+	}
+}
+
+// TestHandleZipImport_ExportRoundTrip 测试批量导出的zip包重新导入后技能内容和文件不变
+func TestHandleZipImport_ExportRoundTrip(t *testing.T) {
+	_, srcRepo, srcStore := newTestUploadHandler(t)
+	ctx := context.Background()
+
+	type exportedSkill struct {
+		skill models.Skill
+		tags  []string
+		files map[string]string
+	}
+	sources := []exportedSkill{
+		{
+			skill: models.Skill{
+				Name:        "pdf-processing",
+				Description: "Extract text from PDF files. Use when reading PDFs.",
+				Detail:      "# PDF\n\nRun `scripts/extract.py`.",
+				License:     "MIT",
+				Metadata:    models.SkillMetadata{"author": "alice", "version": "1.0"},
+				ResourceDir: "pdf",
+			},
+			tags:  []string{"docs", "pdf"},
+			files: map[string]string{"scripts/extract.py": "print('extract')", "reference/forms.md": "# forms"},
+		},
+		{
+			skill: models.Skill{
+				Name:        "office-docs",
+				Description: "Edit office documents. Use when editing docx.",
+				Detail:      "# Office",
+				ResourceDir: "office",
+			},
+			tags:  []string{"docs"},
+			files: map[string]string{"template.docx": "PK\x03\x04binary"},
+		},
+		{
+			// YAML头中的值包含冒号、#、[、引号、换行，或会被解析为其他类型时，导出后仍能重新导入
+			skill: models.Skill{
+				Name:          "null",
+				Description:   "Excel analysis: summarize sheets.\n[beta] #1 tool, Use when: it's a spreadsheet",
+				Detail:        "# Sheets",
+				License:       "#MIT: see LICENSE",
+				Compatibility: "[python] >= 3.10",
+				AllowedTools:  "Bash(git:*) 'Read'",
+				ResourceDir:   "sheets",
+			},
+			tags: []string{"#1 tool", "[beta]", "it's", "key: value", "true"},
+		},
+		{
+			// 没有资源目录时按技能名称确定zip包中的目录
+			skill: models.Skill{
+				Name:        "image-resize",
+				Description: "Resize images. Use when scaling images.",
+				Detail:      "# Resize",
+			},
+		},
+	}
+	for i := range sources {
+		src := &sources[i]
+		if err := srcRepo.CreateSkill(ctx, &src.skill); err != nil {
+			t.Fatalf("创建技能%s失败: %v", src.skill.Name, err)
+		}
+		if _, err := srcRepo.SetSkillTagsByName(ctx, src.skill.ID, src.tags); err != nil {
+			t.Fatalf("设置标签失败: %v", err)
+		}
+		for relPath, content := range src.files {
+			if _, err := srcStore.Save(src.skill.ResourceDir, relPath, strings.NewReader(content)); err != nil {
+				t.Fatalf("保存技能文件失败: %v", err)
+			}
+		}
+	}
+
+	srcService := services.NewSkillService(srcRepo, srcStore)
+	skills, err := srcService.GetSkillsForExport(ctx, services.ExportSkillsRequest{})
+	if err != nil || len(skills) != len(sources) {
+		t.Fatalf("获取待导出技能失败: %d, %v", len(skills), err)
+	}
+	var buf bytes.Buffer
+	if err := srcService.WriteSkillsZip(skills, &buf); err != nil {
+		t.Fatalf("导出zip包失败: %v", err)
+	}
+	fileName := filepath.Join(t.TempDir(), "export.zip")
+	if err := os.WriteFile(fileName, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("保存zip失败: %v", err)
+	}
+
+	// 导入到另一个空的数据库和技能目录
+	handler, repo, store := newTestUploadHandler(t)
+	results, err := handler.handleZipImport(fileName)
+	if err != nil || len(results) != len(sources) {
+		t.Fatalf("重新导入zip包失败: %+v, %v", results, err)
+	}
+	for _, src := range sources {
+		skill, err := repo.GetSkillByName(ctx, src.skill.Name)
+		if err != nil {
+			t.Fatalf("获取导入的技能%s失败: %v", src.skill.Name, err)
+		}
+		if skill.Description != src.skill.Description || skill.Detail != src.skill.Detail ||
+			skill.License != src.skill.License || skill.Compatibility != src.skill.Compatibility ||
+			skill.AllowedTools != src.skill.AllowedTools || !maps.Equal(skill.Metadata, src.skill.Metadata) {
+			t.Errorf("技能%s导入后内容不一致: %+v", src.skill.Name, skill)
+		}
+		var tags []string
+		for _, tag := range skill.Tags {
+			tags = append(tags, tag.Name)
+		}
+		slices.Sort(tags)
+		if !slices.Equal(tags, src.tags) {
+			t.Errorf("技能%s导入后标签不一致: %v", src.skill.Name, tags)
+		}
+		files, err := store.List(skill.ResourceDir)
+		if err != nil {
+			t.Fatalf("列出技能%s的文件失败: %v", src.skill.Name, err)
+		}
+		got := map[string]string{}
+		for _, file := range files {
+			if file.Path == "SKILL.md" {
+				continue
+			}
+			data, err := store.Read(skill.ResourceDir, file.Path, 1<<20)
+			if err != nil {
+				t.Fatalf("读取技能文件失败: %v", err)
+			}
+			got[file.Path] = string(data)
+		}
+		if len(src.files) == 0 {
+			src.files = map[string]string{}
+		}
+		if !maps.Equal(got, src.files) {
+			t.Errorf("技能%s导入后文件不一致: %v", src.skill.Name, got)
+		}
+	}
+}
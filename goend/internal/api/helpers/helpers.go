@@ -4,6 +4,7 @@ import (
 	"aiflow/internal/errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -82,6 +83,22 @@ func ParseIntParam(req *http.Request, paramName string, defaultValue int64) int6
 	return val
 }
 
+// ParseBoolParam 解析布尔查询参数，接受 true/false/1/0
+// 参数不存在或解析失败时返回默认值
+func ParseBoolParam(req *http.Request, paramName string, defaultValue bool) bool {
+	str := req.URL.Query().Get(paramName)
+	if str == "" {
+		return defaultValue
+	}
+
+	val, err := strconv.ParseBool(str)
+	if err != nil {
+		return defaultValue
+	}
+
+	return val
+}
+
 // ParseUintParam 解析无符号整数查询参数
 // 参数不存在或解析失败时返回错误
 func ParseUintParam(req *http.Request, paramName string) (uint, error) {
@@ -97,3 +114,23 @@ func ParseUintParam(req *http.Request, paramName string) (uint, error) {
 
 	return uint(val), nil
 }
+
+// ParseUintListParam 解析逗号分隔的无符号整数列表查询参数，如 ids=1,2,3
+// 同一参数出现多次时合并，参数不存在时返回空列表
+func ParseUintListParam(req *http.Request, paramName string) ([]uint, error) {
+	var result []uint
+	for _, str := range req.URL.Query()[paramName] {
+		for _, part := range strings.Split(str, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			val, err := strconv.ParseUint(part, 10, 32)
+			if err != nil {
+				return nil, errors.NewInvalidParamError(errors.ErrCodeBadRequestParam, "无效的参数: "+paramName, err)
+			}
+			result = append(result, uint(val))
+		}
+	}
+	return result, nil
+}
@@ -17,8 +17,9 @@ type Response struct {
 
 // ErrorInfo 错误信息结构
 type ErrorInfo struct {
-	Code    string `json:"code"`    // 错误码
-	Message string `json:"message"` // 错误消息
+	Code    string              `json:"code"`             // 错误码
+	Message string              `json:"message"`          // 错误消息
+	Fields  []errors.FieldError `json:"fields,omitempty"` // 字段级校验错误
 }
 
 // RenderSuccess 渲染成功响应
@@ -63,6 +64,7 @@ func RenderError(w http.ResponseWriter, req *http.Request, err error) {
 		errorInfo = &ErrorInfo{
 			Code:    string(appErr.Code),
 			Message: appErr.Message,
+			Fields:  appErr.Fields,
 		}
 	} else {
 		// 默认内部服务器错误
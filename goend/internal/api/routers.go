@@ -2,8 +2,11 @@ package api
 
 import (
 	"aiflow/internal/api/handlers"
+	"aiflow/internal/config"
 	"aiflow/internal/repositories"
 	"aiflow/internal/services"
+	"aiflow/internal/storage"
+	"context"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -11,51 +14,126 @@ import (
 // Router API路由器
 type Router struct {
 	skillHandler   *handlers.SkillHandler
+	fileHandler    *handlers.SkillFileHandler
 	tagHandler     *handlers.TagHandler
+	suggestHandler *handlers.TagSuggestionHandler
 	uploadHandler  *handlers.UploadHandler
 	jobTaskHandler *handlers.JobTaskHandler
+	syncHandler    *handlers.SkillSyncHandler
+	reindexHandler *handlers.SkillReindexHandler
+	trashHandler   *handlers.TrashHandler
+
+	syncService    *services.SkillSyncService
+	reindexService *services.SkillReindexService
+	trashService   *services.TrashService
 }
 
 // NewRouter 创建新的API路由器
-func NewRouter(repo *repositories.Repository) *Router {
+func NewRouter(repo *repositories.Repository, store *storage.SkillFileStore, syncCfg config.SyncConfig, trashCfg config.TrashConfig) *Router {
 	// 初始化service层
-	skillService := services.NewSkillService(repo)
+	skillService := services.NewSkillService(repo, store)
+	skillFileService := services.NewSkillFileService(repo, store)
 	tagService := services.NewTagService(repo)
 	jobTaskService := services.NewJobTaskService(repo)
+	syncService := services.NewSkillSyncService(repo, skillService, syncCfg)
+	reindexService := services.NewSkillReindexService(repo)
+	trashService := services.NewTrashService(repo, skillService, trashCfg)
 
 	return &Router{
 		skillHandler:   handlers.NewSkillHandler(skillService),
+		fileHandler:    handlers.NewSkillFileHandler(skillFileService),
 		tagHandler:     handlers.NewTagHandler(tagService),
-		uploadHandler:  handlers.NewUploadHandler(repo),
+		suggestHandler: handlers.NewTagSuggestionHandler(services.NewTagSuggestionService(repo)),
+		uploadHandler:  handlers.NewUploadHandler(skillService, store),
 		jobTaskHandler: handlers.NewJobTaskHandler(jobTaskService),
+		syncHandler:    handlers.NewSkillSyncHandler(syncService),
+		reindexHandler: handlers.NewSkillReindexHandler(reindexService),
+		trashHandler:   handlers.NewTrashHandler(trashService),
+		syncService:    syncService,
+		reindexService: reindexService,
+		trashService:   trashService,
 	}
 }
 
+// StartSync 启动技能目录后台同步，未配置同步目录时不做处理
+func (r *Router) StartSync(ctx context.Context) {
+	r.syncService.Start(ctx)
+}
+
+// StartTrashSweep 启动回收站过期项的后台清理，保留天数小于0时不做处理
+func (r *Router) StartTrashSweep(ctx context.Context) {
+	r.trashService.Start(ctx)
+}
+
+// StartReindex 在后台重建所有技能的搜索索引
+func (r *Router) StartReindex(ctx context.Context) error {
+	_, err := r.reindexService.Start(ctx, 0)
+	return err
+}
+
 // RegisterRoutes 注册API路由
 func (r *Router) RegisterRoutes(chiRouter chi.Router) {
 	// API根路径
 	chiRouter.Route("/api", func(api chi.Router) {
 		// 标签相关路由
 		api.Route("/tags", func(tags chi.Router) {
-			tags.Get("/", r.tagHandler.ListTags)         // 获取所有标签
-			tags.Post("/", r.tagHandler.CreateTag)       // 创建标签
-			tags.Get("/{id}", r.tagHandler.GetTag)       // 根据ID获取标签
-			tags.Put("/{id}", r.tagHandler.UpdateTag)    // 更新标签
-			tags.Delete("/{id}", r.tagHandler.DeleteTag) // 删除标签
+			tags.Get("/", r.tagHandler.ListTags)             // 获取所有标签
+			tags.Post("/", r.tagHandler.CreateTag)           // 创建标签
+			tags.Get("/tree", r.tagHandler.GetTagTree)       // 获取标签树
+			tags.Get("/{id}", r.tagHandler.GetTag)           // 根据ID获取标签
+			tags.Put("/{id}", r.tagHandler.UpdateTag)        // 更新标签
+			tags.Delete("/{id}", r.tagHandler.DeleteTag)     // 删除标签
+			tags.Post("/{id}/move", r.tagHandler.MoveTag)    // 移动标签到新的父标签下
+			tags.Post("/{id}/merge", r.tagHandler.MergeTags) // 将其他标签合并到该标签
+		})
+
+		// 标签建议路由
+		api.Route("/tag-suggestions", func(suggestions chi.Router) {
+			suggestions.Get("/", r.suggestHandler.ListSuggestions)              // 获取标签建议列表
+			suggestions.Post("/generate", r.suggestHandler.GenerateSuggestions) // 为技能生成标签建议
+			suggestions.Post("/{id}/accept", r.suggestHandler.AcceptSuggestion) // 采纳标签建议
+			suggestions.Post("/{id}/reject", r.suggestHandler.RejectSuggestion) // 拒绝标签建议
 		})
 
 		// 技能相关路由
 		api.Route("/skills", func(skills chi.Router) {
-			skills.Get("/", r.skillHandler.ListSkills)              // 获取所有技能
-			skills.Post("/", r.skillHandler.CreateSkill)            // 创建技能
-			skills.Get("/trash", r.skillHandler.ListDeletedSkills)  // 获取回收站技能列表
-			skills.Get("/{id}", r.skillHandler.GetSkill)            // 根据ID获取技能
-			skills.Put("/{id}", r.skillHandler.UpdateSkill)         // 更新技能
-			skills.Delete("/{id}", r.skillHandler.DeleteSkill)      // 删除技能（伪删除，进入回收站）
-			skills.Post("/{id}/restore", r.skillHandler.RestoreSkill) // 恢复回收站中的技能
-			skills.Delete("/{id}/permanent", r.skillHandler.PermanentDeleteSkill) // 彻底删除技能
-			skills.Get("/export", r.skillHandler.ExportSkills)      // 导出所有技能为MD格式
-			skills.Get("/{id}/export", r.skillHandler.ExportSkills) // 导出单个技能为MD格式
+			skills.Get("/", r.skillHandler.ListSkills)                                       // 获取所有技能
+			skills.Post("/", r.skillHandler.CreateSkill)                                     // 创建技能
+			skills.Get("/lint", r.skillHandler.LintSkills)                                   // 检查技能是否符合规范
+			skills.Get("/search", r.skillHandler.SearchSkills)                               // 按关键词全文检索技能
+			skills.Get("/reindex", r.reindexHandler.GetReindexStatus)                        // 获取索引重建进度
+			skills.Post("/reindex", r.reindexHandler.StartReindex)                           // 后台重建所有技能的搜索索引
+			skills.Get("/trash", r.skillHandler.ListDeletedSkills)                           // 获取回收站技能列表
+			skills.Get("/{id}", r.skillHandler.GetSkill)                                     // 根据ID获取技能
+			skills.Put("/{id}", r.skillHandler.UpdateSkill)                                  // 更新技能
+			skills.Delete("/{id}", r.skillHandler.DeleteSkill)                               // 删除技能（伪删除，进入回收站）
+			skills.Post("/{id}/restore", r.skillHandler.RestoreSkill)                        // 恢复回收站中的技能
+			skills.Delete("/{id}/permanent", r.skillHandler.PermanentDeleteSkill)            // 彻底删除技能
+			skills.Get("/export", r.skillHandler.ExportSkills)                               // 批量导出技能为zip包
+			skills.Get("/{id}/export", r.skillHandler.ExportSkills)                          // 导出单个技能为MD格式
+			skills.Get("/{id}/files", r.fileHandler.ListSkillFiles)                          // 获取技能文件列表
+			skills.Post("/{id}/files", r.fileHandler.UploadSkillFile)                        // 上传技能文件
+			skills.Get("/{id}/files/*", r.fileHandler.DownloadSkillFile)                     // 下载技能文件
+			skills.Delete("/{id}/files/*", r.fileHandler.DeleteSkillFile)                    // 删除技能文件
+			skills.Get("/{id}/revisions", r.skillHandler.ListSkillRevisions)                 // 获取技能修订列表
+			skills.Get("/{id}/revisions/diff", r.skillHandler.DiffSkillRevisions)            // 对比两个修订
+			skills.Get("/{id}/revisions/{revision}", r.skillHandler.GetSkillRevision)        // 获取指定修订
+			skills.Post("/{id}/revisions/{revision}/rollback", r.skillHandler.RollbackSkill) // 回滚到指定修订
+		})
+
+		// 技能目录同步路由
+		api.Route("/sync", func(sync chi.Router) {
+			sync.Get("/", r.syncHandler.GetSyncStatus)                              // 获取同步状态
+			sync.Post("/", r.syncHandler.RunSync)                                   // 立即同步
+			sync.Get("/conflicts", r.syncHandler.ListSyncConflicts)                 // 获取同步冲突列表
+			sync.Post("/conflicts/{id}/resolve", r.syncHandler.ResolveSyncConflict) // 处理同步冲突
+		})
+
+		// 回收站路由，统一管理已删除的技能、任务和标签
+		api.Route("/trash", func(trash chi.Router) {
+			trash.Get("/", r.trashHandler.ListTrash)            // 获取回收站列表
+			trash.Post("/restore", r.trashHandler.RestoreTrash) // 批量恢复
+			trash.Post("/purge", r.trashHandler.PurgeTrash)     // 批量彻底删除
 		})
 
 		// 文件上传路由
@@ -63,15 +141,15 @@ func (r *Router) RegisterRoutes(chiRouter chi.Router) {
 
 		// 任务相关路由
 		api.Route("/jobtasks", func(jobtasks chi.Router) {
-			jobtasks.Get("/", r.jobTaskHandler.ListJobTasks)                     // 获取任务列表
-			jobtasks.Post("/", r.jobTaskHandler.CreateJobTask)                   // 创建任务
-			jobtasks.Post("/export", r.jobTaskHandler.BatchExportJobTasks)       // 批量导出任务
-			jobtasks.Get("/projects", r.jobTaskHandler.GetAllJobTaskProjects)    // 获取所有项目列表（去重）
-			jobtasks.Get("/trash", r.jobTaskHandler.ListDeletedJobTasks)         // 获取回收站列表
-			jobtasks.Get("/{id}", r.jobTaskHandler.GetJobTask)                   // 根据ID获取任务
-			jobtasks.Put("/{id}", r.jobTaskHandler.UpdateJobTask)                // 更新任务
-			jobtasks.Delete("/{id}", r.jobTaskHandler.DeleteJobTask)             // 删除任务（伪删除，进入回收站）
-			jobtasks.Post("/{id}/restore", r.jobTaskHandler.RestoreJobTask)      // 恢复回收站中的任务
+			jobtasks.Get("/", r.jobTaskHandler.ListJobTasks)                            // 获取任务列表
+			jobtasks.Post("/", r.jobTaskHandler.CreateJobTask)                          // 创建任务
+			jobtasks.Post("/export", r.jobTaskHandler.BatchExportJobTasks)              // 批量导出任务
+			jobtasks.Get("/projects", r.jobTaskHandler.GetAllJobTaskProjects)           // 获取所有项目列表（去重）
+			jobtasks.Get("/trash", r.jobTaskHandler.ListDeletedJobTasks)                // 获取回收站列表
+			jobtasks.Get("/{id}", r.jobTaskHandler.GetJobTask)                          // 根据ID获取任务
+			jobtasks.Put("/{id}", r.jobTaskHandler.UpdateJobTask)                       // 更新任务
+			jobtasks.Delete("/{id}", r.jobTaskHandler.DeleteJobTask)                    // 删除任务（伪删除，进入回收站）
+			jobtasks.Post("/{id}/restore", r.jobTaskHandler.RestoreJobTask)             // 恢复回收站中的任务
 			jobtasks.Delete("/{id}/permanent", r.jobTaskHandler.PermanentDeleteJobTask) // 彻底删除任务
 		})
 	})
@@ -23,6 +23,26 @@ const (
 	DefaultLogLevel = "info"
 	// DBPath 默认数据库文件路径
 	DBPath = "./db/aiflow.db"
+	// DefaultJobNoFormat 默认任务编号模板
+	DefaultJobNoFormat = "JT-{project}-{date}-{seq}"
+	// DefaultJobNoSeqWidth 默认任务编号序号最小位数
+	DefaultJobNoSeqWidth = 3
+	// DefaultSkillBaseDir 默认技能文件基准目录，每个技能的文件存放在其资源目录下
+	DefaultSkillBaseDir = "./skills"
+	// DefaultSkillMaxFileSize 默认单个技能文件大小上限（字节）
+	DefaultSkillMaxFileSize = 10 << 20
+	// DefaultTrashSweepInterval 默认回收站清理间隔（秒）
+	DefaultTrashSweepInterval = 3600
+)
+
+// 任务编号模板占位符
+const (
+	// JobNoPlaceholderProject 项目代号占位符
+	JobNoPlaceholderProject = "{project}"
+	// JobNoPlaceholderDate 日期占位符，格式为20060102
+	JobNoPlaceholderDate = "{date}"
+	// JobNoPlaceholderSeq 当日序号占位符
+	JobNoPlaceholderSeq = "{seq}"
 )
 
 // 有效日志等级集合
@@ -41,9 +61,15 @@ var validOutputTypes = map[string]bool{
 
 // Config 定义整个应用的配置结构
 type Config struct {
-	Server `yaml:"server"`
-	Log    LogConfig `yaml:"log"`
-	DB     DBConfig  `yaml:"db"`
+	Server    `yaml:"server"`
+	Log       LogConfig       `yaml:"log"`
+	DB        DBConfig        `yaml:"db"`
+	Job       JobConfig       `yaml:"job"`
+	Skill     SkillConfig     `yaml:"skill"`
+	Sync      SyncConfig      `yaml:"sync"`
+	Search    SearchConfig    `yaml:"search"`
+	Embedding EmbeddingConfig `yaml:"embedding"`
+	Trash     TrashConfig     `yaml:"trash"`
 }
 
 // Server 定义服务器相关配置
@@ -68,6 +94,59 @@ type DBConfig struct {
 	Path string `yaml:"path"` // 数据库文件路径
 }
 
+// JobConfig 定义任务相关配置
+type JobConfig struct {
+	NoFormat string `yaml:"no_format"` // 任务编号模板，必须包含{project}、{date}、{seq}占位符
+	SeqWidth int    `yaml:"seq_width"` // 序号最小位数，不足时左侧补零
+}
+
+// SkillConfig 定义技能文件相关配置
+type SkillConfig struct {
+	BaseDir     string `yaml:"base_dir"`      // 技能文件基准目录，技能文件存放在 base_dir/<资源目录>/ 下
+	MaxFileSize int64  `yaml:"max_file_size"` // 单个技能文件大小上限（字节）
+}
+
+// SyncConfig 定义技能目录同步相关配置
+type SyncConfig struct {
+	Dir          string `yaml:"dir"`           // 技能同步目录，目录结构为 dir/<技能名>/SKILL.md，为空时不启用同步
+	PollInterval int    `yaml:"poll_interval"` // 轮询间隔（秒），0表示只在启动时同步一次
+	WriteBack    bool   `yaml:"write_back"`    // 是否将数据库中的技能修改写回SKILL.md文件
+}
+
+// SearchConfig 定义技能搜索分词相关配置
+// 修改后重启服务时会自动重建技能搜索索引
+type SearchConfig struct {
+	UserDicts     []string            `yaml:"user_dicts"`     // gse用户词典文件，每行格式为 "词 [词频] [词性]"
+	Stopwords     []string            `yaml:"stopwords"`      // 停用词，建立索引和搜索时都会忽略
+	StopwordFiles []string            `yaml:"stopword_files"` // 停用词文件，每行一个词
+	Synonyms      map[string][]string `yaml:"synonyms"`       // 同义词，键为标准词，值为它的同义词
+}
+
+// 向量化方式
+const (
+	// EmbeddingProviderLocal 本地哈希n-gram向量化，不依赖外部服务
+	EmbeddingProviderLocal = "local"
+	// EmbeddingProviderOpenAI OpenAI兼容的 /embeddings 接口
+	EmbeddingProviderOpenAI = "openai"
+)
+
+// EmbeddingConfig 定义技能语义搜索的向量化配置
+// 更换向量化方式或模型后重启服务时会自动重新计算所有技能的向量
+type EmbeddingConfig struct {
+	Provider   string `yaml:"provider"`   // 向量化方式：local（默认）、openai
+	Dimensions int    `yaml:"dimensions"` // local方式的向量维度，默认512
+	BaseURL    string `yaml:"base_url"`   // openai方式的接口根地址，如 https://api.openai.com/v1
+	APIKey     string `yaml:"api_key"`    // openai方式的API Key，也可以用环境变量AIFLOW_EMBEDDING_API_KEY设置
+	Model      string `yaml:"model"`      // openai方式的模型名称，如 text-embedding-3-small
+	Timeout    int    `yaml:"timeout"`    // openai方式的请求超时（秒），默认30
+}
+
+// TrashConfig 定义回收站相关配置
+type TrashConfig struct {
+	RetentionDays int `yaml:"retention_days"` // 回收站保留天数，超过后自动彻底删除，默认0表示不自动清理
+	SweepInterval int `yaml:"sweep_interval"` // 检查过期项的间隔（秒），默认3600
+}
+
 // defaultConfig 内部默认配置
 var defaultConfig = &Config{
 	Server: Server{
@@ -86,6 +165,17 @@ var defaultConfig = &Config{
 	DB: DBConfig{
 		Path: DBPath, // 默认数据库路径
 	},
+	Job: JobConfig{
+		NoFormat: DefaultJobNoFormat,   // 默认任务编号模板
+		SeqWidth: DefaultJobNoSeqWidth, // 默认序号位数
+	},
+	Skill: SkillConfig{
+		BaseDir:     DefaultSkillBaseDir,     // 默认技能文件基准目录
+		MaxFileSize: DefaultSkillMaxFileSize, // 默认单个文件大小上限
+	},
+	Trash: TrashConfig{
+		SweepInterval: DefaultTrashSweepInterval, // 默认清理间隔
+	},
 }
 
 // FixWithDefault 修复Server配置的默认值
@@ -131,6 +221,39 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	// 验证任务编号模板，缺少任一占位符都可能导致编号重复
+	if c.Job.NoFormat != "" {
+		for _, placeholder := range []string{JobNoPlaceholderProject, JobNoPlaceholderDate, JobNoPlaceholderSeq} {
+			if !strings.Contains(c.Job.NoFormat, placeholder) {
+				return fmt.Errorf("任务编号模板 '%s' 缺少占位符 %s", c.Job.NoFormat, placeholder)
+			}
+		}
+	}
+	if c.Job.SeqWidth < 0 {
+		return fmt.Errorf("无效的任务编号序号位数 %d，不能小于0", c.Job.SeqWidth)
+	}
+	if c.Skill.MaxFileSize < 0 {
+		return fmt.Errorf("无效的技能文件大小上限 %d，不能小于0", c.Skill.MaxFileSize)
+	}
+	if c.Sync.PollInterval < 0 {
+		return fmt.Errorf("无效的技能同步轮询间隔 %d，不能小于0", c.Sync.PollInterval)
+	}
+	switch strings.ToLower(c.Embedding.Provider) {
+	case "", EmbeddingProviderLocal:
+	case EmbeddingProviderOpenAI:
+		if c.Embedding.BaseURL == "" || c.Embedding.Model == "" {
+			return fmt.Errorf("向量化方式为 openai 时必须配置 embedding.base_url 和 embedding.model")
+		}
+	default:
+		return fmt.Errorf("无效的向量化方式 '%s'，有效值: local, openai", c.Embedding.Provider)
+	}
+	if c.Embedding.Dimensions < 0 || c.Embedding.Timeout < 0 {
+		return fmt.Errorf("向量维度和请求超时不能小于0")
+	}
+	if c.Trash.SweepInterval < 0 {
+		return fmt.Errorf("无效的回收站清理间隔 %d，不能小于0", c.Trash.SweepInterval)
+	}
+
 	return nil
 }
 
@@ -169,10 +292,31 @@ func (c *Config) ApplyDefaults() {
 	if c.DB.Path == "" {
 		c.DB.Path = DBPath
 	}
+
+	// 应用任务默认值
+	if c.Job.NoFormat == "" {
+		c.Job.NoFormat = DefaultJobNoFormat
+	}
+	if c.Job.SeqWidth == 0 {
+		c.Job.SeqWidth = DefaultJobNoSeqWidth
+	}
+
+	// 应用技能文件默认值
+	if c.Skill.BaseDir == "" {
+		c.Skill.BaseDir = DefaultSkillBaseDir
+	}
+	if c.Skill.MaxFileSize == 0 {
+		c.Skill.MaxFileSize = DefaultSkillMaxFileSize
+	}
+
+	// 应用回收站默认值
+	if c.Trash.SweepInterval == 0 {
+		c.Trash.SweepInterval = DefaultTrashSweepInterval
+	}
 }
 
 // LoadFromEnv 从环境变量加载配置
-// 支持的环境变量: AIFLOW_ADDR, AIFLOW_LOG_LEVEL, AIFLOW_LOG_OUTPUT, AIFLOW_DB_PATH
+// 支持的环境变量: AIFLOW_ADDR, AIFLOW_LOG_LEVEL, AIFLOW_LOG_OUTPUT, AIFLOW_DB_PATH, AIFLOW_SKILL_DIR, AIFLOW_SYNC_DIR, AIFLOW_EMBEDDING_API_KEY
 func (c *Config) LoadFromEnv() {
 	// AIFLOW_ADDR -> Server.Addr
 	if addr := os.Getenv("AIFLOW_ADDR"); addr != "" {
@@ -193,6 +337,21 @@ func (c *Config) LoadFromEnv() {
 	if dbPath := os.Getenv("AIFLOW_DB_PATH"); dbPath != "" {
 		c.DB.Path = dbPath
 	}
+
+	// AIFLOW_SKILL_DIR -> Skill.BaseDir
+	if skillDir := os.Getenv("AIFLOW_SKILL_DIR"); skillDir != "" {
+		c.Skill.BaseDir = skillDir
+	}
+
+	// AIFLOW_SYNC_DIR -> Sync.Dir
+	if syncDir := os.Getenv("AIFLOW_SYNC_DIR"); syncDir != "" {
+		c.Sync.Dir = syncDir
+	}
+
+	// AIFLOW_EMBEDDING_API_KEY -> Embedding.APIKey
+	if apiKey := os.Getenv("AIFLOW_EMBEDDING_API_KEY"); apiKey != "" {
+		c.Embedding.APIKey = apiKey
+	}
 }
 
 // FixWithDefault 修复Config配置的默认值
@@ -222,6 +381,45 @@ log:
   output_type: "file"
   # 当output_type为file时，指定日志文件夹路径，日志文件名为main.log
   file_path: "./logs"
+
+job:
+  # 任务编号模板，占位符：{project} 项目代号（英文大写）、{date} 日期、{seq} 项目当日序号
+  no_format: "JT-{project}-{date}-{seq}"
+  # 序号最小位数，不足时左侧补零
+  seq_width: 3
+
+skill:
+  # 技能文件基准目录，技能的脚本、模板等文件存放在 base_dir/<资源目录>/ 下
+  base_dir: "./skills"
+  # 单个技能文件大小上限（字节），默认10MB
+  max_file_size: 10485760
+
+sync:
+  # 技能同步目录，目录结构为 dir/<技能名>/SKILL.md，为空时不启用同步
+  dir: ""
+  # 轮询间隔（秒），0表示只在启动时同步一次
+  poll_interval: 0
+  # 是否将数据库中的技能修改写回SKILL.md文件
+  write_back: false
+
+embedding:
+  # 技能语义搜索的向量化方式，可选值：local（本地哈希n-gram，不依赖外部服务）、openai（OpenAI兼容接口）
+  # 更换向量化方式或模型后，下次启动时会自动重新计算所有技能的向量
+  provider: "local"
+  # local方式的向量维度
+  dimensions: 512
+  # openai方式的接口根地址、模型和API Key（也可以用环境变量AIFLOW_EMBEDDING_API_KEY设置）
+  base_url: ""
+  model: ""
+  api_key: ""
+  # openai方式的请求超时（秒）
+  timeout: 30
+
+trash:
+  # 回收站保留天数，删除超过该天数的技能、任务和标签会被自动彻底删除，0表示不自动清理
+  retention_days: 0
+  # 检查过期项的间隔（秒）
+  sweep_interval: 3600
 `
 
 // LoadConfig 从指定路径加载YAML配置文件
@@ -314,5 +512,16 @@ func DefConfig() *Config {
 		DB: DBConfig{
 			Path: DBPath,
 		},
+		Job: JobConfig{
+			NoFormat: DefaultJobNoFormat,
+			SeqWidth: DefaultJobNoSeqWidth,
+		},
+		Skill: SkillConfig{
+			BaseDir:     DefaultSkillBaseDir,
+			MaxFileSize: DefaultSkillMaxFileSize,
+		},
+		Trash: TrashConfig{
+			SweepInterval: DefaultTrashSweepInterval,
+		},
 	}
 }
@@ -0,0 +1,63 @@
+// embedding包提供技能语义搜索使用的文本向量化
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"aiflow/internal/config"
+)
+
+// Embedder 文本向量化接口
+type Embedder interface {
+	// Name 返回模型标识，标识不同的向量不能相互比较，变化后需要重新计算所有技能的向量
+	Name() string
+	// Embed 批量计算文本的向量，返回的向量与texts一一对应，并已归一化为单位长度
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// New 按配置创建向量化实现，provider为空时使用本地哈希n-gram向量化
+func New(cfg config.EmbeddingConfig) (Embedder, error) {
+	switch strings.ToLower(cfg.Provider) {
+	case "", config.EmbeddingProviderLocal:
+		return NewHashEmbedder(cfg.Dimensions), nil
+	case config.EmbeddingProviderOpenAI:
+		if cfg.BaseURL == "" || cfg.Model == "" {
+			return nil, fmt.Errorf("向量化服务需要配置 base_url 和 model")
+		}
+		return NewOpenAIEmbedder(cfg.BaseURL, cfg.APIKey, cfg.Model, time.Duration(cfg.Timeout)*time.Second), nil
+	default:
+		return nil, fmt.Errorf("不支持的向量化方式 '%s'，有效值: local, openai", cfg.Provider)
+	}
+}
+
+// Cosine 计算两个单位向量的余弦相似度，长度不同时返回0
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+	}
+	return dot
+}
+
+// normalize 将向量归一化为单位长度，零向量原样返回
+func normalize(vector []float32) []float32 {
+	var sum float64
+	for _, v := range vector {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return vector
+	}
+	norm := float32(math.Sqrt(sum))
+	for i := range vector {
+		vector[i] /= norm
+	}
+	return vector
+}
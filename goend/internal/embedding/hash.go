@@ -0,0 +1,103 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"unicode"
+)
+
+// DefaultHashDimensions 本地哈希向量化的默认维度
+const DefaultHashDimensions = 512
+
+// HashEmbedder 本地哈希n-gram向量化，不依赖外部服务
+// 英文和数字按单词及其字符三元组、中文按单字和相邻两字计特征，特征哈希到固定维度后归一化
+// 只能衡量字面相似度（如 spreadsheet 与 spreadsheets），不理解近义词
+type HashEmbedder struct {
+	dimensions int
+}
+
+// NewHashEmbedder 创建本地哈希向量化，dimensions不大于0时使用默认维度
+func NewHashEmbedder(dimensions int) *HashEmbedder {
+	if dimensions <= 0 {
+		dimensions = DefaultHashDimensions
+	}
+	return &HashEmbedder{dimensions: dimensions}
+}
+
+// Name 返回模型标识
+func (e *HashEmbedder) Name() string {
+	return fmt.Sprintf("local-hash-ngram-%d", e.dimensions)
+}
+
+// Embed 计算文本的向量
+func (e *HashEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vector := make([]float32, e.dimensions)
+		for _, feature := range hashFeatures(text) {
+			h := fnv.New64a()
+			h.Write([]byte(feature.text))
+			sum := h.Sum64()
+			// 用哈希的最高位决定符号，减少不同特征落在同一维度时的相互干扰
+			sign := float32(1)
+			if sum>>63 == 1 {
+				sign = -1
+			}
+			vector[sum%uint64(e.dimensions)] += sign * feature.weight
+		}
+		vectors[i] = normalize(vector)
+	}
+	return vectors, nil
+}
+
+// hashFeature 向量化的一个特征
+type hashFeature struct {
+	text   string
+	weight float32
+}
+
+// hashFeatures 提取文本的n-gram特征，整词权重高于字符片段
+func hashFeatures(text string) []hashFeature {
+	var features []hashFeature
+	var word []rune
+	var han []rune
+	flushWord := func() {
+		if len(word) == 0 {
+			return
+		}
+		features = append(features, hashFeature{"w:" + string(word), 1})
+		padded := []rune("<" + string(word) + ">")
+		for i := 0; i+3 <= len(padded); i++ {
+			features = append(features, hashFeature{"g:" + string(padded[i:i+3]), 0.5})
+		}
+		word = word[:0]
+	}
+	flushHan := func() {
+		for i := range han {
+			features = append(features, hashFeature{"h:" + string(han[i]), 0.5})
+			if i+1 < len(han) {
+				features = append(features, hashFeature{"w:" + string(han[i:i+2]), 1})
+			}
+		}
+		han = han[:0]
+	}
+
+	for _, c := range strings.ToLower(text) {
+		switch {
+		case unicode.Is(unicode.Han, c):
+			flushWord()
+			han = append(han, c)
+		case unicode.IsLetter(c) || unicode.IsNumber(c):
+			flushHan()
+			word = append(word, c)
+		default:
+			flushWord()
+			flushHan()
+		}
+	}
+	flushWord()
+	flushHan()
+	return features
+}
@@ -0,0 +1,113 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultOpenAITimeout 向量化服务请求的默认超时
+const defaultOpenAITimeout = 30 * time.Second
+
+// OpenAIEmbedder 调用OpenAI兼容的 /embeddings 接口计算向量
+// 适用于OpenAI以及Ollama、vLLM、各类云厂商提供的兼容接口
+type OpenAIEmbedder struct {
+	baseURL string
+	apiKey  string
+	model   string
+	client  *http.Client
+}
+
+// NewOpenAIEmbedder 创建OpenAI兼容的向量化
+// baseURL为接口根地址（如 https://api.openai.com/v1），apiKey为空时不发送认证头，timeout不大于0时使用30秒
+func NewOpenAIEmbedder(baseURL, apiKey, model string, timeout time.Duration) *OpenAIEmbedder {
+	if timeout <= 0 {
+		timeout = defaultOpenAITimeout
+	}
+	return &OpenAIEmbedder{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+// Name 返回模型标识
+func (e *OpenAIEmbedder) Name() string {
+	return "openai:" + e.model
+}
+
+// openAIRequest /embeddings 请求体
+type openAIRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// openAIResponse /embeddings 响应体
+type openAIResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Embed 批量计算文本的向量
+func (e *OpenAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return [][]float32{}, nil
+	}
+	body, err := json.Marshal(openAIRequest{Model: e.model, Input: texts})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.baseURL+"/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求向量化服务失败: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取向量化服务响应失败: %w", err)
+	}
+
+	var result openAIResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("向量化服务返回 %d，响应无法解析: %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || result.Error != nil {
+		message := strings.TrimSpace(string(data))
+		if result.Error != nil {
+			message = result.Error.Message
+		}
+		return nil, fmt.Errorf("向量化服务返回 %d: %s", resp.StatusCode, message)
+	}
+	if len(result.Data) != len(texts) {
+		return nil, fmt.Errorf("向量化服务返回 %d 个向量，期望 %d 个", len(result.Data), len(texts))
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, item := range result.Data {
+		if item.Index < 0 || item.Index >= len(texts) || vectors[item.Index] != nil {
+			return nil, fmt.Errorf("向量化服务返回的向量序号 %d 无效", item.Index)
+		}
+		vectors[item.Index] = normalize(item.Embedding)
+	}
+	return vectors, nil
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 )
 
 // ErrorCode 错误码类型
@@ -27,6 +28,23 @@ const (
 	ErrCodeSkillUpdate     ErrorCode = "SKL-UPD-001"  // 技能更新失败
 	ErrCodeSkillDelete     ErrorCode = "SKL-DEL-001"  // 技能删除失败
 	ErrCodeSkillTrash      ErrorCode = "SKL-TRSH-001" // 技能回收失败
+
+	ErrCodeSkillFileInvalidPath ErrorCode = "SKL-FILE-001" // 技能文件路径非法
+	ErrCodeSkillFileNotFound    ErrorCode = "SKL-FILE-002" // 技能文件不存在
+	ErrCodeSkillFileTooLarge    ErrorCode = "SKL-FILE-003" // 技能文件超过大小上限
+	ErrCodeSkillFileSave        ErrorCode = "SKL-FILE-004" // 技能文件保存失败
+
+	ErrCodeSkillSyncDisabled ErrorCode = "SKL-SYNC-001" // 技能目录同步未启用
+	ErrCodeSkillSyncRunning  ErrorCode = "SKL-SYNC-002" // 技能目录同步正在进行
+	ErrCodeSkillSyncConflict ErrorCode = "SKL-SYNC-003" // 同步冲突不存在
+	ErrCodeSkillSync         ErrorCode = "SKL-SYNC-004" // 技能目录同步失败
+
+	ErrCodeSkillRevisionNotFound ErrorCode = "SKL-REV-001" // 技能修订不存在
+	ErrCodeSkillRevision         ErrorCode = "SKL-REV-002" // 技能修订操作失败
+
+	ErrCodeSkillValidate ErrorCode = "SKL-VAL-001" // 技能不符合Agent Skills规范
+
+	ErrCodeSkillReindexRunning ErrorCode = "SKL-IDX-001" // 技能索引重建正在进行
 )
 
 // 任务模块错误码
@@ -37,6 +55,8 @@ const (
 	ErrCodeTaskDelete     ErrorCode = "TSK-DEL-001"  // 任务删除失败
 	ErrCodeTaskTrash      ErrorCode = "TSK-TRSH-001" // 任务回收失败
 	ErrCodeTaskValidate   ErrorCode = "TSK-VAL-001"  // 任务验证失败
+
+	ErrCodeTaskStatusTransition ErrorCode = "TSK-VAL-002" // 任务状态流转非法
 )
 
 // 标签模块错误码
@@ -45,6 +65,19 @@ const (
 	ErrCodeTagCreate   ErrorCode = "TAG-CRT-001" // 标签创建失败
 	ErrCodeTagUpdate   ErrorCode = "TAG-UPD-001" // 标签更新失败
 	ErrCodeTagDelete   ErrorCode = "TAG-DEL-001" // 标签删除失败
+	ErrCodeTagMove     ErrorCode = "TAG-MOV-001" // 标签移动失败
+	ErrCodeTagCycle    ErrorCode = "TAG-MOV-002" // 标签层级出现循环
+	ErrCodeTagMerge    ErrorCode = "TAG-MRG-001" // 标签合并失败
+
+	ErrCodeTagSuggestionNotFound ErrorCode = "TAG-SUG-001" // 标签建议不存在
+	ErrCodeTagSuggestionHandled  ErrorCode = "TAG-SUG-002" // 标签建议已处理
+	ErrCodeTagSuggestion         ErrorCode = "TAG-SUG-003" // 标签建议操作失败
+)
+
+// 回收站模块错误码
+const (
+	ErrCodeTrashNotFound ErrorCode = "TRSH-NF-001"  // 回收站中不存在该项
+	ErrCodeTrash         ErrorCode = "TRSH-OPR-001" // 回收站操作失败
 )
 
 // 错误消息映射
@@ -61,6 +94,23 @@ var errorCodeMessages = map[ErrorCode]string{
 	ErrCodeSkillDelete:   "技能删除失败",
 	ErrCodeSkillTrash:    "技能回收失败",
 
+	ErrCodeSkillFileInvalidPath: "技能文件路径非法",
+	ErrCodeSkillFileNotFound:    "技能文件不存在",
+	ErrCodeSkillFileTooLarge:    "技能文件超过大小上限",
+	ErrCodeSkillFileSave:        "技能文件保存失败",
+
+	ErrCodeSkillSyncDisabled: "技能目录同步未启用",
+	ErrCodeSkillSyncRunning:  "技能目录同步正在进行",
+	ErrCodeSkillSyncConflict: "同步冲突不存在",
+	ErrCodeSkillSync:         "技能目录同步失败",
+
+	ErrCodeSkillRevisionNotFound: "技能修订不存在",
+	ErrCodeSkillRevision:         "技能修订操作失败",
+
+	ErrCodeSkillValidate: "技能不符合规范",
+
+	ErrCodeSkillReindexRunning: "技能索引重建正在进行",
+
 	ErrCodeTaskNotFound:  "任务不存在",
 	ErrCodeTaskCreate:    "任务创建失败",
 	ErrCodeTaskUpdate:    "任务更新失败",
@@ -68,10 +118,22 @@ var errorCodeMessages = map[ErrorCode]string{
 	ErrCodeTaskTrash:     "任务回收失败",
 	ErrCodeTaskValidate:  "任务验证失败",
 
+	ErrCodeTaskStatusTransition: "任务状态流转非法",
+
 	ErrCodeTagNotFound: "标签不存在",
 	ErrCodeTagCreate:   "标签创建失败",
 	ErrCodeTagUpdate:   "标签更新失败",
 	ErrCodeTagDelete:   "标签删除失败",
+	ErrCodeTagMove:     "标签移动失败",
+	ErrCodeTagCycle:    "不能将标签移动到自身或其子标签下",
+	ErrCodeTagMerge:    "标签合并失败",
+
+	ErrCodeTagSuggestionNotFound: "标签建议不存在",
+	ErrCodeTagSuggestionHandled:  "标签建议已处理",
+	ErrCodeTagSuggestion:         "标签建议操作失败",
+
+	ErrCodeTrashNotFound: "回收站中不存在该项",
+	ErrCodeTrash:         "回收站操作失败",
 }
 
 // 错误码对应的HTTP状态码映射
@@ -88,6 +150,23 @@ var errorCodeHTTPStatus = map[ErrorCode]int{
 	ErrCodeSkillDelete:   http.StatusInternalServerError,
 	ErrCodeSkillTrash:    http.StatusInternalServerError,
 
+	ErrCodeSkillFileInvalidPath: http.StatusBadRequest,
+	ErrCodeSkillFileNotFound:    http.StatusNotFound,
+	ErrCodeSkillFileTooLarge:    http.StatusRequestEntityTooLarge,
+	ErrCodeSkillFileSave:        http.StatusInternalServerError,
+
+	ErrCodeSkillSyncDisabled: http.StatusBadRequest,
+	ErrCodeSkillSyncRunning:  http.StatusConflict,
+	ErrCodeSkillSyncConflict: http.StatusNotFound,
+	ErrCodeSkillSync:         http.StatusInternalServerError,
+
+	ErrCodeSkillRevisionNotFound: http.StatusNotFound,
+	ErrCodeSkillRevision:         http.StatusInternalServerError,
+
+	ErrCodeSkillValidate: http.StatusBadRequest,
+
+	ErrCodeSkillReindexRunning: http.StatusConflict,
+
 	ErrCodeTaskNotFound:  http.StatusNotFound,
 	ErrCodeTaskCreate:    http.StatusInternalServerError,
 	ErrCodeTaskUpdate:    http.StatusInternalServerError,
@@ -95,18 +174,37 @@ var errorCodeHTTPStatus = map[ErrorCode]int{
 	ErrCodeTaskTrash:     http.StatusInternalServerError,
 	ErrCodeTaskValidate:  http.StatusBadRequest,
 
+	ErrCodeTaskStatusTransition: http.StatusBadRequest,
+
 	ErrCodeTagNotFound: http.StatusNotFound,
 	ErrCodeTagCreate:   http.StatusInternalServerError,
 	ErrCodeTagUpdate:   http.StatusInternalServerError,
 	ErrCodeTagDelete:   http.StatusInternalServerError,
+	ErrCodeTagMove:     http.StatusInternalServerError,
+	ErrCodeTagCycle:    http.StatusBadRequest,
+	ErrCodeTagMerge:    http.StatusInternalServerError,
+
+	ErrCodeTagSuggestionNotFound: http.StatusNotFound,
+	ErrCodeTagSuggestionHandled:  http.StatusConflict,
+	ErrCodeTagSuggestion:         http.StatusInternalServerError,
+
+	ErrCodeTrashNotFound: http.StatusNotFound,
+	ErrCodeTrash:         http.StatusInternalServerError,
+}
+
+// FieldError 字段级校验错误
+type FieldError struct {
+	Field   string `json:"field"`   // 字段名
+	Message string `json:"message"` // 错误说明
 }
 
 // AppError 应用错误结构体
 type AppError struct {
-	Code    ErrorCode // 错误码
-	Message string    // 错误消息
-	HTTP    int       // HTTP状态码
-	Err     error     // 原始错误
+	Code    ErrorCode    // 错误码
+	Message string       // 错误消息
+	HTTP    int          // HTTP状态码
+	Err     error        // 原始错误
+	Fields  []FieldError // 字段级校验错误，校验失败时才有
 }
 
 // Error 实现error接口，返回错误消息
@@ -232,6 +330,33 @@ func NewTagError(code ErrorCode, message string, err error) *AppError {
 	}
 }
 
+// NewTrashError 创建回收站模块错误
+func NewTrashError(code ErrorCode, message string, err error) *AppError {
+	if message == "" {
+		message = getMessage(code)
+	}
+	return &AppError{
+		Code:    code,
+		Message: message,
+		HTTP:    getHTTPStatus(code),
+		Err:     err,
+	}
+}
+
+// NewValidationError 创建字段校验错误，消息中列出每个字段的错误
+func NewValidationError(code ErrorCode, fields []FieldError) *AppError {
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		parts = append(parts, field.Field+": "+field.Message)
+	}
+	return &AppError{
+		Code:    code,
+		Message: getMessage(code) + "，" + strings.Join(parts, "；"),
+		HTTP:    getHTTPStatus(code),
+		Fields:  fields,
+	}
+}
+
 // IsAppError 检查错误是否为AppError类型
 func IsAppError(err error) (*AppError, bool) {
 	var appErr *AppError
@@ -1,9 +1,25 @@
 package mcp
 
+import "time"
+
 // 任务编号生成相关常量
 const (
-	// JobNoSequenceMod 任务编号序号取模值，用于生成5位序号
-	JobNoSequenceMod = 100000
+	// JobNoDateLayout 任务编号中的日期格式
+	JobNoDateLayout = "20060102"
+	// JobNoProjectCodeMaxLen 项目代号最大字符数，超出部分截断
+	JobNoProjectCodeMaxLen = 30
+	// JobNoDefaultProjectCode 项目名规范化后为空时使用的默认代号
+	JobNoDefaultProjectCode = "PRJ"
+	// JobNoMaxRetries 任务编号冲突时的最大重试次数
+	JobNoMaxRetries = 3
+)
+
+// 任务列表分页相关常量
+const (
+	// JobListDefaultPageSize 任务列表默认每页条数
+	JobListDefaultPageSize = 20
+	// JobListMaxPageSize 任务列表最大每页条数
+	JobListMaxPageSize = 100
 )
 
 // 任务类型常量
@@ -25,8 +41,18 @@ const (
 // 任务类型选项字符串（用于MCP工具描述）
 const JobTypeOptions = "新需求、Bug修复、改进功能、重构代码、单元测试、集成测试、数据处理、版本控制"
 
+// 验收标准常量
+const (
+	// AcceptStdManual 人工验收
+	AcceptStdManual = "人工验收"
+	// AcceptStdTest 测试验收
+	AcceptStdTest = "测试验收"
+	// AcceptStdBuild 编译验收
+	AcceptStdBuild = "编译验收"
+)
+
 // 验收标准选项字符串（用于MCP工具描述）
-const AcceptStdOptions = "人工验收、测试验收、编译验收"
+const AcceptStdOptions = AcceptStdManual + "、" + AcceptStdTest + "、" + AcceptStdBuild
 
 // 任务状态常量
 const (
@@ -44,3 +70,33 @@ const (
 
 // 任务状态选项字符串（用于MCP工具描述）
 const JobStatusOptions = "已创建、处理中、处理失败、处理完成、验收通过"
+
+// 技能资源相关常量
+const (
+	// SkillResourceScheme 技能资源URI前缀
+	SkillResourceScheme = "skill://"
+	// SkillResourceTemplate 技能资源URI模板
+	SkillResourceTemplate = "skill://{name}"
+	// SkillFileResourceTemplate 技能文件资源URI模板
+	SkillFileResourceTemplate = "skill://{name}/{+file}"
+	// SkillMainFile 技能主文件名
+	SkillMainFile = "SKILL.md"
+	// SkillResourceMIMEType 技能资源内容类型
+	SkillResourceMIMEType = "text/markdown"
+	// SkillResourceSyncDelay 技能变更后延迟同步资源列表的时间，期间的多次变更合并为一次同步
+	SkillResourceSyncDelay = 200 * time.Millisecond
+)
+
+// 技能文件相关常量
+const (
+	// SkillFileReadMaxSize skill_file_read单次读取的文件大小上限（字节）
+	SkillFileReadMaxSize = 1 << 20
+	// SkillFileBlobMIMEType 无法识别内容类型的技能文件使用的MIME类型
+	SkillFileBlobMIMEType = "application/octet-stream"
+)
+
+// 技能修订历史相关常量
+const (
+	// SkillHistoryMaxRevisions skill_history列出的最近修订数上限
+	SkillHistoryMaxRevisions = 20
+)
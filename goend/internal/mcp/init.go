@@ -1,7 +1,9 @@
 package mcp
 
 import (
+	"aiflow/internal/config"
 	"aiflow/internal/repositories"
+	"aiflow/internal/storage"
 
 	"github.com/mark3labs/mcp-go/server"
 )
@@ -9,11 +11,26 @@ import (
 // repo 全局数据库仓库实例
 var repo *repositories.Repository
 
-// InitTools 初始化工具，向MCP服务器添加greet工具
-func InitTools(server *server.MCPServer, r *repositories.Repository) {
+// appConfig 全局配置实例，未初始化时使用默认配置
+var appConfig = config.DefConfig()
+
+// fileStore 全局技能文件存储实例，按配置的技能基准目录初始化
+var fileStore = storage.NewSkillFileStore(config.DefaultSkillBaseDir, config.DefaultSkillMaxFileSize)
+
+// InitTools 初始化工具、资源和提示词，向MCP服务器注册技能、任务相关工具、技能资源及任务规则提示词
+func InitTools(server *server.MCPServer, r *repositories.Repository, cfg *config.Config) {
 	repo = r
+	if cfg != nil {
+		appConfig = cfg
+	}
+	fileStore = storage.NewSkillFileStore(appConfig.Skill.BaseDir, appConfig.Skill.MaxFileSize)
 	initMenu(server)
+	initTag(server)
 	initDetail(server)
 	initSave(server)
+	initSkillFile(server)
+	initSkillHistory(server)
 	initJobTask(server)
+	initResource(server)
+	initPrompt(server)
 }
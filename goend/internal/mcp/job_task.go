@@ -1,13 +1,17 @@
 package mcp
 
 import (
+	"aiflow/internal/config"
 	"aiflow/internal/models"
+	"aiflow/internal/services"
+	"aiflow/internal/utils"
 	"aiflow/internal/utils/logx"
 	"context"
-	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -132,6 +136,201 @@ func initJobTask(server *server.MCPServer) {
 			Required: []string{"jobNo"},
 		},
 	}, queryJobTool)
+	// 注册任务列表工具
+	server.AddTool(mcp.Tool{
+		Name:        "job_list",
+		Description: "分页查询任务列表，用于找回未完成的任务继续处理，避免重复创建",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"project": map[string]any{
+					"type":        "string",
+					"description": "所属项目，不传则查询全部项目",
+				},
+				"type": map[string]any{
+					"type":        "string",
+					"description": "任务类型，可选值：" + JobTypeOptions,
+				},
+				"status": map[string]any{
+					"type":        "string",
+					"description": "任务状态，可选值：" + JobStatusOptions,
+				},
+				"startDate": map[string]any{
+					"type":        "string",
+					"description": "创建日期起始，格式：2006-01-02",
+				},
+				"endDate": map[string]any{
+					"type":        "string",
+					"description": "创建日期截止（包含当天），格式：2006-01-02",
+				},
+				"page": map[string]any{
+					"type":        "number",
+					"description": "页码，从1开始，默认1",
+				},
+				"pageSize": map[string]any{
+					"type":        "number",
+					"description": fmt.Sprintf("每页条数，默认%d，最大%d", JobListDefaultPageSize, JobListMaxPageSize),
+				},
+			},
+			Required: []string{},
+		},
+	}, listJobTool)
+}
+
+// listJobTool 任务列表工具函数
+// 按项目、类型、状态和日期范围分页查询任务，每行附带当前活跃执行记录摘要
+func listJobTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// 获取参数
+	project := request.GetString("project", "")
+	jobType := request.GetString("type", "")
+	status := request.GetString("status", "")
+	startDateStr := request.GetString("startDate", "")
+	endDateStr := request.GetString("endDate", "")
+	page := request.GetInt("page", 1)
+	pageSize := request.GetInt("pageSize", JobListDefaultPageSize)
+
+	logx.Debug("job_list - project: %s, type: %s, status: %s, startDate: %s, endDate: %s, page: %d, pageSize: %d", project, jobType, status, startDateStr, endDateStr, page, pageSize)
+
+	// 检查数据库是否初始化
+	if repo == nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: "数据库未初始化，无法查询任务列表",
+				},
+			},
+		}, nil
+	}
+
+	// 校验类型和状态，避免拼写错误时误以为没有匹配的任务
+	if jobType != "" && !slices.Contains(strings.Split(JobTypeOptions, "、"), jobType) {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: "无效的type参数「" + jobType + "」，可选值：" + JobTypeOptions,
+				},
+			},
+		}, nil
+	}
+	if status != "" && !services.IsValidJobTaskStatus(status) {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: "无效的status参数「" + status + "」，可选值：" + JobStatusOptions,
+				},
+			},
+		}, nil
+	}
+
+	// 修正分页参数
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = JobListDefaultPageSize
+	}
+	if pageSize > JobListMaxPageSize {
+		pageSize = JobListMaxPageSize
+	}
+
+	// 解析日期范围（毫秒级时间戳），截止日期包含当天
+	startDate, err := parseDateParam(startDateStr, false)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: "无效的startDate参数: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	endDate, err := parseDateParam(endDateStr, true)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: "无效的endDate参数: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+
+	jobTasks, total, err := repo.ListJobTasks(ctx, page, pageSize, project, jobType, status, startDate, endDate)
+	if err != nil {
+		logx.Error("查询任务列表失败: %v", err)
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: "查询任务列表失败: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: formatJobList(jobTasks, total, page, pageSize),
+			},
+		},
+	}, nil
+}
+
+// formatJobList 格式化任务列表为紧凑的文本行
+// 每行包含任务编号、项目、类型、状态、目标以及当前活跃执行记录的摘要
+func formatJobList(jobTasks []models.JobTask, total int64, page, pageSize int) string {
+	if len(jobTasks) == 0 {
+		return "未找到匹配的任务"
+	}
+
+	totalPage := (total + int64(pageSize) - 1) / int64(pageSize)
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("任务列表（第%d/%d页，共%d条）：\n", page, totalPage, total))
+	for _, task := range jobTasks {
+		result.WriteString(fmt.Sprintf("%s | %s | %s | %s | %s", task.JobNo, task.Project, task.Type, task.Status, task.Goal))
+
+		// 附加当前活跃执行记录摘要
+		if record := task.ActiveExecution(); record != nil {
+			result.WriteString(fmt.Sprintf(" | 执行#%d %s", record.Sequence, record.Status))
+			if record.Result != "" {
+				result.WriteString(": " + record.Result)
+			}
+		}
+		result.WriteString("\n")
+	}
+
+	if int64(page) < totalPage {
+		result.WriteString(fmt.Sprintf("... 可传 page=%d 查看下一页\n", page+1))
+	}
+	result.WriteString("请调用 job_get 查任务详情，继续处理时调用 job_redo 或 job_report")
+
+	return result.String()
+}
+
+// parseDateParam 解析日期参数为毫秒级时间戳
+// 空字符串返回0表示不限制；endOfDay为true时返回当天最后一毫秒
+func parseDateParam(value string, endOfDay bool) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	t, err := time.ParseInLocation(utils.DateLayout, value, time.Local)
+	if err != nil {
+		return 0, err
+	}
+
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Millisecond)
+	}
+	return t.UnixMilli(), nil
 }
 
 // queryJobTool 查询任务详情工具函数
@@ -168,18 +367,21 @@ func queryJobTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallTo
 		}, nil
 	}
 
-	// 解析执行记录
-	var executionRecords []models.ExecutionRecord
-	if jobTask.ExecutionRecords != "" {
-		if err = json.Unmarshal([]byte(jobTask.ExecutionRecords), &executionRecords); err != nil {
-			logx.Error("解析执行结果失败: %v", err)
-			executionRecords = []models.ExecutionRecord{}
-		}
-	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: formatJobDetail(jobTask),
+			},
+		},
+	}, nil
+}
 
+// formatJobDetail 格式化任务详情，包含全部执行记录
+func formatJobDetail(jobTask *models.JobTask) string {
 	// 构建执行记录详情
 	var executionDetails strings.Builder
-	for i, record := range executionRecords {
+	for i, record := range jobTask.Executions {
 		if i > 0 {
 			executionDetails.WriteString("\n---\n")
 		}
@@ -198,7 +400,7 @@ func queryJobTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallTo
 	if jobTask.PassAcceptStd {
 		passStatus = "已通过"
 	}
-	resultText := fmt.Sprintf("任务详情:\n任务编号: %s\n所属项目: %s\n任务类型: %s\n任务目标: %s\n验收状态: %s\n当前状态: %s\n当前执行序号: %d\n\n执行记录:\n%s",
+	return fmt.Sprintf("任务详情:\n任务编号: %s\n所属项目: %s\n任务类型: %s\n任务目标: %s\n验收状态: %s\n当前状态: %s\n当前执行序号: %d\n\n执行记录:\n%s",
 		jobTask.JobNo,
 		jobTask.Project,
 		jobTask.Type,
@@ -208,15 +410,6 @@ func queryJobTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallTo
 		jobTask.ActiveExecutionSequence,
 		executionDetails.String(),
 	)
-
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			mcp.TextContent{
-				Type: "text",
-				Text: resultText,
-			},
-		},
-	}, nil
 }
 
 // newJobTool 创建任务工具函数
@@ -245,9 +438,6 @@ func newJobTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallTool
 		}, nil
 	}
 
-	// 生成任务编号: 项目代号-日期-序号
-	jobNo := generateJobNo(project)
-
 	now := time.Now().UnixMilli()
 	executionRecords := []models.ExecutionRecord{
 		{
@@ -263,33 +453,34 @@ func newJobTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallTool
 		},
 	}
 
-	exes, err := json.Marshal(executionRecords)
-	if err != nil {
-		logx.Error("序列化执行记录失败: %v", err)
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				mcp.TextContent{
-					Type: "text",
-					Text: "序列化执行记录失败: " + err.Error(),
-				},
-			},
-		}, nil
-	}
-
 	// 创建任务对象
 	jobTask := &models.JobTask{
-		JobNo:                   jobNo,
 		Project:                 project,
 		Type:                    jobType,
 		Goal:                    goal,
 		PassAcceptStd:           false, // 默认未通过验收
 		Status:                  JobStatusCreated,
 		ActiveExecutionSequence: 1,
-		ExecutionRecords:        string(exes),
+		Executions:              executionRecords,
 	}
 
-	// 保存到数据库
-	if err := repo.CreateJobTask(ctx, jobTask); err != nil {
+	// 生成任务编号并保存到数据库，编号与历史数据冲突时重新分配序号
+	var jobNo string
+	var err error
+	for attempt := 0; attempt < JobNoMaxRetries; attempt++ {
+		jobNo, err = generateJobNo(ctx, project)
+		if err != nil {
+			break
+		}
+		jobTask.ID = 0
+		jobTask.Executions[0].ID = 0
+		jobTask.JobNo = jobNo
+		if err = repo.CreateJobTask(ctx, jobTask); !isUniqueConstraintError(err) {
+			break
+		}
+		logx.Warn("任务编号 %s 已存在，重新分配序号", jobNo)
+	}
+	if err != nil {
 		logx.Error("创建任务失败: %v", err)
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -351,28 +542,23 @@ func reportJobTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallT
 		}, nil
 	}
 
+	// 校验状态流转是否合法
+	if err := services.ValidateJobTaskStatusTransition(jobTask.Status, status); err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: "报告任务失败: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+
 	// 更新任务状态
 	jobTask.Status = status
 
-	// 解析现有的executionRecords
-	var executionRecords []models.ExecutionRecord
-	if jobTask.ExecutionRecords != "" {
-		if err = json.Unmarshal([]byte(jobTask.ExecutionRecords), &executionRecords); err != nil {
-			logx.Error("解析执行结果失败: %v", err)
-			// 如果解析失败，重置为空数组
-			executionRecords = []models.ExecutionRecord{}
-		}
-	}
-
 	// 找出当前执行记录
-	var executionRecord *models.ExecutionRecord = nil
-	for i := range executionRecords {
-		if executionRecords[i].Sequence == jobTask.ActiveExecutionSequence {
-			executionRecord = &executionRecords[i]
-			break
-		}
-	}
-
+	executionRecord := jobTask.ActiveExecution()
 	if executionRecord == nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -387,28 +573,12 @@ func reportJobTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallT
 	// 更新执行记录
 	executionRecord.Status = status
 	executionRecord.Result = result
-	executionRecord.UpdatedAt = time.Now().UnixMilli()
 
 	// 更新JobTask的验收状态
 	jobTask.PassAcceptStd = passAcceptStd
 
-	// 序列化回JSON
-	executionJSON, err := json.Marshal(executionRecords)
-	if err != nil {
-		logx.Error("序列化执行结果失败: %v", err)
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				mcp.TextContent{
-					Type: "text",
-					Text: "报告任务失败: " + err.Error(),
-				},
-			},
-		}, nil
-	}
-	jobTask.ExecutionRecords = string(executionJSON)
-
-	// 保存到数据库
-	if err := repo.UpdateJobTask(ctx, jobTask); err != nil {
+	// 在同一事务中保存任务和执行记录
+	if err := repo.SaveJobTaskExecution(ctx, jobTask, executionRecord); err != nil {
 		logx.Error("报告任务失败: %v", err)
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -422,7 +592,7 @@ func reportJobTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallT
 
 	// 返回成功结果
 	resultText := fmt.Sprintf("任务报告成功\n任务编号: %s\n当前状态: %s\n历史记录数: %d",
-		jobNo, status, len(executionRecords))
+		jobNo, status, len(jobTask.Executions))
 
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{
@@ -435,17 +605,57 @@ func reportJobTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallT
 }
 
 // generateJobNo 生成任务编号
-// 格式: JT-项目代号-日期-序号 (如: JT-ZL-20250207-001)
-func generateJobNo(project string) string {
-	// 获取当前日期
-	now := time.Now()
-	dateStr := now.Format("20060102")
+// 按配置的模板生成，默认格式: JT-项目代号-日期-序号 (如: JT-ZL-20250207-001)
+// 序号按项目代号和日期在数据库中自增分配，保证并发创建时不重复
+func generateJobNo(ctx context.Context, project string) (string, error) {
+	projectCode := normalizeProjectCode(project)
+	dateStr := time.Now().Format(JobNoDateLayout)
 
-	// 生成序号: 使用当前时间戳的后5位作为序号
-	// 这样可以保证同一秒内创建的任务也有不同的编号
-	seq := now.UnixMilli() % JobNoSequenceMod
+	seq, err := repo.NextJobNoSequence(ctx, projectCode, dateStr)
+	if err != nil {
+		return "", err
+	}
 
-	return fmt.Sprintf("JT-%s-%s-%05d", project, dateStr, seq)
+	jobNo := strings.NewReplacer(
+		config.JobNoPlaceholderProject, projectCode,
+		config.JobNoPlaceholderDate, dateStr,
+		config.JobNoPlaceholderSeq, fmt.Sprintf("%0*d", appConfig.Job.SeqWidth, seq),
+	).Replace(appConfig.Job.NoFormat)
+	return jobNo, nil
+}
+
+// normalizeProjectCode 规范化项目代号
+// 英文字母转大写，字母和数字之外的字符替换为连字符并合并，去除首尾连字符
+// 如: "my project_v2" -> "MY-PROJECT-V2"，"智流 mcp" -> "智流-MCP"
+func normalizeProjectCode(project string) string {
+	var builder strings.Builder
+	count := 0
+	lastHyphen := true // 避免开头出现连字符
+	for _, r := range project {
+		if count >= JobNoProjectCodeMaxLen {
+			break
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			builder.WriteRune(unicode.ToUpper(r))
+			lastHyphen = false
+			count++
+		} else if !lastHyphen {
+			builder.WriteRune('-')
+			lastHyphen = true
+			count++
+		}
+	}
+
+	code := strings.TrimRight(builder.String(), "-")
+	if code == "" {
+		return JobNoDefaultProjectCode
+	}
+	return code
+}
+
+// isUniqueConstraintError 判断是否为唯一约束冲突错误
+func isUniqueConstraintError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed")
 }
 
 // redoJobTool 重复执行任务工具函数
@@ -485,50 +695,40 @@ func redoJobTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToo
 		}, nil
 	}
 
-	// 解析执行记录
-	var executionRecords []models.ExecutionRecord
-	if jobTask.ExecutionRecords != "" {
-		if err = json.Unmarshal([]byte(jobTask.ExecutionRecords), &executionRecords); err != nil {
-			logx.Error("解析执行结果失败: %v", err)
-			executionRecords = []models.ExecutionRecord{}
-		}
+	// 重新执行任务会使任务回到处理中，需校验状态流转是否合法
+	if err := services.ValidateJobTaskStatusTransition(jobTask.Status, JobStatusProcessing); err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: "重复执行任务失败: " + err.Error(),
+				},
+			},
+		}, nil
 	}
 
-	jobTask.ActiveExecutionSequence = len(executionRecords) + 1
+	// 执行记录按序号升序加载，新序号在最后一条的基础上递增
+	executionRecords := jobTask.Executions
+	jobTask.Status = JobStatusProcessing
+	jobTask.ActiveExecutionSequence = 1
 	// 继承上一次的验收标准
 	var inheritAcceptStd string
 	if len(executionRecords) > 0 {
-		inheritAcceptStd = executionRecords[len(executionRecords)-1].AcceptStd
+		last := executionRecords[len(executionRecords)-1]
+		inheritAcceptStd = last.AcceptStd
+		jobTask.ActiveExecutionSequence = last.Sequence + 1
 	}
-	now := time.Now().UnixMilli()
-	newExecutionRecord := models.ExecutionRecord{
+	newExecutionRecord := &models.ExecutionRecord{
 		Sequence:     jobTask.ActiveExecutionSequence,
 		Solution:     solution,
 		RelatedFiles: splitString(relatedFiles),
 		Status:       JobStatusProcessing,
 		AcceptStd:    inheritAcceptStd,
 		Skills:       splitString(skills),
-		CreatedAt:    now,
-		UpdatedAt:    now,
 	}
-	executionRecords = append(executionRecords, newExecutionRecord)
 
-	// 序列化回JSON
-	executionJSON, err := json.Marshal(executionRecords)
-	if err != nil {
-		logx.Error("序列化执行结果失败: %v", err)
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				mcp.TextContent{
-					Type: "text",
-					Text: "重复执行任务失败: " + err.Error(),
-				},
-			},
-		}, nil
-	}
-	jobTask.ExecutionRecords = string(executionJSON)
-	// 保存到数据库
-	if err := repo.UpdateJobTask(ctx, jobTask); err != nil {
+	// 在同一事务中保存任务和新的执行记录
+	if err := repo.SaveJobTaskExecution(ctx, jobTask, newExecutionRecord); err != nil {
 		logx.Error("重复执行任务失败: %v", err)
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -0,0 +1,319 @@
+package mcp
+
+import (
+	"aiflow/internal/repositories"
+	"aiflow/internal/utils"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// callJobTool 调用任务工具并返回文本结果
+// 参数:
+//   - t: 测试实例
+//   - handler: 工具处理函数
+//   - args: 工具参数
+//
+// 返回: 工具返回的文本内容
+func callJobTool(t *testing.T, handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]interface{}) string {
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = args
+
+	result, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("工具调用失败: %v", err)
+	}
+	if len(result.Content) == 0 {
+		t.Fatal("期望返回内容，但实际为空")
+	}
+
+	textContent, ok := result.Content[0].(mcp.TextContent)
+	if !ok {
+		t.Fatal("期望返回文本内容")
+	}
+	return textContent.Text
+}
+
+// createTestJob 通过job_new工具创建测试任务，返回任务编号
+func createTestJob(t *testing.T, project string) string {
+	text := callJobTool(t, newJobTool, map[string]interface{}{
+		"project":      project,
+		"type":         JobTypeNewFeature,
+		"goal":         "测试任务",
+		"relatedFiles": "main.go",
+		"solution":     "直接修改",
+		"acceptStd":    "测试验收",
+		"skills":       "go",
+	})
+
+	_, jobNo, found := strings.Cut(text, "任务编号: ")
+	if !found {
+		t.Fatalf("创建任务失败: %s", text)
+	}
+	return strings.TrimSpace(jobNo)
+}
+
+// TestReportJobTool_StatusTransition 测试报告任务时的状态流转校验
+func TestReportJobTool_StatusTransition(t *testing.T) {
+	testRepo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	originalRepo := repo
+	setRepoForTest(testRepo)
+	defer setRepoForTest(originalRepo)
+
+	ctx := context.Background()
+	jobNo := createTestJob(t, "demo")
+
+	t.Run("拒绝已创建直接验收通过", func(t *testing.T) {
+		text := callJobTool(t, reportJobTool, map[string]interface{}{
+			"jobNo":         jobNo,
+			"status":        JobStatusAccepted,
+			"result":        "完成",
+			"passAcceptStd": true,
+		})
+		if !strings.Contains(text, "TSK-VAL") || !strings.Contains(text, JobStatusCompleted) {
+			t.Errorf("期望返回TSK-VAL错误并列出允许的下一状态，实际: %s", text)
+		}
+
+		jobTask, err := testRepo.GetJobTaskByJobNo(ctx, jobNo)
+		if err != nil {
+			t.Fatalf("查询任务失败: %v", err)
+		}
+		if jobTask.Status != JobStatusCreated {
+			t.Errorf("非法流转不应修改状态，实际状态: %s", jobTask.Status)
+		}
+	})
+
+	t.Run("拒绝无效状态", func(t *testing.T) {
+		text := callJobTool(t, reportJobTool, map[string]interface{}{
+			"jobNo":         jobNo,
+			"status":        "已完成",
+			"result":        "完成",
+			"passAcceptStd": false,
+		})
+		if !strings.Contains(text, "TSK-VAL") {
+			t.Errorf("期望返回TSK-VAL错误，实际: %s", text)
+		}
+	})
+
+	t.Run("合法流转到验收通过", func(t *testing.T) {
+		for _, status := range []string{JobStatusCompleted, JobStatusAccepted} {
+			text := callJobTool(t, reportJobTool, map[string]interface{}{
+				"jobNo":         jobNo,
+				"status":        status,
+				"result":        "完成",
+				"passAcceptStd": true,
+			})
+			if !strings.Contains(text, "任务报告成功") {
+				t.Fatalf("流转到%s失败: %s", status, text)
+			}
+		}
+	})
+
+	t.Run("验收通过后不允许重新执行", func(t *testing.T) {
+		text := callJobTool(t, redoJobTool, map[string]interface{}{
+			"jobNo":        jobNo,
+			"solution":     "重新修改",
+			"relatedFiles": "main.go",
+		})
+		if !strings.Contains(text, "TSK-VAL") {
+			t.Errorf("期望返回TSK-VAL错误，实际: %s", text)
+		}
+	})
+}
+
+// TestNewJobTool_SequentialJobNo 测试任务编号按项目和日期顺序分配
+func TestNewJobTool_SequentialJobNo(t *testing.T) {
+	testRepo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	originalRepo := repo
+	setRepoForTest(testRepo)
+	defer setRepoForTest(originalRepo)
+
+	date := time.Now().Format(JobNoDateLayout)
+	expected := []string{
+		"JT-MY-DEMO-" + date + "-001",
+		"JT-MY-DEMO-" + date + "-002",
+	}
+	for _, want := range expected {
+		if jobNo := createTestJob(t, "my demo"); jobNo != want {
+			t.Errorf("期望任务编号%s，实际%s", want, jobNo)
+		}
+	}
+
+	// 不同项目独立计数，项目代号大小写规范化后共用序号
+	if jobNo := createTestJob(t, "other"); jobNo != "JT-OTHER-"+date+"-001" {
+		t.Errorf("不同项目应独立计数，实际%s", jobNo)
+	}
+	if jobNo := createTestJob(t, "My_Demo"); jobNo != "JT-MY-DEMO-"+date+"-003" {
+		t.Errorf("规范化后相同的项目应共用序号，实际%s", jobNo)
+	}
+}
+
+// TestRedoJobTool_ExecutionRecords 测试重新执行任务时执行记录写入独立表
+func TestRedoJobTool_ExecutionRecords(t *testing.T) {
+	testRepo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	originalRepo := repo
+	setRepoForTest(testRepo)
+	defer setRepoForTest(originalRepo)
+
+	ctx := context.Background()
+	jobNo := createTestJob(t, "demo")
+
+	text := callJobTool(t, reportJobTool, map[string]interface{}{
+		"jobNo":         jobNo,
+		"status":        JobStatusFailed,
+		"result":        "编译失败",
+		"passAcceptStd": false,
+	})
+	if !strings.Contains(text, "任务报告成功") {
+		t.Fatalf("报告任务失败: %s", text)
+	}
+
+	text = callJobTool(t, redoJobTool, map[string]interface{}{
+		"jobNo":        jobNo,
+		"solution":     "修复编译错误",
+		"relatedFiles": "main.go,util.go",
+		"skills":       "go-review",
+	})
+	if !strings.Contains(text, "任务内容") {
+		t.Fatalf("重新执行任务失败: %s", text)
+	}
+
+	jobTask, err := testRepo.GetJobTaskByJobNo(ctx, jobNo)
+	if err != nil {
+		t.Fatalf("查询任务失败: %v", err)
+	}
+	if len(jobTask.Executions) != 2 || jobTask.ActiveExecutionSequence != 2 {
+		t.Fatalf("期望2条执行记录且当前序号为2，实际: %d条，序号%d", len(jobTask.Executions), jobTask.ActiveExecutionSequence)
+	}
+	if first := jobTask.Executions[0]; first.Status != JobStatusFailed || first.Result != "编译失败" {
+		t.Errorf("第一条执行记录未更新: %+v", first)
+	}
+	if active := jobTask.ActiveExecution(); active == nil || active.AcceptStd != "测试验收" || len(active.RelatedFiles) != 2 {
+		t.Errorf("新执行记录应继承验收标准并保存关联文件: %+v", active)
+	}
+
+	records, err := testRepo.ListExecutionRecords(ctx, repositories.ExecutionRecordFilter{Skill: "go-review"})
+	if err != nil {
+		t.Fatalf("按技能查询执行记录失败: %v", err)
+	}
+	if len(records) != 1 || records[0].Sequence != 2 {
+		t.Errorf("期望按技能查到序号2的执行记录，实际: %+v", records)
+	}
+}
+
+// TestListJobTool_Filters 测试任务列表的项目、状态、类型和日期筛选以及分页输出
+func TestListJobTool_Filters(t *testing.T) {
+	testRepo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	originalRepo := repo
+	setRepoForTest(testRepo)
+	defer setRepoForTest(originalRepo)
+
+	demoJobs := []string{createTestJob(t, "demo"), createTestJob(t, "demo"), createTestJob(t, "demo")}
+	createTestJob(t, "other")
+	text := callJobTool(t, newJobTool, map[string]interface{}{
+		"project": "other", "type": JobTypeBugFix, "goal": "修复缺陷", "acceptStd": AcceptStdTest,
+	})
+	if !strings.Contains(text, "任务编号: ") {
+		t.Fatalf("创建任务失败: %s", text)
+	}
+	text = callJobTool(t, reportJobTool, map[string]interface{}{
+		"jobNo": demoJobs[0], "status": JobStatusFailed, "result": "编译失败", "passAcceptStd": false,
+	})
+	if !strings.Contains(text, "任务报告成功") {
+		t.Fatalf("报告任务失败: %s", text)
+	}
+
+	// countJobs 统计列表中的任务行数
+	countJobs := func(text string) int {
+		return strings.Count(text, "JT-")
+	}
+	today := time.Now().Format(utils.DateLayout)
+	yesterday := time.Now().AddDate(0, 0, -1).Format(utils.DateLayout)
+	tomorrow := time.Now().AddDate(0, 0, 1).Format(utils.DateLayout)
+
+	for _, tc := range []struct {
+		name string
+		args map[string]interface{}
+		want int
+	}{
+		{"全部", map[string]interface{}{}, 5},
+		{"按项目", map[string]interface{}{"project": "demo"}, 3},
+		{"按状态", map[string]interface{}{"status": JobStatusFailed}, 1},
+		{"按类型", map[string]interface{}{"type": JobTypeBugFix}, 1},
+		{"项目和类型组合", map[string]interface{}{"project": "demo", "type": JobTypeBugFix}, 0},
+		{"日期范围包含当天", map[string]interface{}{"startDate": today, "endDate": today}, 5},
+		{"截止日期早于创建日期", map[string]interface{}{"endDate": yesterday}, 0},
+		{"起始日期晚于创建日期", map[string]interface{}{"startDate": tomorrow}, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := countJobs(callJobTool(t, listJobTool, tc.args)); got != tc.want {
+				t.Errorf("期望%d个任务，实际%d个", tc.want, got)
+			}
+		})
+	}
+
+	t.Run("失败任务附带执行记录摘要", func(t *testing.T) {
+		text := callJobTool(t, listJobTool, map[string]interface{}{"status": JobStatusFailed})
+		if !strings.Contains(text, demoJobs[0]) || !strings.Contains(text, "执行#1 "+JobStatusFailed+": 编译失败") {
+			t.Errorf("期望列出失败任务及执行结果，实际: %s", text)
+		}
+	})
+
+	t.Run("拒绝无效的筛选值", func(t *testing.T) {
+		for param, args := range map[string]map[string]interface{}{
+			"status":    {"status": "已完成"},
+			"type":      {"type": "新功能"},
+			"startDate": {"startDate": "2026/01/01"},
+			"endDate":   {"endDate": "昨天"},
+		} {
+			text := callJobTool(t, listJobTool, args)
+			if !strings.Contains(text, "无效的"+param+"参数") || countJobs(text) != 0 {
+				t.Errorf("参数%v应报错，实际: %s", args, text)
+			}
+		}
+	})
+
+	t.Run("分页", func(t *testing.T) {
+		text := callJobTool(t, listJobTool, map[string]interface{}{"project": "demo", "pageSize": 2})
+		if countJobs(text) != 2 || !strings.Contains(text, "第1/2页，共3条") || !strings.Contains(text, "page=2") {
+			t.Errorf("第一页应有2个任务并提示下一页，实际: %s", text)
+		}
+		text = callJobTool(t, listJobTool, map[string]interface{}{"project": "demo", "page": 2, "pageSize": 2})
+		if countJobs(text) != 1 || !strings.Contains(text, "第2/2页，共3条") || strings.Contains(text, "page=3") {
+			t.Errorf("最后一页应有1个任务且不提示下一页，实际: %s", text)
+		}
+		if text := callJobTool(t, listJobTool, map[string]interface{}{"page": 3, "pageSize": 5}); !strings.Contains(text, "未找到匹配的任务") {
+			t.Errorf("超出页数时应提示未找到，实际: %s", text)
+		}
+		if text := callJobTool(t, listJobTool, map[string]interface{}{"pageSize": 1000}); countJobs(text) != 5 || !strings.Contains(text, "第1/1页") {
+			t.Errorf("每页条数超过上限时应按上限处理，实际: %s", text)
+		}
+	})
+}
+
+// TestNormalizeProjectCode 测试项目代号规范化
+func TestNormalizeProjectCode(t *testing.T) {
+	cases := map[string]string{
+		"aiflow":        "AIFLOW",
+		"my project_v2": "MY-PROJECT-V2",
+		"智流 mcp":        "智流-MCP",
+		"--a//b--":      "A-B",
+		"  ":            JobNoDefaultProjectCode,
+	}
+	for input, want := range cases {
+		if got := normalizeProjectCode(input); got != want {
+			t.Errorf("normalizeProjectCode(%q) = %q，期望 %q", input, got, want)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package mcp
+
+import (
+	"aiflow/internal/config"
+	"aiflow/internal/services"
+	"aiflow/internal/utils/logx"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// jobStatusOrder 任务状态展示顺序
+var jobStatusOrder = []string{JobStatusCreated, JobStatusProcessing, JobStatusFailed, JobStatusCompleted, JobStatusAccepted}
+
+// initPrompt 初始化提示词
+// 规则内容由任务类型、状态、验收标准常量及状态流转表生成，保证与工具定义一致
+func initPrompt(server *server.MCPServer) {
+	server.AddPrompt(mcp.NewPrompt("aiflow_rules",
+		mcp.WithPromptDescription("智流任务处理规则，指导AI按 job_new、job_report、job_redo 流程跟踪任务"),
+		mcp.WithArgument("project",
+			mcp.ArgumentDescription("项目名称"),
+			mcp.RequiredArgument(),
+		),
+	), rulesPrompt)
+	server.AddPrompt(mcp.NewPrompt("job_resume",
+		mcp.WithPromptDescription("继续处理已有任务，附带任务详情和执行历史"),
+		mcp.WithArgument("jobNo",
+			mcp.ArgumentDescription("任务编号"),
+			mcp.RequiredArgument(),
+		),
+	), resumeJobPrompt)
+}
+
+// rulesPrompt 生成任务处理规则提示词
+func rulesPrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	project := strings.TrimSpace(request.Params.Arguments["project"])
+	logx.Debug("prompt aiflow_rules - project: %s", project)
+
+	if project == "" {
+		return nil, fmt.Errorf("项目名称不能为空")
+	}
+
+	return mcp.NewGetPromptResult("智流任务处理规则", []mcp.PromptMessage{
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(buildRulesText(project))),
+	}), nil
+}
+
+// buildRulesText 生成任务处理规则文本
+func buildRulesText(project string) string {
+	jobNoExample := strings.NewReplacer(
+		config.JobNoPlaceholderProject, normalizeProjectCode(project),
+		config.JobNoPlaceholderDate, "{日期}",
+		config.JobNoPlaceholderSeq, "{序号}",
+	).Replace(appConfig.Job.NoFormat)
+
+	var rules strings.Builder
+	rules.WriteString(fmt.Sprintf("# 项目名：%s\n\n", project))
+
+	rules.WriteString("## 【执行前必做】\n\n")
+	rules.WriteString("1. 检查任务编号：无则调用 `job_new`创建，有则提取\n")
+	rules.WriteString("2. 无论任务大小，响应首行必须是任务状态声明\n")
+	rules.WriteString("3. 判断是否需要 `job_report`\n\n")
+
+	rules.WriteString("## 【规则正文】\n\n")
+	rules.WriteString(fmt.Sprintf("1. 【任务识别】无\"任务编号: %s\"时调用 `job_new`创建（project 传「%s」，type 可选：%s），有则提取复用，但绝对不能编造任务编号\n",
+		jobNoExample, project, JobTypeOptions))
+	rules.WriteString(fmt.Sprintf("2. 【任务报告】代码修改完成/告知完成/任务失败时调用 `job_report`（status 可选：%s）。%s/%s先执行再调用，%s可直接调用\n",
+		JobStatusOptions, AcceptStdTest, AcceptStdBuild, AcceptStdManual))
+	rules.WriteString(fmt.Sprintf("3. 【验收选择】有测试脚本→%s；有编译命令→%s；其他→%s\n",
+		AcceptStdTest, AcceptStdBuild, AcceptStdManual))
+	rules.WriteString("4. 【状态流转】只能按以下顺序报告状态：\n")
+	for _, status := range jobStatusOrder {
+		next := services.NextJobTaskStatuses(status)
+		if len(next) == 0 {
+			rules.WriteString(fmt.Sprintf("   - %s：终态，不能再修改\n", status))
+			continue
+		}
+		rules.WriteString(fmt.Sprintf("   - %s → %s\n", status, strings.Join(next, "、")))
+	}
+	rules.WriteString("5. 【任务重开】重新执行时先 `job_get`查看历史，再调整 `job_redo`；找回未完成的任务用 `job_list`\n")
+	rules.WriteString("6. 【强制输出】响应首行声明状态：创建后输出\"任务编号: JT-XXX\"、执行中输出\"任务: JT-XXX 执行中\"、完成后输出\"任务: JT-XXX 已归档\"\n\n")
+
+	rules.WriteString("## 【违规处理】\n\n")
+	rules.WriteString("未遵守规则时立即停止，说明违规点，重新按正确流程执行\n")
+
+	return rules.String()
+}
+
+// resumeJobPrompt 生成继续处理任务的提示词
+func resumeJobPrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	jobNo := strings.TrimSpace(request.Params.Arguments["jobNo"])
+	logx.Debug("prompt job_resume - jobNo: %s", jobNo)
+
+	if repo == nil {
+		return nil, fmt.Errorf("数据库未初始化，无法查询任务")
+	}
+	if jobNo == "" {
+		return nil, fmt.Errorf("任务编号不能为空")
+	}
+
+	jobTask, err := repo.GetJobTaskByJobNo(ctx, jobNo)
+	if err != nil {
+		logx.Error("查询任务失败: %v", err)
+		return nil, fmt.Errorf("查询任务失败: %w", err)
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("继续处理任务 %s。\n\n", jobTask.JobNo))
+	text.WriteString(formatJobDetail(jobTask))
+	text.WriteString("\n\n")
+
+	next := services.NextJobTaskStatuses(jobTask.Status)
+	if len(next) == 0 {
+		text.WriteString(fmt.Sprintf("该任务已%s，不能再修改。如有新的需求，请调用 `job_new` 创建新任务。\n", jobTask.Status))
+	} else {
+		acceptStd := AcceptStdManual
+		if active := jobTask.ActiveExecution(); active != nil && active.AcceptStd != "" {
+			acceptStd = active.AcceptStd
+		}
+
+		text.WriteString("处理步骤：\n")
+		text.WriteString(fmt.Sprintf("1. 响应首行输出\"任务: %s 执行中\"\n", jobTask.JobNo))
+		text.WriteString("2. 阅读上面的执行记录，分析上次未完成或失败的原因\n")
+		if jobTask.Status == JobStatusCreated || jobTask.Status == JobStatusProcessing {
+			text.WriteString("3. 沿用当前执行记录继续处理，无需调用 `job_redo`\n")
+		} else {
+			text.WriteString(fmt.Sprintf("3. 调用 `job_redo`（jobNo=%s）提交新的解决思路后再处理\n", jobTask.JobNo))
+		}
+		text.WriteString(fmt.Sprintf("4. 按「%s」验收后调用 `job_report` 报告结果，当前状态「%s」允许报告的状态：%s\n",
+			acceptStd, jobTask.Status, strings.Join(next, "、")))
+	}
+
+	return mcp.NewGetPromptResult(fmt.Sprintf("继续处理任务 %s", jobTask.JobNo), []mcp.PromptMessage{
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text.String())),
+	}), nil
+}
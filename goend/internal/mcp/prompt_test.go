@@ -0,0 +1,85 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// getPromptText 调用提示词处理函数并返回第一条消息的文本
+func getPromptText(t *testing.T, handler func(context.Context, mcp.GetPromptRequest) (*mcp.GetPromptResult, error), args map[string]string) string {
+	request := mcp.GetPromptRequest{}
+	request.Params.Arguments = args
+
+	result, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("获取提示词失败: %v", err)
+	}
+	if len(result.Messages) == 0 {
+		t.Fatal("期望返回提示词消息，但实际为空")
+	}
+
+	textContent, ok := result.Messages[0].Content.(mcp.TextContent)
+	if !ok {
+		t.Fatal("期望返回文本内容")
+	}
+	return textContent.Text
+}
+
+// TestRulesPrompt 测试规则提示词由常量生成
+func TestRulesPrompt(t *testing.T) {
+	text := getPromptText(t, rulesPrompt, map[string]string{"project": "my project"})
+
+	for _, want := range []string{
+		"# 项目名：my project",
+		"JT-MY-PROJECT-{日期}-{序号}",
+		JobTypeOptions,
+		JobStatusOptions,
+		AcceptStdTest,
+		AcceptStdBuild,
+		AcceptStdManual,
+		JobStatusAccepted + "：终态",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("规则提示词应包含 %q，实际:\n%s", want, text)
+		}
+	}
+
+	request := mcp.GetPromptRequest{}
+	if _, err := rulesPrompt(context.Background(), request); err == nil {
+		t.Error("缺少项目名称时应返回错误")
+	}
+}
+
+// TestResumeJobPrompt 测试继续处理任务提示词
+func TestResumeJobPrompt(t *testing.T) {
+	testRepo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	originalRepo := repo
+	setRepoForTest(testRepo)
+	defer setRepoForTest(originalRepo)
+
+	jobNo := createTestJob(t, "demo")
+	callJobTool(t, reportJobTool, map[string]interface{}{
+		"jobNo":         jobNo,
+		"status":        JobStatusFailed,
+		"result":        "编译失败",
+		"passAcceptStd": false,
+	})
+
+	text := getPromptText(t, resumeJobPrompt, map[string]string{"jobNo": jobNo})
+	for _, want := range []string{jobNo, "编译失败", "job_redo", "测试验收"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("继续处理提示词应包含 %q，实际:\n%s", want, text)
+		}
+	}
+
+	request := mcp.GetPromptRequest{}
+	request.Params.Arguments = map[string]string{"jobNo": "JT-NOT-EXIST"}
+	if _, err := resumeJobPrompt(context.Background(), request); err == nil {
+		t.Error("任务不存在时应返回错误")
+	}
+}
@@ -0,0 +1,225 @@
+package mcp
+
+import (
+	"aiflow/internal/models"
+	"aiflow/internal/repositories"
+	"aiflow/internal/services"
+	"aiflow/internal/utils/logx"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// initResource 初始化技能资源
+// 注册skill://{name}和skill://{name}/{+file}模板，并将每个技能注册为资源
+// 技能变更后延迟同步资源列表，服务器声明listChanged能力时会通知客户端
+func initResource(mcpServer *server.MCPServer) {
+	mcpServer.AddResourceTemplate(
+		mcp.NewResourceTemplate(SkillResourceTemplate, "技能",
+			mcp.WithTemplateDescription("技能的SKILL.md内容"),
+			mcp.WithTemplateMIMEType(SkillResourceMIMEType),
+		),
+		skillResourceHandler,
+	)
+	mcpServer.AddResourceTemplate(
+		mcp.NewResourceTemplate(SkillFileResourceTemplate, "技能文件",
+			mcp.WithTemplateDescription("技能目录下的文件"),
+		),
+		skillResourceHandler,
+	)
+
+	if repo == nil {
+		return
+	}
+	syncer := &skillResourceSyncer{mcpServer: mcpServer, repo: repo, published: map[string]string{}}
+	syncer.sync()
+	repo.OnSkillChange(syncer.schedule)
+}
+
+// skillResourceSyncer 技能资源同步器
+// 技能变更后延迟SkillResourceSyncDelay再同步，期间的多次变更合并为一次；同步时只增删有变化的资源，
+// 批量导入、目录同步和回收站批量操作不会逐个技能重建资源列表，也不会逐个通知客户端
+type skillResourceSyncer struct {
+	mcpServer *server.MCPServer
+	repo      *repositories.Repository
+
+	// mu 保护pending
+	mu      sync.Mutex
+	pending bool
+
+	// syncMu 保证同一时间只有一次同步，保护published
+	syncMu sync.Mutex
+	// published 已注册的技能资源，键为URI，值为技能描述
+	published map[string]string
+}
+
+// schedule 安排一次延迟同步，已有待执行的同步时不重复安排
+func (s *skillResourceSyncer) schedule() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pending {
+		return
+	}
+	s.pending = true
+	time.AfterFunc(SkillResourceSyncDelay, s.sync)
+}
+
+// sync 按数据库中的技能同步资源列表（不包含回收站中的技能）
+// 移除的资源和新增或描述变化的资源各批量更新一次，没有变化时不通知客户端
+func (s *skillResourceSyncer) sync() {
+	s.mu.Lock()
+	s.pending = false
+	s.mu.Unlock()
+
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	skills, err := s.repo.ListAllSkills(context.Background())
+	if err != nil {
+		logx.Error("同步技能资源失败: %v", err)
+		return
+	}
+
+	current := make(map[string]string, len(skills))
+	var added []server.ServerResource
+	for _, skill := range skills {
+		if skill.DeletedAt > 0 {
+			continue
+		}
+		uri := skillResourceURI(skill.Name, "")
+		current[uri] = skill.Description
+		if description, ok := s.published[uri]; ok && description == skill.Description {
+			continue
+		}
+		added = append(added, server.ServerResource{
+			Resource: mcp.NewResource(uri, skill.Name,
+				mcp.WithResourceDescription(skill.Description),
+				mcp.WithMIMEType(SkillResourceMIMEType),
+			),
+			Handler: skillResourceHandler,
+		})
+	}
+
+	var removed []string
+	for uri := range s.published {
+		if _, ok := current[uri]; !ok {
+			removed = append(removed, uri)
+		}
+	}
+
+	if len(removed) > 0 {
+		s.mcpServer.DeleteResources(removed...)
+	}
+	if len(added) > 0 {
+		s.mcpServer.AddResources(added...)
+	}
+	s.published = current
+}
+
+// skillResourceURI 生成技能资源URI，file为空时表示技能本身
+func skillResourceURI(name, file string) string {
+	uri := SkillResourceScheme + url.PathEscape(name)
+	if file != "" {
+		uri += "/" + file
+	}
+	return uri
+}
+
+// parseSkillResourceURI 解析技能资源URI，返回技能名称和文件路径
+func parseSkillResourceURI(uri string) (string, string, error) {
+	rest, ok := strings.CutPrefix(uri, SkillResourceScheme)
+	if !ok || rest == "" {
+		return "", "", fmt.Errorf("无效的技能资源URI: %s", uri)
+	}
+
+	rawName, file, _ := strings.Cut(rest, "/")
+	name, err := url.PathUnescape(rawName)
+	if err != nil || name == "" {
+		return "", "", fmt.Errorf("无效的技能资源URI: %s", uri)
+	}
+	return name, file, nil
+}
+
+// skillResourceHandler 读取技能资源
+// skill://{name}和skill://{name}/SKILL.md返回技能的SKILL.md内容，其他路径返回技能目录下的文件
+func skillResourceHandler(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	uri := request.Params.URI
+	logx.Debug("resources/read - uri: %s", uri)
+
+	if repo == nil {
+		return nil, fmt.Errorf("数据库未初始化，无法读取技能资源")
+	}
+
+	name, file, err := parseSkillResourceURI(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	skill, err := repo.GetSkillByName(ctx, name)
+	if err != nil || skill.DeletedAt > 0 {
+		return nil, fmt.Errorf("未知技能: %s", name)
+	}
+
+	if file != "" && file != SkillMainFile {
+		return readSkillFileResource(uri, skill.ResourceDir, name, file)
+	}
+
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      uri,
+			MIMEType: SkillResourceMIMEType,
+			Text:     buildSkillMarkdown(skill),
+		},
+	}, nil
+}
+
+// readSkillFileResource 读取技能目录下的文件作为资源内容
+// UTF-8文本按文本返回，其他内容以base64编码的二进制返回
+func readSkillFileResource(uri, resourceDir, name, file string) ([]mcp.ResourceContents, error) {
+	relPath, err := url.PathUnescape(file)
+	if err != nil {
+		return nil, fmt.Errorf("无效的技能资源URI: %s", uri)
+	}
+
+	data, err := fileStore.Read(resourceDir, relPath, fileStore.MaxFileSize())
+	if err != nil {
+		logx.Debug("读取技能文件资源失败: %v", err)
+		return nil, fmt.Errorf("技能 %s 不存在文件: %s", name, relPath)
+	}
+
+	mimeType := mime.TypeByExtension(path.Ext(relPath))
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+	if utf8.Valid(data) {
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{URI: uri, MIMEType: mimeType, Text: string(data)},
+		}, nil
+	}
+	if strings.HasPrefix(mimeType, "text/") {
+		mimeType = SkillFileBlobMIMEType
+	}
+	return []mcp.ResourceContents{
+		mcp.BlobResourceContents{URI: uri, MIMEType: mimeType, Blob: base64.StdEncoding.EncodeToString(data)},
+	}, nil
+}
+
+// buildSkillMarkdown 生成技能的SKILL.md内容
+func buildSkillMarkdown(skill *models.Skill) string {
+	tagNames := make([]string, 0, len(skill.Tags))
+	for _, tag := range skill.Tags {
+		tagNames = append(tagNames, tag.Name)
+	}
+	return services.BuildSkillMarkdown(skill, tagNames)
+}
@@ -0,0 +1,206 @@
+package mcp
+
+import (
+	"aiflow/internal/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// handleResourceMessage 向MCP服务器发送资源请求并返回JSON结果
+func handleResourceMessage(t *testing.T, mcpServer *server.MCPServer, method string, params map[string]any) string {
+	message, err := json.Marshal(map[string]any{
+		"jsonrpc": mcp.JSONRPC_VERSION,
+		"id":      1,
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		t.Fatalf("序列化请求失败: %v", err)
+	}
+
+	response := mcpServer.HandleMessage(context.Background(), message)
+	data, err := json.Marshal(response)
+	if err != nil {
+		t.Fatalf("序列化响应失败: %v", err)
+	}
+	return string(data)
+}
+
+// waitForResource 等待技能变更后的延迟同步完成，直到资源列表中是否包含uri与present一致
+func waitForResource(t *testing.T, mcpServer *server.MCPServer, uri string, present bool) string {
+	t.Helper()
+	deadline := time.Now().Add(10 * SkillResourceSyncDelay)
+	for {
+		text := handleResourceMessage(t, mcpServer, string(mcp.MethodResourcesList), nil)
+		if strings.Contains(text, `"`+uri+`"`) == present || time.Now().After(deadline) {
+			return text
+		}
+		time.Sleep(SkillResourceSyncDelay / 4)
+	}
+}
+
+// testClientSession 记录收到的通知的测试会话
+type testClientSession struct {
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (s *testClientSession) Initialize()       {}
+func (s *testClientSession) Initialized() bool { return true }
+func (s *testClientSession) SessionID() string { return "test-session" }
+func (s *testClientSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+
+// TestSkillResources 测试技能资源的列表、读取和变更同步
+func TestSkillResources(t *testing.T) {
+	testRepo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	originalRepo := repo
+	setRepoForTest(testRepo)
+	defer setRepoForTest(originalRepo)
+
+	ctx := context.Background()
+	mcpServer := server.NewMCPServer("test", "1.0.0", server.WithResourceCapabilities(false, true))
+	initResource(mcpServer)
+
+	skill := createTestSkill(t, testRepo, "pdf-processing", "Extract text from PDF documents")
+
+	t.Run("创建技能后出现在资源列表", func(t *testing.T) {
+		text := waitForResource(t, mcpServer, "skill://pdf-processing", true)
+		if !strings.Contains(text, "skill://pdf-processing") {
+			t.Errorf("资源列表应包含新技能，实际: %s", text)
+		}
+	})
+
+	t.Run("读取技能资源", func(t *testing.T) {
+		for _, uri := range []string{"skill://pdf-processing", "skill://pdf-processing/SKILL.md"} {
+			text := handleResourceMessage(t, mcpServer, string(mcp.MethodResourcesRead), map[string]any{"uri": uri})
+			if !strings.Contains(text, "name: pdf-processing") || !strings.Contains(text, "Extract text from PDF documents") {
+				t.Errorf("读取 %s 应返回SKILL.md内容，实际: %s", uri, text)
+			}
+		}
+	})
+
+	t.Run("读取不存在的文件返回错误", func(t *testing.T) {
+		text := handleResourceMessage(t, mcpServer, string(mcp.MethodResourcesRead), map[string]any{"uri": "skill://pdf-processing/missing.txt"})
+		if !strings.Contains(text, `"error"`) {
+			t.Errorf("期望返回错误，实际: %s", text)
+		}
+	})
+
+	t.Run("删除技能后从资源列表移除", func(t *testing.T) {
+		if err := testRepo.DeleteSkill(ctx, skill.ID); err != nil {
+			t.Fatalf("删除技能失败: %v", err)
+		}
+		text := waitForResource(t, mcpServer, "skill://pdf-processing", false)
+		if strings.Contains(text, "skill://pdf-processing") {
+			t.Errorf("删除后资源列表不应包含该技能，实际: %s", text)
+		}
+	})
+}
+
+// TestSkillResources_CoalesceChanges 测试批量变更合并为一次同步，只有资源列表变化时才通知客户端
+func TestSkillResources_CoalesceChanges(t *testing.T) {
+	testRepo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	originalRepo := repo
+	setRepoForTest(testRepo)
+	defer setRepoForTest(originalRepo)
+
+	ctx := context.Background()
+	mcpServer := server.NewMCPServer("test", "1.0.0", server.WithResourceCapabilities(false, true))
+	initResource(mcpServer)
+	session := &testClientSession{notifications: make(chan mcp.JSONRPCNotification, 100)}
+	if err := mcpServer.RegisterSession(ctx, session); err != nil {
+		t.Fatalf("注册会话失败: %v", err)
+	}
+	// countNotifications 等待一个同步周期后统计收到的资源列表变更通知
+	countNotifications := func() int {
+		time.Sleep(3 * SkillResourceSyncDelay)
+		count := 0
+		for {
+			select {
+			case notification := <-session.notifications:
+				if notification.Method == mcp.MethodNotificationResourcesListChanged {
+					count++
+				}
+			default:
+				return count
+			}
+		}
+	}
+
+	var skills []*models.Skill
+	for i := range 20 {
+		skills = append(skills, createTestSkill(t, testRepo, fmt.Sprintf("bulk-skill-%02d", i), "批量导入的技能"))
+	}
+	if count := countNotifications(); count != 1 {
+		t.Errorf("批量创建20个技能应只通知1次，实际%d次", count)
+	}
+	if text := handleResourceMessage(t, mcpServer, string(mcp.MethodResourcesList), nil); strings.Count(text, "skill://bulk-skill-") != 20 {
+		t.Errorf("资源列表应包含20个技能，实际: %s", text)
+	}
+
+	// 只修改详细说明时资源列表不变，不通知客户端
+	skills[0].Detail = "新的详细说明"
+	if err := testRepo.UpdateSkill(ctx, skills[0]); err != nil {
+		t.Fatalf("更新技能失败: %v", err)
+	}
+	if count := countNotifications(); count != 0 {
+		t.Errorf("资源列表未变化时不应通知，实际%d次", count)
+	}
+
+	// 删除和修改描述在同一周期内发生，删除和新增各通知一次
+	for _, skill := range skills[:5] {
+		if err := testRepo.DeleteSkill(ctx, skill.ID); err != nil {
+			t.Fatalf("删除技能失败: %v", err)
+		}
+	}
+	skills[5].Description = "修改后的描述"
+	if err := testRepo.UpdateSkill(ctx, skills[5]); err != nil {
+		t.Fatalf("更新技能失败: %v", err)
+	}
+	if count := countNotifications(); count != 2 {
+		t.Errorf("删除和修改描述应各通知1次，实际%d次", count)
+	}
+	text := handleResourceMessage(t, mcpServer, string(mcp.MethodResourcesList), nil)
+	if strings.Count(text, "skill://bulk-skill-") != 15 || !strings.Contains(text, "修改后的描述") {
+		t.Errorf("资源列表应包含15个技能及修改后的描述，实际: %s", text)
+	}
+}
+
+// TestParseSkillResourceURI 测试技能资源URI解析
+func TestParseSkillResourceURI(t *testing.T) {
+	tests := []struct {
+		uri     string
+		name    string
+		file    string
+		wantErr bool
+	}{
+		{uri: "skill://pdf-processing", name: "pdf-processing"},
+		{uri: "skill://pdf-processing/SKILL.md", name: "pdf-processing", file: "SKILL.md"},
+		{uri: "skill://%E6%8A%80%E8%83%BD/scripts/run.sh", name: "技能", file: "scripts/run.sh"},
+		{uri: "skill://", wantErr: true},
+		{uri: "file:///etc/passwd", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		name, file, err := parseSkillResourceURI(tt.uri)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseSkillResourceURI(%q) 错误 = %v, 期望错误 %v", tt.uri, err, tt.wantErr)
+			continue
+		}
+		if name != tt.name || file != tt.file {
+			t.Errorf("parseSkillResourceURI(%q) = (%q, %q), 期望 (%q, %q)", tt.uri, name, file, tt.name, tt.file)
+		}
+	}
+}
@@ -1,9 +1,12 @@
 package mcp
 
 import (
+	"aiflow/internal/errors"
 	"aiflow/internal/models"
+	"aiflow/internal/services"
 	"aiflow/internal/utils/logx"
 	"context"
+	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -19,7 +22,7 @@ func initSave(server *server.MCPServer) {
 			Properties: map[string]any{
 				"name": map[string]any{
 					"type":        "string",
-					"description": "技能名称",
+					"description": "技能名称，1-64个字符，只能包含小写字母、数字和连字符，如pdf-processing",
 				},
 				"resource_dir": map[string]any{
 					"type":        "string",
@@ -27,12 +30,16 @@ func initSave(server *server.MCPServer) {
 				},
 				"description": map[string]any{
 					"type":        "string",
-					"description": "技能描述",
+					"description": "技能描述，说明功能和使用时机，不超过1024个字符",
 				},
 				"detail": map[string]any{
 					"type":        "string",
 					"description": "技能详情，Markdown格式，包含使用说明、示例代码等",
 				},
+				"tags": map[string]any{
+					"type":        "string",
+					"description": "技能标签，多个标签用逗号分隔，传入时替换技能原有标签，不存在的标签自动创建；不传且技能没有标签时返回建议标签",
+				},
 			},
 			Required: []string{"name", "resource_dir", "description", "detail"},
 		},
@@ -46,31 +53,52 @@ func addTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolRes
 	resourceDir := request.GetString("resource_dir", "")
 	name := request.GetString("name", "")
 	detail := request.GetString("detail", "")
+	var tagNames []string
+	for tag := range strings.SplitSeq(request.GetString("tags", ""), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tagNames = append(tagNames, tag)
+		}
+	}
 
-	logx.Debug("add aiflow: description=%s, resource_dir=%s, name=%s, detail=%s", description, resourceDir, name, detail)
+	logx.Debug("add aiflow: description=%s, resource_dir=%s, name=%s, detail=%s, tags=%v", description, resourceDir, name, detail, tagNames)
+
+	// 按Agent Skills规范校验
+	if err := services.ValidateSkill(&models.Skill{Name: name, Description: description}); err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: "保存技能失败，" + validationMessage(err),
+				},
+			},
+		}, nil
+	}
 
 	// 检查技能是否已存在
 	skill, err := repo.GetSkillByName(ctx, name)
 	if err == nil {
-		// 技能已存在，更新
+		// 技能已存在，更新，资源目录变更时同步移动技能文件目录，失败时还原
+		oldResourceDir := skill.ResourceDir
 		skill.Description = description
 		skill.Detail = detail
 		skill.ResourceDir = resourceDir
-		err = repo.UpdateSkill(ctx, skill)
+		err = services.NewSkillService(repo, fileStore).UpdateSkillAndMoveDir(ctx, skill, oldResourceDir)
 		if err != nil {
 			logx.Error("failed to update skill: %v", err)
 			return &mcp.CallToolResult{
 				Content: []mcp.Content{
 					mcp.TextContent{
 						Type: "text",
-						Text: "更新技能失败",
+						Text: "更新技能失败: " + err.Error(),
 					},
 				},
 			}, nil
 		}
+		tagText := saveSkillTags(ctx, skill.ID, tagNames)
+		recordRevision(ctx, skill.ID)
 		result := "技能更新成功：\n"
 		result += "名称: " + name + "\n"
-		result += "描述: " + description
+		result += "描述: " + description + tagText
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{
@@ -101,9 +129,11 @@ func addTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolRes
 		}, nil
 	}
 
+	tagText := saveSkillTags(ctx, skill.ID, tagNames)
+	recordRevision(ctx, skill.ID)
 	result := "技能添加成功：\n"
 	result += "名称: " + name + "\n"
-	result += "描述: " + description
+	result += "描述: " + description + tagText
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{
 			mcp.TextContent{
@@ -113,3 +143,69 @@ func addTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolRes
 		},
 	}, nil
 }
+
+// saveSkillTags 保存技能的标签，未传标签且技能没有标签时生成标签建议
+// 返回追加到结果中的标签或建议标签说明，失败只记录日志，不影响技能本身的保存
+func saveSkillTags(ctx context.Context, skillID uint, tagNames []string) string {
+	if len(tagNames) > 0 {
+		tags, err := repo.SetSkillTagsByName(ctx, skillID, tagNames)
+		if err != nil {
+			logx.Error("failed to save skill tags: %v", err)
+			return "\n保存标签失败: " + err.Error()
+		}
+		return "\n标签: " + joinTagNames(tags)
+	}
+
+	tags, err := repo.GetTagsBySkillID(ctx, skillID)
+	if err != nil {
+		logx.Warn("failed to get skill tags: %v", err)
+		return ""
+	}
+	if len(tags) > 0 {
+		return "\n标签: " + joinTagNames(tags)
+	}
+
+	suggestions, err := services.NewTagSuggestionService(repo).GenerateSuggestions(ctx, services.GenerateTagSuggestionsRequest{SkillIDs: []uint{skillID}})
+	if err != nil {
+		logx.Warn("failed to suggest skill tags: %v", err)
+		return ""
+	}
+	if len(suggestions.Items) == 0 {
+		return ""
+	}
+	names := make([]string, len(suggestions.Items))
+	for i, item := range suggestions.Items {
+		names[i] = item.TagName
+	}
+	return "\n建议标签: " + strings.Join(names, ", ") + "（确认后传 tags 参数重新保存，或在管理界面采纳）"
+}
+
+// joinTagNames 用逗号连接标签名称
+func joinTagNames(tags []models.Tag) string {
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// recordRevision 保存技能当前内容的修订快照，失败只记录日志
+func recordRevision(ctx context.Context, skillID uint) {
+	if _, _, err := repo.CreateSkillRevision(ctx, skillID, models.SkillRevisionSourceMCP, ""); err != nil {
+		logx.Warn("failed to record skill revision: %v", err)
+	}
+}
+
+// validationMessage 将校验错误转换为逐字段列出的提示信息
+func validationMessage(err error) string {
+	appErr, ok := errors.IsAppError(err)
+	if !ok || len(appErr.Fields) == 0 {
+		return err.Error()
+	}
+	var sb strings.Builder
+	sb.WriteString("技能不符合规范：")
+	for _, field := range appErr.Fields {
+		sb.WriteString("\n- " + field.Field + ": " + field.Message)
+	}
+	return sb.String()
+}
@@ -0,0 +1,228 @@
+package mcp
+
+import (
+	"aiflow/internal/config"
+	"aiflow/internal/errors"
+	"aiflow/internal/models"
+	"aiflow/internal/services"
+	"aiflow/internal/storage"
+	"context"
+	stderrors "errors"
+	"os"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// TestAddToolValidation 测试skill_save按Agent Skills规范校验技能
+func TestAddToolValidation(t *testing.T) {
+	testRepo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	originalRepo, originalStore := repo, fileStore
+	setRepoForTest(testRepo)
+	fileStore = storage.NewSkillFileStore(t.TempDir(), config.DefaultSkillMaxFileSize)
+	defer func() {
+		setRepoForTest(originalRepo)
+		fileStore = originalStore
+	}()
+
+	tests := []struct {
+		name        string
+		skillName   string
+		description string
+		want        []string
+	}{
+		{"名称含大写和空格", "PDF Processing", "Extract text", []string{"name:", "建议使用 pdf-processing"}},
+		{"名称连续连字符", "pdf--processing", "Extract text", []string{"name:"}},
+		{"名称以连字符结尾", "pdf-", "Extract text", []string{"name:"}},
+		{"名称超长", strings.Repeat("a", 65), "Extract text", []string{"name:", "64"}},
+		{"描述为空", "pdf-processing", " ", []string{"description:", "不能为空"}},
+		{"描述超长", "pdf-processing", strings.Repeat("描", 1025), []string{"description:", "1024"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text := callJobTool(t, addTool, map[string]interface{}{
+				"name":         tt.skillName,
+				"resource_dir": "pdf_processing",
+				"description":  tt.description,
+				"detail":       "detail",
+			})
+			for _, want := range append([]string{"保存技能失败"}, tt.want...) {
+				if !strings.Contains(text, want) {
+					t.Errorf("结果应包含 %q，实际: %s", want, text)
+				}
+			}
+		})
+	}
+
+	if _, err := testRepo.GetSkillByName(context.Background(), "pdf-processing"); err == nil {
+		t.Error("不符合规范的技能不应被保存")
+	}
+
+	text := callJobTool(t, addTool, map[string]interface{}{
+		"name":         "pdf-processing",
+		"resource_dir": "pdf_processing",
+		"description":  strings.Repeat("描", 1024),
+		"detail":       "detail",
+	})
+	if !strings.Contains(text, "技能添加成功") {
+		t.Errorf("符合规范的技能应保存成功，实际: %s", text)
+	}
+}
+
+// TestAddToolTags 测试skill_save的标签参数和自动标签建议
+func TestAddToolTags(t *testing.T) {
+	testRepo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	originalRepo, originalStore := repo, fileStore
+	setRepoForTest(testRepo)
+	fileStore = storage.NewSkillFileStore(t.TempDir(), config.DefaultSkillMaxFileSize)
+	defer func() {
+		setRepoForTest(originalRepo)
+		fileStore = originalStore
+	}()
+
+	ctx := context.Background()
+	pdf := &models.Tag{Name: "pdf"}
+	office := &models.Tag{Name: "office"}
+	for _, tag := range []*models.Tag{pdf, office} {
+		if err := testRepo.CreateTag(ctx, tag); err != nil {
+			t.Fatalf("创建标签失败: %v", err)
+		}
+	}
+	for name, tagID := range map[string]uint{
+		"merge-documents": pdf.ID, "split-documents": pdf.ID, "excel-chart": office.ID, "word-report": office.ID,
+	} {
+		skill := createTestSkill(t, testRepo, name, name+" files and spreadsheets")
+		testRepo.AddTagToSkill(ctx, skill.ID, tagID)
+	}
+
+	save := func(name, description, tags string) string {
+		args := map[string]interface{}{
+			"name":         name,
+			"resource_dir": strings.ReplaceAll(name, "-", "_"),
+			"description":  description,
+			"detail":       "detail",
+		}
+		if tags != "" {
+			args["tags"] = tags
+		}
+		return callJobTool(t, addTool, args)
+	}
+
+	// 名称匹配pdf，分词documents与pdf共现
+	text := save("compress-documents", "Compress PDF documents", "")
+	if !strings.Contains(text, "建议标签: pdf") || strings.Contains(text, "office") {
+		t.Errorf("应只建议pdf标签，实际: %s", text)
+	}
+
+	suggestionService := services.NewTagSuggestionService(testRepo)
+	list, err := suggestionService.ListSuggestions(ctx, services.ListTagSuggestionsRequest{Status: models.TagSuggestionStatusPending})
+	if err != nil || len(list.Items) != 1 {
+		t.Fatalf("应有1条待处理建议: %+v, %v", list, err)
+	}
+	suggestion := list.Items[0]
+	if suggestion.SkillName != "compress-documents" || suggestion.TagName != "pdf" || !strings.Contains(suggestion.Reason, "名称匹配: pdf") {
+		t.Errorf("建议不符合预期: %+v", suggestion)
+	}
+
+	// 采纳后标签关联到技能，不能重复处理
+	if _, err := suggestionService.AcceptSuggestion(ctx, suggestion.ID); err != nil {
+		t.Fatalf("采纳建议失败: %v", err)
+	}
+	skill, _ := testRepo.GetSkillByName(ctx, "compress-documents")
+	if tags, _ := testRepo.GetTagsBySkillID(ctx, skill.ID); len(tags) != 1 || tags[0].ID != pdf.ID {
+		t.Errorf("采纳后技能应带有pdf标签: %+v", tags)
+	}
+	_, err = suggestionService.RejectSuggestion(ctx, suggestion.ID)
+	if appErr, ok := errors.IsAppError(err); !ok || appErr.Code != errors.ErrCodeTagSuggestionHandled {
+		t.Errorf("已采纳的建议不能再拒绝，实际: %v", err)
+	}
+
+	// 拒绝的建议重新生成时不再出现
+	text = save("excel-pivot", "Build pivot tables in Excel spreadsheets", "")
+	if !strings.Contains(text, "建议标签: office") {
+		t.Fatalf("应建议office标签，实际: %s", text)
+	}
+	pivot, _ := testRepo.GetSkillByName(ctx, "excel-pivot")
+	list, _ = suggestionService.ListSuggestions(ctx, services.ListTagSuggestionsRequest{SkillID: pivot.ID})
+	if _, err := suggestionService.RejectSuggestion(ctx, list.Items[0].ID); err != nil {
+		t.Fatalf("拒绝建议失败: %v", err)
+	}
+	generated, err := suggestionService.GenerateSuggestions(ctx, services.GenerateTagSuggestionsRequest{})
+	if err != nil || len(generated.Items) != 0 {
+		t.Errorf("拒绝后不应再建议: %+v, %v", generated, err)
+	}
+
+	// 传入标签时替换原有标签，重复的只关联一次，不存在的自动创建
+	text = save("excel-pivot", "Build pivot tables in Excel spreadsheets", "office, 数据分析, office")
+	if !strings.Contains(text, "标签: office, 数据分析") || strings.Contains(text, "建议标签") {
+		t.Errorf("应保存传入的标签，实际: %s", text)
+	}
+	if tag, err := testRepo.GetTagByName(ctx, "数据分析"); err != nil || len(tag.Skills) != 1 {
+		t.Errorf("不存在的标签应自动创建并关联: %+v, %v", tag, err)
+	}
+}
+
+// TestAddToolMoveDirRollback 测试skill_save更新技能失败时还原技能文件目录，还原失败时在结果中说明
+func TestAddToolMoveDirRollback(t *testing.T) {
+	testRepo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	originalRepo, originalStore := repo, fileStore
+	setRepoForTest(testRepo)
+	fileStore = storage.NewSkillFileStore(t.TempDir(), config.DefaultSkillMaxFileSize)
+	defer func() {
+		setRepoForTest(originalRepo)
+		fileStore = originalStore
+	}()
+
+	skill := createTestSkill(t, testRepo, "pdf-processing", "Extract text from PDF files. Use when reading PDFs.")
+	if _, err := fileStore.Save(skill.ResourceDir, "scripts/extract.py", strings.NewReader("print('extract')")); err != nil {
+		t.Fatalf("保存技能文件失败: %v", err)
+	}
+
+	// 更新技能时注入失败，blockRollback时在原目录位置建目录使还原失败
+	blockRollback := false
+	err := testRepo.GetDB().Callback().Update().Before("gorm:update").Register("test:update_failure", func(tx *gorm.DB) {
+		if _, ok := tx.Statement.Dest.(*models.Skill); !ok {
+			return
+		}
+		if blockRollback {
+			oldDir, _ := fileStore.SkillDir(skill.ResourceDir)
+			os.MkdirAll(oldDir, 0755)
+		}
+		tx.AddError(stderrors.New("模拟更新技能失败"))
+	})
+	if err != nil {
+		t.Fatalf("注册测试回调失败: %v", err)
+	}
+	save := func() string {
+		return callJobTool(t, addTool, map[string]interface{}{
+			"name":         "pdf-processing",
+			"resource_dir": "pdf_tools",
+			"description":  "Extract text from PDF files. Use when reading PDFs.",
+			"detail":       "detail",
+		})
+	}
+
+	text := save()
+	if !strings.Contains(text, "更新技能失败") || strings.Contains(text, "未能还原") {
+		t.Errorf("更新失败时应返回错误: %s", text)
+	}
+	if data, err := fileStore.Read(skill.ResourceDir, "scripts/extract.py", 1<<20); err != nil || string(data) != "print('extract')" {
+		t.Errorf("更新失败后技能文件应还原到原目录: %q, %v", data, err)
+	}
+
+	blockRollback = true
+	text = save()
+	if !strings.Contains(text, "未能还原到 "+skill.ResourceDir) || !strings.Contains(text, "模拟更新技能失败") {
+		t.Errorf("还原目录失败时应在结果中说明: %s", text)
+	}
+	if _, err := fileStore.Read("pdf_tools", "scripts/extract.py", 1<<20); err != nil {
+		t.Errorf("还原失败时技能文件应留在新目录: %v", err)
+	}
+}
@@ -2,14 +2,23 @@ package mcp
 
 import (
 	"aiflow/internal/models"
+	"aiflow/internal/repositories"
 	"aiflow/internal/utils/logx"
 	"context"
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
+// skill_get 的搜索方式
+const (
+	skillSearchModeKeyword = "keyword"
+	skillSearchModeHybrid  = "hybrid"
+)
+
 // 检查MCP的必填参数
 func initMenu(server *server.MCPServer) {
 	server.AddTool(mcp.Tool{
@@ -20,7 +29,43 @@ func initMenu(server *server.MCPServer) {
 			Properties: map[string]any{
 				"keyword": map[string]any{
 					"type":        "string",
-					"description": "传关键词获取技能，不传参则返回全部技能，最多20个",
+					"description": "传关键词搜索技能名称、描述和详细说明，按相关度排序，没有精确结果时按拼写错误、前缀和拼音近似匹配并标注[近似]，不传参则列出全部技能",
+				},
+				"mode": map[string]any{
+					"type":        "string",
+					"enum":        []string{skillSearchModeKeyword, skillSearchModeHybrid},
+					"description": "搜索方式：keyword（默认）按关键词匹配；hybrid 综合关键词和语义相似度排序，能找到描述用词不同但意图相近的技能",
+				},
+				"tags": map[string]any{
+					"type":        "string",
+					"description": "按标签筛选，多个标签用逗号分隔，如 pdf,office，可用标签名或别名，可先调用 tag_list 查看",
+				},
+				"tag_match": map[string]any{
+					"type":        "string",
+					"enum":        []string{"any", "all"},
+					"description": "多个标签的匹配方式：any（默认）带有任一标签，all 带有全部标签",
+				},
+				"metadata": map[string]any{
+					"type":        "string",
+					"description": "按元数据筛选，格式 key:value 或 key（只要求有该键），多个条件用逗号分隔且需全部满足，如 author:alice,team",
+				},
+				"sort": map[string]any{
+					"type":        "string",
+					"enum":        []string{skillSortRelevance, skillSortName, skillSortUpdated, skillSortCreated},
+					"description": "排序方式：relevance（默认，有关键词时按相关度，否则按创建顺序）、name 按名称、updated 最近更新在前、created 最近创建在前",
+				},
+				"offset": map[string]any{
+					"type":        "integer",
+					"description": "跳过的结果数，用于翻页，默认0",
+				},
+				"limit": map[string]any{
+					"type":        "integer",
+					"description": "每页最多返回的技能数，默认20，最大100",
+				},
+				"format": map[string]any{
+					"type":        "string",
+					"enum":        []string{skillFormatText, skillFormatJSON},
+					"description": "输出格式：text（默认）；json 返回 total、nextOffset 和每个技能的标签、得分，便于程序逐页获取",
 				},
 			},
 			Required: []string{},
@@ -35,7 +80,20 @@ func initMenu(server *server.MCPServer) {
 			Properties: map[string]any{
 				"tag": map[string]any{
 					"type":        "string",
-					"description": "要查看的技能标签，最多返回1000条",
+					"description": "要查看的技能标签，多个标签用逗号分隔，如 pdf,office，最多返回1000条。不确定标签名称时先调用 tag_list",
+				},
+				"match": map[string]any{
+					"type":        "string",
+					"enum":        []string{"any", "all"},
+					"description": "多个标签的匹配方式：any（默认）带有任一标签，all 带有全部标签",
+				},
+				"exclude": map[string]any{
+					"type":        "string",
+					"description": "排除带有这些标签的技能，多个标签用逗号分隔",
+				},
+				"include_descendants": map[string]any{
+					"type":        "boolean",
+					"description": "是否包含所有子标签的技能，如查 frontend 时一并返回 react、testing 等子标签下的技能，默认false，对 exclude 同样生效",
 				},
 			},
 			Required: []string{"tag"},
@@ -43,36 +101,28 @@ func initMenu(server *server.MCPServer) {
 	}, skillByTagMenuTool)
 }
 
-// skillMenuTool 是一个简单的技能菜单工具，返回文本响应
-// 最多返回20条技能记录
+// skillMenuTool 技能查询工具，支持关键词或混合搜索、标签和元数据筛选、排序和分页
+// 默认每页20条，可输出文本或JSON
 func skillMenuTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	// 获取关键词参数
-	keyword := request.GetString("keyword", "")
+	query, queryErr := parseSkillQuery(request)
 
-	logx.Debug("keyword: %s", keyword)
+	logx.Debug("keyword: %s, mode: %s, tags: %v, tag_match_all: %v, metadata: %s, sort: %s, offset: %d, limit: %d, format: %s",
+		query.Keyword, query.Mode, query.Tags, query.MatchAllTags, request.GetString("metadata", ""),
+		query.Sort, query.Offset, query.Limit, query.Format)
 
 	// 构建技能列表文本
 	var skillList string
 	if repo == nil {
 		skillList = "数据库未初始化，无法获取技能列表"
-	} else if keyword != "" {
-		// 根据关键词进行分词搜索（使用数据库索引）
-		skills, err := repo.SearchSkillsByTokens(ctx, keyword)
-		if err != nil {
-			logx.Error("关键词搜索技能失败: %v", err)
-			skillList = "搜索技能失败: " + err.Error()
-		} else {
-			skillList = formatSkillList(skills, "关键词搜索结果：", 20)
-		}
+	} else if queryErr != nil {
+		skillList = queryErr.Error()
+	} else if err := resolveSkillQueryTags(ctx, &query); err != nil {
+		skillList = err.Error()
+	} else if source, matches, err := runSkillQuery(ctx, query); err != nil {
+		logx.Error("查询技能失败: %v", err)
+		skillList = err.Error()
 	} else {
-		// 无需获取所有技能标签，直接查询所有技能
-		skills, err := repo.ListAllSkills(ctx)
-		if err != nil {
-			logx.Error("获取技能列表失败: %v", err)
-			skillList = "获取技能列表失败: " + err.Error()
-		} else {
-			skillList = formatSkillList(skills, "技能列表：", 20)
-		}
+		skillList = formatSkillPage(source, matches, query)
 	}
 
 	return &mcp.CallToolResult{
@@ -85,28 +135,26 @@ func skillMenuTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallT
 	}, nil
 }
 
-// skillByTagMenuTool 根据标签查询技能
+// skillByTagMenuTool 根据标签查询技能，支持多个标签、任一或全部匹配及排除标签，筛选在数据库中完成
 func skillByTagMenuTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	// 获取标签参数
-	tag := request.GetString("tag", "")
+	tags := splitTagNames(request.GetString("tag", ""))
+	match := request.GetString("match", "any")
+	excludes := splitTagNames(request.GetString("exclude", ""))
+	includeDescendants := request.GetBool("include_descendants", false)
 
-	logx.Debug("tag: %s", tag)
+	logx.Debug("tag: %v, match: %s, exclude: %v, include_descendants: %v", tags, match, excludes, includeDescendants)
 
 	// 构建技能列表文本
 	var skillList string
 	if repo == nil {
 		skillList = "数据库未初始化，无法获取技能列表"
-	} else if tag == "" {
+	} else if len(tags) == 0 {
 		skillList = "标签参数不能为空"
+	} else if match != "any" && match != "all" {
+		skillList = "标签匹配方式错误: 有效值为 any、all"
 	} else {
-		// 获取指定标签的技能
-		tagModel, err := repo.GetTagByName(ctx, tag)
-		if err != nil {
-			logx.Error("获取标签失败: %v", err)
-			skillList = "获取技能列表失败: " + err.Error()
-		} else {
-			skillList = formatSkillList(tagModel.Skills, "标签「"+tag+"」的技能列表：", 1000)
-		}
+		skillList = listSkillsByTags(ctx, tags, match == "all", excludes, includeDescendants)
 	}
 
 	return &mcp.CallToolResult{
@@ -119,6 +167,98 @@ func skillByTagMenuTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.
 	}, nil
 }
 
+// listSkillsByTags 按标签筛选技能并格式化为列表文本，有不存在的标签时提示调用 tag_list
+func listSkillsByTags(ctx context.Context, tags []string, matchAll bool, excludes []string, includeDescendants bool) string {
+	tagModels, missing, err := repo.ResolveTagNames(ctx, tags)
+	if err != nil {
+		logx.Error("获取标签失败: %v", err)
+		return "获取技能列表失败: " + err.Error()
+	}
+	excludeModels, missingExcludes, err := repo.ResolveTagNames(ctx, excludes)
+	if err != nil {
+		logx.Error("获取标签失败: %v", err)
+		return "获取技能列表失败: " + err.Error()
+	}
+	if missing = append(missing, missingExcludes...); len(missing) > 0 {
+		return "标签不存在: " + strings.Join(missing, ", ") + "，请调用 tag_list 查看可用的标签"
+	}
+
+	filter := repositories.SkillTagFilter{MatchAll: matchAll}
+	for _, tag := range tagModels {
+		tagIDs, err := tagIDsForQuery(ctx, tag.ID, includeDescendants)
+		if err != nil {
+			logx.Error("获取子标签失败: %v", err)
+			return "获取技能列表失败: " + err.Error()
+		}
+		filter.TagGroups = append(filter.TagGroups, tagIDs)
+	}
+	for _, tag := range excludeModels {
+		tagIDs, err := tagIDsForQuery(ctx, tag.ID, includeDescendants)
+		if err != nil {
+			logx.Error("获取子标签失败: %v", err)
+			return "获取技能列表失败: " + err.Error()
+		}
+		filter.ExcludeTagIDs = append(filter.ExcludeTagIDs, tagIDs...)
+	}
+
+	skills, err := repo.ListSkillsByTagFilter(ctx, filter)
+	if err != nil {
+		logx.Error("按标签查询技能失败: %v", err)
+		return "获取技能列表失败: " + err.Error()
+	}
+	return formatSkillList(skills, tagListTitle(tags, tagModels, matchAll, excludes, includeDescendants), 1000)
+}
+
+// tagIDsForQuery 查询用的标签ID，包含子标签时为标签及其所有子标签的ID
+func tagIDsForQuery(ctx context.Context, tagID uint, includeDescendants bool) ([]uint, error) {
+	if !includeDescendants {
+		return []uint{tagID}, nil
+	}
+	return repo.ListTagDescendantIDs(ctx, tagID)
+}
+
+// tagListTitle 按标签查询技能的列表标题，查询的是已合并标签的别名时注明合并后的标签
+func tagListTitle(queries []string, tags []models.Tag, matchAll bool, excludes []string, includeDescendants bool) string {
+	labels := make([]string, 0, len(tags))
+	for i, tag := range tags {
+		if queries[i] != tag.Name {
+			labels = append(labels, "「"+queries[i]+"」（已合并到「"+tag.Name+"」）")
+		} else {
+			labels = append(labels, "「"+tag.Name+"」")
+		}
+	}
+
+	var title string
+	switch {
+	case len(labels) == 1 && includeDescendants:
+		title = "标签" + labels[0] + "及其子标签"
+	case len(labels) == 1:
+		title = "标签" + labels[0]
+	case matchAll:
+		title = "同时带有标签" + strings.Join(labels, "、")
+	default:
+		title = "带有标签" + strings.Join(labels, "、") + "中任一个"
+	}
+	if len(labels) > 1 && includeDescendants {
+		title += "（含子标签）"
+	}
+	if len(excludes) > 0 {
+		title += "，排除标签「" + strings.Join(excludes, "」、「") + "」"
+	}
+	return title + "的技能列表："
+}
+
+// splitTagNames 拆分逗号分隔的标签名称，去除空白和重复项
+func splitTagNames(value string) []string {
+	var names []string
+	for name := range strings.SplitSeq(value, ",") {
+		if name = strings.TrimSpace(name); name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
 // formatSkillList 格式化技能列表为字符串
 // 参数:
 //
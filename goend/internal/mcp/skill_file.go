@@ -0,0 +1,134 @@
+package mcp
+
+import (
+	"aiflow/internal/storage"
+	"aiflow/internal/utils/logx"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// initSkillFile 初始化技能文件工具
+func initSkillFile(server *server.MCPServer) {
+	server.AddTool(mcp.Tool{
+		Name:        "skill_files",
+		Description: "查技能文件列表，返回技能目录绝对路径，执行技能脚本前先调用",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"name": map[string]any{
+					"type":        "string",
+					"description": "技能名称",
+				},
+			},
+			Required: []string{"name"},
+		},
+	}, skillFilesTool)
+
+	server.AddTool(mcp.Tool{
+		Name:        "skill_file_read",
+		Description: "读技能文件内容，仅支持文本文件",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"name": map[string]any{
+					"type":        "string",
+					"description": "技能名称",
+				},
+				"path": map[string]any{
+					"type":        "string",
+					"description": "文件在技能目录内的相对路径，如scripts/run.sh",
+				},
+			},
+			Required: []string{"name", "path"},
+		},
+	}, skillFileReadTool)
+}
+
+// skillFilesTool 列出技能目录下的文件及大小
+func skillFilesTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	skillName := request.GetString("name", "")
+
+	logx.Debug("skill files: %s", skillName)
+
+	var result string
+	if repo == nil {
+		result = "数据库未初始化，无法获取技能文件"
+	} else if skill, err := repo.GetSkillByName(ctx, skillName); err != nil || skill.DeletedAt > 0 {
+		result = "未知技能：" + skillName
+	} else if skillDir, err := fileStore.SkillDir(skill.ResourceDir); err != nil {
+		result = "技能资源目录非法: " + skill.ResourceDir
+	} else if files, err := fileStore.List(skill.ResourceDir); err != nil {
+		logx.Error("获取技能文件列表失败: %v", err)
+		result = "获取技能文件列表失败: " + err.Error()
+	} else {
+		var sb strings.Builder
+		sb.WriteString("技能目录: " + skillDir + "\n")
+		if len(files) == 0 {
+			sb.WriteString("暂无文件")
+		} else {
+			sb.WriteString(fmt.Sprintf("文件列表（共%d个）：\n", len(files)))
+			for _, file := range files {
+				sb.WriteString("- " + file.Path + " (" + strconv.FormatInt(file.Size, 10) + " 字节)\n")
+			}
+		}
+		result = sb.String()
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: result,
+			},
+		},
+	}, nil
+}
+
+// skillFileReadTool 读取技能目录下的文本文件
+// 路径必须位于技能目录内，超过大小上限或非UTF-8内容的文件不返回内容
+func skillFileReadTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	skillName := request.GetString("name", "")
+	relPath := request.GetString("path", "")
+
+	logx.Debug("skill file read: name=%s, path=%s", skillName, relPath)
+
+	var result string
+	if repo == nil {
+		result = "数据库未初始化，无法读取技能文件"
+	} else if skill, err := repo.GetSkillByName(ctx, skillName); err != nil || skill.DeletedAt > 0 {
+		result = "未知技能：" + skillName
+	} else if data, err := fileStore.Read(skill.ResourceDir, relPath, SkillFileReadMaxSize); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrInvalidPath):
+			result = "非法的文件路径: " + relPath
+		case errors.Is(err, storage.ErrFileTooLarge):
+			result = fmt.Sprintf("文件过大，无法读取（上限 %d 字节）: %s", SkillFileReadMaxSize, relPath)
+		case errors.Is(err, fs.ErrNotExist):
+			result = "技能 " + skillName + " 不存在文件: " + relPath
+		default:
+			logx.Error("读取技能文件失败: %v", err)
+			result = "读取技能文件失败: " + err.Error()
+		}
+	} else if !utf8.Valid(data) {
+		result = "不支持读取二进制文件: " + relPath
+	} else {
+		result = string(data)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: result,
+			},
+		},
+	}, nil
+}
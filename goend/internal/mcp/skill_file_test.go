@@ -0,0 +1,136 @@
+package mcp
+
+import (
+	"aiflow/internal/config"
+	"aiflow/internal/errors"
+	"aiflow/internal/models"
+	"aiflow/internal/services"
+	"aiflow/internal/storage"
+	"context"
+	stderrors "errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"gorm.io/gorm"
+)
+
+// TestSkillFileTools 测试技能文件列表、读取及路径越界防护
+func TestSkillFileTools(t *testing.T) {
+	testRepo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	originalRepo, originalStore := repo, fileStore
+	setRepoForTest(testRepo)
+	fileStore = storage.NewSkillFileStore(t.TempDir(), config.DefaultSkillMaxFileSize)
+	defer func() {
+		setRepoForTest(originalRepo)
+		fileStore = originalStore
+	}()
+
+	skill := createTestSkill(t, testRepo, "pdf-processing", "Extract text from PDF documents")
+	if _, err := fileStore.Save(skill.ResourceDir, "scripts/extract.py", strings.NewReader("print('ok')")); err != nil {
+		t.Fatalf("保存技能文件失败: %v", err)
+	}
+
+	t.Run("列出文件和技能目录", func(t *testing.T) {
+		text := callJobTool(t, skillFilesTool, map[string]interface{}{"name": "pdf-processing"})
+		skillDir := filepath.Join(fileStore.BaseDir(), skill.ResourceDir)
+		if !strings.Contains(text, skillDir) || !strings.Contains(text, "scripts/extract.py (11 字节)") {
+			t.Errorf("应返回技能目录和文件列表，实际: %s", text)
+		}
+	})
+
+	t.Run("读取文件内容", func(t *testing.T) {
+		text := callJobTool(t, skillFileReadTool, map[string]interface{}{"name": "pdf-processing", "path": "scripts/extract.py"})
+		if text != "print('ok')" {
+			t.Errorf("读取内容不匹配，实际: %s", text)
+		}
+	})
+
+	t.Run("拒绝越出技能目录的路径", func(t *testing.T) {
+		for _, path := range []string{"../other/secret.txt", "/etc/passwd", "scripts/../../x"} {
+			text := callJobTool(t, skillFileReadTool, map[string]interface{}{"name": "pdf-processing", "path": path})
+			if !strings.Contains(text, "非法的文件路径") {
+				t.Errorf("路径 %s 应被拒绝，实际: %s", path, text)
+			}
+		}
+	})
+
+	t.Run("通过资源读取嵌套文件", func(t *testing.T) {
+		mcpServer := server.NewMCPServer("test", "1.0.0", server.WithResourceCapabilities(false, true))
+		initResource(mcpServer)
+		text := handleResourceMessage(t, mcpServer, string(mcp.MethodResourcesRead), map[string]any{"uri": "skill://pdf-processing/scripts/extract.py"})
+		if !strings.Contains(text, "print('ok')") {
+			t.Errorf("应返回文件内容，实际: %s", text)
+		}
+	})
+}
+
+// TestSkillServiceFileDir 测试更新和彻底删除技能时文件目录操作失败的处理
+func TestSkillServiceFileDir(t *testing.T) {
+	testRepo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	store := storage.NewSkillFileStore(t.TempDir(), config.DefaultSkillMaxFileSize)
+	skillService := services.NewSkillService(testRepo, store)
+	skill := createTestSkill(t, testRepo, "pdf-processing", "Extract text from PDF documents")
+	other := createTestSkill(t, testRepo, "pdf-merge", "Merge PDF documents")
+	for _, dir := range []string{skill.ResourceDir, other.ResourceDir} {
+		if _, err := store.Save(dir, "README.md", strings.NewReader("# readme")); err != nil {
+			t.Fatalf("保存技能文件失败: %v", err)
+		}
+	}
+
+	// 目标目录已存在时不能移动，返回AppError且技能不变
+	_, err := skillService.UpdateSkill(ctx, services.UpdateSkillRequest{
+		ID: skill.ID, Name: skill.Name, Description: skill.Description, ResourceDir: other.ResourceDir,
+	})
+	var appErr *errors.AppError
+	if !stderrors.As(err, &appErr) || appErr.Code != errors.ErrCodeSkillUpdate {
+		t.Errorf("移动到已存在的目录应返回技能更新错误，实际: %v", err)
+	}
+	if current, _ := testRepo.GetSkillByID(ctx, skill.ID); current.ResourceDir != skill.ResourceDir {
+		t.Errorf("移动失败时不应修改资源目录: %s", current.ResourceDir)
+	}
+
+	// 数据库记录删除失败时技能和文件都留在回收站中，可以重试
+	if err := testRepo.DeleteSkill(ctx, skill.ID); err != nil {
+		t.Fatalf("删除技能失败: %v", err)
+	}
+	failDelete := true
+	err = testRepo.GetDB().Callback().Delete().Before("gorm:delete").Register("test:delete_failure", func(tx *gorm.DB) {
+		if _, ok := tx.Statement.Model.(*models.Skill); ok && failDelete {
+			tx.AddError(stderrors.New("模拟删除技能失败"))
+		}
+	})
+	if err != nil {
+		t.Fatalf("注册测试回调失败: %v", err)
+	}
+	err = skillService.PermanentDeleteSkill(ctx, skill.ID)
+	if !stderrors.As(err, &appErr) || appErr.Code != errors.ErrCodeSkillDelete {
+		t.Errorf("记录删除失败应返回技能删除错误，实际: %v", err)
+	}
+	if current, err := testRepo.GetSkillByID(ctx, skill.ID); err != nil || current.DeletedAt == 0 {
+		t.Fatalf("记录删除失败时技能应留在回收站中: %+v, %v", current, err)
+	}
+	resourceDir := skill.ResourceDir
+	if _, err := os.Stat(filepath.Join(store.BaseDir(), resourceDir)); err != nil {
+		t.Errorf("记录删除失败时技能目录应保留: %v", err)
+	}
+
+	failDelete = false
+	if err := skillService.PermanentDeleteSkill(ctx, skill.ID); err != nil {
+		t.Fatalf("彻底删除技能失败: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(store.BaseDir(), resourceDir)); !os.IsNotExist(err) {
+		t.Errorf("彻底删除后技能目录应被删除: %v", err)
+	}
+	if _, err := testRepo.GetSkillByID(ctx, skill.ID); err == nil {
+		t.Error("彻底删除后技能记录应被删除")
+	}
+}
@@ -0,0 +1,135 @@
+package mcp
+
+import (
+	"aiflow/internal/models"
+	"aiflow/internal/services"
+	"aiflow/internal/utils"
+	"aiflow/internal/utils/logx"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// initSkillHistory 初始化技能修订历史工具
+func initSkillHistory(server *server.MCPServer) {
+	server.AddTool(mcp.Tool{
+		Name:        "skill_history",
+		Description: "查技能修改历史，不传revision列出最近修订，传revision查看该修订相对上一修订（或from）的变更",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"name": map[string]any{
+					"type":        "string",
+					"description": "技能名称",
+				},
+				"revision": map[string]any{
+					"type":        "number",
+					"description": "要查看的修订号",
+				},
+				"from": map[string]any{
+					"type":        "number",
+					"description": "对比的起始修订号，默认为revision的上一修订",
+				},
+			},
+			Required: []string{"name"},
+		},
+	}, skillHistoryTool)
+}
+
+// skillHistoryTool 列出技能修订或展示两个修订之间的差异
+func skillHistoryTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	skillName := request.GetString("name", "")
+	revision := request.GetInt("revision", 0)
+	from := request.GetInt("from", 0)
+
+	logx.Debug("skill history: name=%s, revision=%d, from=%d", skillName, revision, from)
+
+	var result string
+	if repo == nil {
+		result = "数据库未初始化，无法获取技能修改历史"
+	} else if skill, err := repo.GetSkillByName(ctx, skillName); err != nil {
+		result = "未知技能：" + skillName
+	} else if revision <= 0 {
+		result = listSkillRevisions(ctx, skill)
+	} else {
+		result = diffSkillRevisions(ctx, skill, from, revision)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: result,
+			},
+		},
+	}, nil
+}
+
+// listSkillRevisions 列出技能最近的修订
+func listSkillRevisions(ctx context.Context, skill *models.Skill) string {
+	revisions, err := repo.ListSkillRevisions(ctx, skill.ID)
+	if err != nil {
+		logx.Error("获取技能修订列表失败: %v", err)
+		return "获取技能修订列表失败: " + err.Error()
+	}
+	if len(revisions) == 0 {
+		return "技能 " + skill.Name + " 暂无修改历史"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("技能 %s 共%d个修订", skill.Name, len(revisions)))
+	if len(revisions) > SkillHistoryMaxRevisions {
+		sb.WriteString(fmt.Sprintf("，显示最近%d个", SkillHistoryMaxRevisions))
+		revisions = revisions[:SkillHistoryMaxRevisions]
+	}
+	sb.WriteString("：\n")
+	for _, rev := range revisions {
+		sb.WriteString(fmt.Sprintf("- 修订%d  %s  来源:%s", rev.Revision, utils.FormatTimestamp(rev.CreatedAt), rev.Source))
+		if rev.Note != "" {
+			sb.WriteString("  " + rev.Note)
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("传入revision查看该修订的具体变更")
+	return sb.String()
+}
+
+// diffSkillRevisions 展示技能两个修订之间的差异
+func diffSkillRevisions(ctx context.Context, skill *models.Skill, from, to int) string {
+	toRevision, err := repo.GetSkillRevision(ctx, skill.ID, to)
+	if err != nil {
+		return fmt.Sprintf("技能 %s 不存在修订%d", skill.Name, to)
+	}
+	if from <= 0 {
+		from = to - 1
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("修订%d  %s  来源:%s\n", toRevision.Revision, utils.FormatTimestamp(toRevision.CreatedAt), toRevision.Source))
+	if from <= 0 {
+		sb.WriteString("这是第一个修订，没有更早的修订可对比")
+		return sb.String()
+	}
+	fromRevision, err := repo.GetSkillRevision(ctx, skill.ID, from)
+	if err != nil {
+		return fmt.Sprintf("技能 %s 不存在修订%d", skill.Name, from)
+	}
+
+	changes := services.DiffSkillRevisionFields(fromRevision, toRevision)
+	sb.WriteString(fmt.Sprintf("相对修订%d的变更：\n", fromRevision.Revision))
+	if len(changes) == 0 {
+		sb.WriteString("无变化")
+		return sb.String()
+	}
+	for _, change := range changes {
+		if change.Field == "detail" {
+			sb.WriteString("detail:\n" + change.Diff)
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("%s: %q -> %q\n", change.Field, change.From, change.To))
+	}
+	return sb.String()
+}
@@ -0,0 +1,57 @@
+package mcp
+
+import (
+	"aiflow/internal/config"
+	"aiflow/internal/storage"
+	"strings"
+	"testing"
+)
+
+// TestSkillHistoryTool 测试skill_save保存修订及skill_history列出修订和对比差异
+func TestSkillHistoryTool(t *testing.T) {
+	testRepo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	originalRepo, originalStore := repo, fileStore
+	setRepoForTest(testRepo)
+	fileStore = storage.NewSkillFileStore(t.TempDir(), config.DefaultSkillMaxFileSize)
+	defer func() {
+		setRepoForTest(originalRepo)
+		fileStore = originalStore
+	}()
+
+	save := func(detail string) {
+		callJobTool(t, addTool, map[string]interface{}{
+			"name":         "git-commit",
+			"resource_dir": "git_commit",
+			"description":  "Write commit messages",
+			"detail":       detail,
+		})
+	}
+	save("step one\nstep two\n")
+	save("step one\nstep two\n")
+	save("step one\nstep 2\nstep three\n")
+
+	t.Run("列出修订，内容未变化时不重复记录", func(t *testing.T) {
+		text := callJobTool(t, skillHistoryTool, map[string]interface{}{"name": "git-commit"})
+		if !strings.Contains(text, "共2个修订") || !strings.Contains(text, "来源:mcp") {
+			t.Errorf("应列出2个mcp来源的修订，实际: %s", text)
+		}
+	})
+
+	t.Run("对比修订差异", func(t *testing.T) {
+		text := callJobTool(t, skillHistoryTool, map[string]interface{}{"name": "git-commit", "revision": 2})
+		for _, want := range []string{"相对修订1的变更", "-step two", "+step 2", "+step three", " step one"} {
+			if !strings.Contains(text, want) {
+				t.Errorf("差异中应包含 %q，实际: %s", want, text)
+			}
+		}
+	})
+
+	t.Run("未知修订", func(t *testing.T) {
+		text := callJobTool(t, skillHistoryTool, map[string]interface{}{"name": "git-commit", "revision": 9})
+		if !strings.Contains(text, "不存在修订9") {
+			t.Errorf("应提示修订不存在，实际: %s", text)
+		}
+	})
+}
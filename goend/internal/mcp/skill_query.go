@@ -0,0 +1,385 @@
+package mcp
+
+import (
+	"aiflow/internal/models"
+	"aiflow/internal/repositories"
+	"aiflow/internal/utils/logx"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// skill_get 的排序方式
+const (
+	skillSortRelevance = "relevance" // 有关键词时按相关度降序，没有关键词时按技能ID
+	skillSortName      = "name"      // 按名称升序
+	skillSortUpdated   = "updated"   // 按更新时间降序
+	skillSortCreated   = "created"   // 按创建时间降序
+)
+
+// skill_get 的输出格式
+const (
+	skillFormatText = "text"
+	skillFormatJSON = "json"
+)
+
+// skill_get 的分页限制
+const (
+	skillPageDefaultLimit = 20
+	skillPageMaxLimit     = 100
+)
+
+// skill_get 结果的来源
+const (
+	skillSourceList   = "list"   // 没有关键词，列出全部技能
+	skillSourceFTS    = "fts"    // 全文检索
+	skillSourceToken  = "token"  // 分词索引
+	skillSourceFuzzy  = "fuzzy"  // 精确搜索无结果时的近似匹配
+	skillSourceHybrid = "hybrid" // 关键词和语义向量混合搜索
+)
+
+// skillQuery skill_get 的查询条件
+type skillQuery struct {
+	Keyword      string
+	Mode         string // keyword、hybrid
+	Metadata     []models.SkillMetadataFilter
+	Tags         []string
+	TagIDs       []uint // Tags按名称或别名解析后的标签ID，与Tags一一对应
+	MatchAllTags bool   // 为true时要求带有全部标签，否则带有任一标签即可
+	Sort         string
+	Offset       int
+	Limit        int
+	Format       string
+}
+
+// skillMatch 查询命中的技能
+type skillMatch struct {
+	Skill       models.Skill
+	Score       float64                   // 相关度得分，列出全部技能时为0
+	TokenScore  float64                   // 分词得分，仅混合搜索
+	VectorScore float64                   // 向量得分，仅混合搜索
+	Snippet     string                    // 命中摘要，仅全文检索
+	Matches     []repositories.FuzzyMatch // 近似匹配详情，仅近似匹配
+}
+
+// skillPageItem JSON输出中的一个技能
+type skillPageItem struct {
+	Name        string                    `json:"name"`
+	Description string                    `json:"description"`
+	Tags        []string                  `json:"tags"`
+	UpdatedAt   int64                     `json:"updatedAt"`
+	Score       float64                   `json:"score,omitempty"`
+	TokenScore  *float64                  `json:"tokenScore,omitempty"`
+	VectorScore *float64                  `json:"vectorScore,omitempty"`
+	Snippet     string                    `json:"snippet,omitempty"`
+	Fuzzy       bool                      `json:"fuzzy,omitempty"`
+	Matches     []repositories.FuzzyMatch `json:"matches,omitempty"`
+}
+
+// skillPage JSON输出的一页结果
+type skillPage struct {
+	Source     string          `json:"source"` // 结果来源：list、fts、token、fuzzy、hybrid
+	Total      int             `json:"total"`  // 满足条件的技能总数
+	Offset     int             `json:"offset"`
+	Limit      int             `json:"limit"`
+	NextOffset *int            `json:"nextOffset"` // 下一页的offset，没有更多结果时为null
+	Items      []skillPageItem `json:"items"`
+}
+
+// parseSkillQuery 解析并校验skill_get的参数
+func parseSkillQuery(request mcp.CallToolRequest) (skillQuery, error) {
+	query := skillQuery{
+		Keyword: strings.TrimSpace(request.GetString("keyword", "")),
+		Mode:    request.GetString("mode", skillSearchModeKeyword),
+		Sort:    request.GetString("sort", skillSortRelevance),
+		Offset:  request.GetInt("offset", 0),
+		Limit:   request.GetInt("limit", skillPageDefaultLimit),
+		Format:  request.GetString("format", skillFormatText),
+	}
+
+	if query.Mode != skillSearchModeKeyword && query.Mode != skillSearchModeHybrid {
+		return query, fmt.Errorf("搜索方式错误: 有效值为 keyword、hybrid")
+	}
+	if !slices.Contains([]string{skillSortRelevance, skillSortName, skillSortUpdated, skillSortCreated}, query.Sort) {
+		return query, fmt.Errorf("排序方式错误: 有效值为 relevance、name、updated、created")
+	}
+	if query.Format != skillFormatText && query.Format != skillFormatJSON {
+		return query, fmt.Errorf("输出格式错误: 有效值为 text、json")
+	}
+	if query.Offset < 0 {
+		return query, fmt.Errorf("offset 不能小于0")
+	}
+	if query.Limit <= 0 || query.Limit > skillPageMaxLimit {
+		return query, fmt.Errorf("limit 必须在1到%d之间", skillPageMaxLimit)
+	}
+
+	switch tagMatch := request.GetString("tag_match", "any"); tagMatch {
+	case "any":
+	case "all":
+		query.MatchAllTags = true
+	default:
+		return query, fmt.Errorf("标签匹配方式错误: 有效值为 any、all")
+	}
+	query.Tags = splitTagNames(request.GetString("tags", ""))
+
+	filters, err := models.ParseSkillMetadataFilters(request.GetString("metadata", ""))
+	if err != nil {
+		return query, fmt.Errorf("元数据筛选条件错误: %w", err)
+	}
+	query.Metadata = filters
+	return query, nil
+}
+
+// resolveSkillQueryTags 按名称或别名解析筛选的标签，有不存在的标签时返回提示调用 tag_list 的错误
+func resolveSkillQueryTags(ctx context.Context, query *skillQuery) error {
+	tags, missing, err := repo.ResolveTagNames(ctx, query.Tags)
+	if err != nil {
+		logx.Error("获取标签失败: %v", err)
+		return fmt.Errorf("获取标签失败: %w", err)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("标签不存在: %s，请调用 tag_list 查看可用的标签", strings.Join(missing, ", "))
+	}
+	query.TagIDs = make([]uint, 0, len(tags))
+	for _, tag := range tags {
+		query.TagIDs = append(query.TagIDs, tag.ID)
+	}
+	return nil
+}
+
+// runSkillQuery 按查询条件搜索并筛选、排序技能，返回结果来源和全部命中的技能
+// 关键词精确搜索在筛选后没有结果时，退回近似匹配
+func runSkillQuery(ctx context.Context, query skillQuery) (string, []skillMatch, error) {
+	source, matches, err := searchSkillMatches(ctx, query)
+	if err != nil {
+		return source, nil, err
+	}
+	matches = slices.DeleteFunc(matches, func(match skillMatch) bool { return !query.accept(&match.Skill) })
+
+	if len(matches) == 0 && query.Keyword != "" && query.Mode == skillSearchModeKeyword {
+		hits, err := repo.SearchSkillsFuzzy(ctx, query.Keyword)
+		if err != nil {
+			return skillSourceFuzzy, nil, fmt.Errorf("近似搜索技能失败: %w", err)
+		}
+		source = skillSourceFuzzy
+		for _, hit := range hits {
+			if query.accept(&hit.Skill) {
+				matches = append(matches, skillMatch{Skill: hit.Skill, Score: hit.Score, Matches: hit.Matches})
+			}
+		}
+	}
+
+	sortSkillMatches(matches, query.Sort)
+	return source, matches, nil
+}
+
+// searchSkillMatches 按关键词和搜索方式查询未删除的技能，结果按相关度降序排列
+func searchSkillMatches(ctx context.Context, query skillQuery) (string, []skillMatch, error) {
+	var matches []skillMatch
+	switch {
+	case query.Keyword == "":
+		skills, err := repo.ListSkillsForExport(ctx, nil, nil, 0, 0)
+		if err != nil {
+			return skillSourceList, nil, fmt.Errorf("获取技能列表失败: %w", err)
+		}
+		for _, skill := range skills {
+			matches = append(matches, skillMatch{Skill: skill})
+		}
+		return skillSourceList, matches, nil
+
+	case query.Mode == skillSearchModeHybrid:
+		// 综合分词得分和向量相似度排序
+		hits, err := repo.SearchSkillsHybrid(ctx, query.Keyword, 0)
+		if err != nil {
+			return skillSourceHybrid, nil, fmt.Errorf("搜索技能失败: %w", err)
+		}
+		for _, hit := range hits {
+			matches = append(matches, skillMatch{Skill: hit.Skill, Score: hit.Score, TokenScore: hit.TokenScore, VectorScore: hit.VectorScore})
+		}
+		return skillSourceHybrid, matches, nil
+
+	case repo.FullTextSearchEnabled():
+		// 全文检索名称、描述和详细说明，按相关度排序并附带命中摘要
+		hits, err := repo.SearchSkillsFullText(ctx, query.Keyword, 0)
+		if err != nil {
+			return skillSourceFTS, nil, fmt.Errorf("搜索技能失败: %w", err)
+		}
+		for _, hit := range hits {
+			matches = append(matches, skillMatch{Skill: hit.Skill, Score: hit.Score, Snippet: hit.Snippet})
+		}
+		return skillSourceFTS, matches, nil
+
+	default:
+		// 根据关键词进行分词搜索（使用数据库索引）
+		hits, err := repo.SearchSkillTokenHits(ctx, query.Keyword)
+		if err != nil {
+			return skillSourceToken, nil, fmt.Errorf("搜索技能失败: %w", err)
+		}
+		for _, hit := range hits {
+			matches = append(matches, skillMatch{Skill: hit.Skill, Score: hit.Score})
+		}
+		return skillSourceToken, matches, nil
+	}
+}
+
+// accept 判断技能是否满足元数据和标签筛选条件
+func (q skillQuery) accept(skill *models.Skill) bool {
+	if !skill.Metadata.MatchAll(q.Metadata) {
+		return false
+	}
+	if len(q.TagIDs) == 0 {
+		return true
+	}
+	matched := 0
+	for _, tagID := range q.TagIDs {
+		if slices.ContainsFunc(skill.Tags, func(t models.Tag) bool { return t.ID == tagID }) {
+			matched++
+		}
+	}
+	if q.MatchAllTags {
+		return matched == len(q.TagIDs)
+	}
+	return matched > 0
+}
+
+// sortSkillMatches 按排序方式排序，相同时按技能ID升序，保证分页结果稳定
+func sortSkillMatches(matches []skillMatch, sort string) {
+	var compare func(a, b *skillMatch) int
+	switch sort {
+	case skillSortName:
+		compare = func(a, b *skillMatch) int {
+			return strings.Compare(strings.ToLower(a.Skill.Name), strings.ToLower(b.Skill.Name))
+		}
+	case skillSortUpdated:
+		compare = func(a, b *skillMatch) int { return cmp.Compare(b.Skill.UpdatedAt, a.Skill.UpdatedAt) }
+	case skillSortCreated:
+		compare = func(a, b *skillMatch) int { return cmp.Compare(b.Skill.CreatedAt, a.Skill.CreatedAt) }
+	default:
+		// 搜索结果已按相关度排序，列出全部技能时按ID排序
+		compare = func(a, b *skillMatch) int { return cmp.Compare(b.Score, a.Score) }
+	}
+	slices.SortStableFunc(matches, func(a, b skillMatch) int {
+		return cmp.Or(compare(&a, &b), cmp.Compare(a.Skill.ID, b.Skill.ID))
+	})
+}
+
+// formatSkillPage 按输出格式格式化一页结果
+func formatSkillPage(source string, matches []skillMatch, query skillQuery) string {
+	page := matches[min(query.Offset, len(matches)):min(query.Offset+query.Limit, len(matches))]
+	if query.Format == skillFormatJSON {
+		return formatSkillPageJSON(source, len(matches), page, query)
+	}
+	return formatSkillPageText(source, len(matches), page, query)
+}
+
+// formatSkillPageJSON 将一页结果格式化为JSON，便于按nextOffset逐页获取
+func formatSkillPageJSON(source string, total int, page []skillMatch, query skillQuery) string {
+	result := skillPage{
+		Source: source,
+		Total:  total,
+		Offset: query.Offset,
+		Limit:  query.Limit,
+		Items:  make([]skillPageItem, 0, len(page)),
+	}
+	if next := query.Offset + len(page); next < total {
+		result.NextOffset = &next
+	}
+	for _, match := range page {
+		item := skillPageItem{
+			Name:        match.Skill.Name,
+			Description: match.Skill.Description,
+			Tags:        skillTagNames(&match.Skill),
+			UpdatedAt:   match.Skill.UpdatedAt,
+			Score:       match.Score,
+			Snippet:     match.Snippet,
+			Fuzzy:       source == skillSourceFuzzy,
+			Matches:     match.Matches,
+		}
+		if source == skillSourceHybrid {
+			item.TokenScore, item.VectorScore = &match.TokenScore, &match.VectorScore
+		}
+		result.Items = append(result.Items, item)
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return "序列化技能列表失败: " + err.Error()
+	}
+	return string(data)
+}
+
+// skillPageTitles 各结果来源的文本标题
+var skillPageTitles = map[string]string{
+	skillSourceList:   "技能列表",
+	skillSourceFTS:    "关键词搜索结果",
+	skillSourceToken:  "关键词搜索结果",
+	skillSourceHybrid: "混合搜索结果",
+	skillSourceFuzzy:  "未找到完全匹配的技能，以下为近似匹配结果（关键词可能有拼写错误，请确认是否为所需技能）",
+}
+
+// formatSkillPageText 将一页结果格式化为文本，每个技能后附带得分、摘要或近似匹配详情
+func formatSkillPageText(source string, total int, page []skillMatch, query skillQuery) string {
+	if total == 0 {
+		return "未找到匹配的技能"
+	}
+	if len(page) == 0 {
+		return fmt.Sprintf("offset=%d 超出结果范围，共%d个技能", query.Offset, total)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(skillPageTitles[source])
+	if query.Offset > 0 || len(page) < total {
+		fmt.Fprintf(&sb, "（共%d个，第%d-%d个）", total, min(query.Offset+1, total), query.Offset+len(page))
+	}
+	sb.WriteString("：\n")
+	for _, match := range page {
+		if source == skillSourceFuzzy {
+			sb.WriteString("[近似] ")
+		}
+		sb.WriteString("name: " + match.Skill.Name + " description: " + match.Skill.Description + "\n")
+		if tags := skillTagNames(&match.Skill); len(tags) > 0 {
+			sb.WriteString("  标签: " + strings.Join(tags, ", ") + "\n")
+		}
+		switch source {
+		case skillSourceHybrid:
+			fmt.Fprintf(&sb, "  得分: %.2f（关键词 %.2f，语义 %.2f）\n", match.Score, match.TokenScore, match.VectorScore)
+		case skillSourceFTS, skillSourceToken:
+			fmt.Fprintf(&sb, "  得分: %.2f\n", match.Score)
+		case skillSourceFuzzy:
+			matches := make([]string, 0, len(match.Matches))
+			for _, m := range match.Matches {
+				matches = append(matches, fmt.Sprintf("%s→%s(%s)", m.Query, m.Term, fuzzyMatchKindNames[m.Kind]))
+			}
+			fmt.Fprintf(&sb, "  近似: %s，得分: %.2f\n", strings.Join(matches, ", "), match.Score)
+		}
+		if match.Snippet != "" {
+			sb.WriteString("  匹配: " + strings.ReplaceAll(match.Snippet, "\n", " ") + "\n")
+		}
+	}
+	if next := query.Offset + len(page); next < total {
+		fmt.Fprintf(&sb, "... 还有 %d 个技能未显示，传 offset=%d 查看后续\n", total-next, next)
+	}
+	sb.WriteString("请调用 skill_detail 查技能详情")
+	return sb.String()
+}
+
+// fuzzyMatchKindNames 近似匹配方式的显示名称
+var fuzzyMatchKindNames = map[string]string{
+	repositories.FuzzyMatchTypo:   "拼写",
+	repositories.FuzzyMatchPrefix: "前缀",
+	repositories.FuzzyMatchPinyin: "拼音",
+}
+
+// skillTagNames 技能的标签名称，按名称排序
+func skillTagNames(skill *models.Skill) []string {
+	names := make([]string, 0, len(skill.Tags))
+	for _, tag := range skill.Tags {
+		names = append(names, tag.Name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -1,12 +1,26 @@
 package mcp
 
 import (
+	"aiflow/internal/config"
+	"aiflow/internal/embedding"
+	"aiflow/internal/errors"
 	"aiflow/internal/models"
 	"aiflow/internal/repositories"
+	"aiflow/internal/services"
+	"aiflow/internal/storage"
 	"context"
+	"encoding/json"
+	stderrors "errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 )
@@ -22,21 +36,16 @@ func setRepoForTest(r *repositories.Repository) {
 //
 // 返回: 仓库实例和清理函数
 func setupTestRepo(t *testing.T) (*repositories.Repository, func()) {
-	// 创建唯一的临时数据库文件，避免测试间冲突
-	dbPath := fmt.Sprintf("test_skill_%s.db", t.Name())
-
-	// 清理已存在的测试数据库
-	_ = os.Remove(dbPath)
+	// 在测试临时目录中创建数据库，WAL模式产生的-wal、-shm文件随目录一起删除，避免测试间冲突
+	dbPath := filepath.Join(t.TempDir(), "test_skill.db")
 
 	repo, err := repositories.NewRepository(dbPath)
 	if err != nil {
 		t.Fatalf("创建测试仓库失败: %v", err)
 	}
 
-	// 返回清理函数
-	cleanup := func() {
-		_ = os.Remove(dbPath)
-	}
+	// 返回清理函数，临时目录由测试框架删除
+	cleanup := func() {}
 
 	return repo, cleanup
 }
@@ -192,6 +201,79 @@ func TestSearchSkillsByTokens_Chinese(t *testing.T) {
 	})
 }
 
+// TestSearchSkillsByTokens_Analyzer 测试用户词典、停用词和同义词配置
+func TestSearchSkillsByTokens_Analyzer(t *testing.T) {
+	dictPath := filepath.Join(t.TempDir(), "user.dict")
+	if err := os.WriteFile(dictPath, []byte("# 领域词\n智流平台\n"), 0644); err != nil {
+		t.Fatalf("写入用户词典失败: %v", err)
+	}
+	err := repositories.ConfigureTextAnalyzer(config.SearchConfig{
+		UserDicts: []string{dictPath},
+		Stopwords: []string{"数据"},
+		Synonyms:  map[string][]string{"kubernetes": {"k8s", "kube"}},
+	})
+	if err != nil {
+		t.Fatalf("加载分词配置失败: %v", err)
+	}
+	defer repositories.ConfigureTextAnalyzer(config.SearchConfig{})
+
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	deploy := createTestSkill(t, repo, "k8s-deploy", "部署应用到Kubernetes集群")
+	_ = createTestSkill(t, repo, "etl", "清洗数据并导入智流平台")
+
+	countTerm := func(term string) int64 {
+		var count int64
+		repo.GetDB().Model(&models.SkillToken{}).Where("term = ?", term).Count(&count)
+		return count
+	}
+	if countTerm("数据") != 0 {
+		t.Error("停用词不应写入分词索引")
+	}
+	if countTerm("智流平台") != 1 {
+		t.Error("用户词典中的词应作为整体写入分词索引")
+	}
+
+	// 同义词归一为标准词，搜索任一同义词都能命中
+	for _, keyword := range []string{"k8s", "KUBE", "kubernetes"} {
+		results, err := repo.SearchSkillsByTokens(ctx, keyword)
+		if err != nil {
+			t.Fatalf("搜索失败: %v", err)
+		}
+		if len(results) != 1 || results[0].ID != deploy.ID {
+			t.Errorf("搜索%q期望只返回技能%d，实际返回%d个", keyword, deploy.ID, len(results))
+		}
+	}
+
+	// 全文检索索引保存原文，查询时按同义词组扩展
+	if repo.FullTextSearchEnabled() {
+		hits, err := repo.SearchSkillsFullText(ctx, "k8s", 0)
+		if err != nil {
+			t.Fatalf("全文检索失败: %v", err)
+		}
+		if len(hits) != 1 || hits[0].Skill.ID != deploy.ID {
+			t.Errorf("全文检索k8s期望只返回技能%d，实际返回%d个", deploy.ID, len(hits))
+		}
+	}
+
+	// 分词配置变化后索引需要重建
+	if !repo.SearchIndexOutdated(ctx) {
+		t.Error("未记录分词配置时索引应视为需要重建")
+	}
+	if err := repo.MarkSearchIndexCurrent(ctx); err != nil {
+		t.Fatalf("记录分词配置失败: %v", err)
+	}
+	if repo.SearchIndexOutdated(ctx) {
+		t.Error("记录分词配置后索引不应需要重建")
+	}
+	repositories.ConfigureTextAnalyzer(config.SearchConfig{Stopwords: []string{"数据"}})
+	if !repo.SearchIndexOutdated(ctx) {
+		t.Error("分词配置变化后索引应需要重建")
+	}
+}
+
 // TestSearchSkillsByTokens_MatchScore 测试分词匹配度排序
 func TestSearchSkillsByTokens_MatchScore(t *testing.T) {
 	repo, cleanup := setupTestRepo(t)
@@ -321,6 +403,873 @@ func TestSkillMenuTool_WithKeyword(t *testing.T) {
 	})
 }
 
+// TestSkillMenuTool_MetadataFilter 测试按元数据筛选技能
+func TestSkillMenuTool_MetadataFilter(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	originalRepo := repo
+	setRepoForTest(repo)
+	defer func() {
+		setRepoForTest(originalRepo)
+	}()
+
+	ctx := context.Background()
+
+	pdf := createTestSkill(t, repo, "pdf-extractor", "Extract text from PDF documents")
+	pdf.Metadata = models.SkillMetadata{"author": "Alice", "team": "docs"}
+	if err := repo.UpdateSkill(ctx, pdf); err != nil {
+		t.Fatalf("更新技能失败: %v", err)
+	}
+	image := createTestSkill(t, repo, "image-processor", "Process and optimize PDF images")
+	image.Metadata = models.SkillMetadata{"author": "bob"}
+	if err := repo.UpdateSkill(ctx, image); err != nil {
+		t.Fatalf("更新技能失败: %v", err)
+	}
+
+	call := func(args map[string]interface{}) string {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := skillMenuTool(ctx, request)
+		if err != nil {
+			t.Fatalf("工具调用失败: %v", err)
+		}
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	tests := []struct {
+		name    string
+		args    map[string]interface{}
+		want    []string
+		notWant []string
+	}{
+		{"按键值筛选不区分大小写", map[string]interface{}{"metadata": "author:alice"}, []string{"pdf-extractor"}, []string{"image-processor"}},
+		{"只要求键存在", map[string]interface{}{"metadata": "team"}, []string{"pdf-extractor"}, []string{"image-processor"}},
+		{"多个条件同时满足", map[string]interface{}{"metadata": "author:bob,team"}, []string{"未找到匹配的技能"}, nil},
+		{"与关键词组合", map[string]interface{}{"keyword": "pdf", "metadata": "author:bob"}, []string{"image-processor"}, []string{"pdf-extractor"}},
+		{"条件缺少键名", map[string]interface{}{"metadata": ":alice"}, []string{"元数据筛选条件错误"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text := call(tt.args)
+			for _, want := range tt.want {
+				if !strings.Contains(text, want) {
+					t.Errorf("期望结果包含 %q，实际: %s", want, text)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(text, notWant) {
+					t.Errorf("期望结果不包含 %q，实际: %s", notWant, text)
+				}
+			}
+		})
+	}
+}
+
+// TestSkillMenuTool_FullText 测试全文检索（需以 -tags sqlite_fts5 构建）
+func TestSkillMenuTool_FullText(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+	if !repo.FullTextSearchEnabled() {
+		t.Skip("SQLite未编译FTS5模块，跳过全文检索测试")
+	}
+
+	originalRepo := repo
+	setRepoForTest(repo)
+	defer func() {
+		setRepoForTest(originalRepo)
+	}()
+
+	ctx := context.Background()
+
+	// 关键词只出现在详细说明中
+	report := createTestSkill(t, repo, "weekly-report", "生成周报")
+	report.Detail = "# 使用说明\n从项目管理系统导出本周完成的任务，按负责人汇总成表格"
+	if err := repo.UpdateSkill(ctx, report); err != nil {
+		t.Fatalf("更新技能失败: %v", err)
+	}
+	// 名称命中的技能排在详细说明命中的技能前面
+	createTestSkill(t, repo, "table-format", "表格格式化工具")
+	deleted := createTestSkill(t, repo, "old-table", "旧的表格工具")
+	if err := repo.DeleteSkill(ctx, deleted.ID); err != nil {
+		t.Fatalf("删除技能失败: %v", err)
+	}
+
+	hits, err := repo.SearchSkillsFullText(ctx, "表格", 0)
+	if err != nil {
+		t.Fatalf("全文检索失败: %v", err)
+	}
+	if len(hits) != 2 {
+		t.Fatalf("期望命中2个未删除的技能，实际%d个", len(hits))
+	}
+	if hits[0].Skill.Name != "table-format" || hits[1].Skill.Name != "weekly-report" {
+		t.Errorf("排序不符合字段权重: %s, %s", hits[0].Skill.Name, hits[1].Skill.Name)
+	}
+	if !strings.Contains(hits[1].Snippet, "**表格**") {
+		t.Errorf("摘要应高亮命中的词，实际: %s", hits[1].Snippet)
+	}
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"keyword": "负责人"}
+	result, err := skillMenuTool(ctx, request)
+	if err != nil {
+		t.Fatalf("工具调用失败: %v", err)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	if !strings.Contains(text, "weekly-report") || !strings.Contains(text, "匹配: ") {
+		t.Errorf("期望返回详细说明命中的技能和摘要，实际: %s", text)
+	}
+
+	// 彻底删除后索引同步删除
+	if err := repo.PermanentDeleteSkill(ctx, report.ID); err != nil {
+		t.Fatalf("彻底删除技能失败: %v", err)
+	}
+	hits, _ = repo.SearchSkillsFullText(ctx, "负责人", 0)
+	if len(hits) != 0 {
+		t.Errorf("彻底删除后不应再命中，实际%d个", len(hits))
+	}
+}
+
+// TestSkillMenuTool_Fuzzy 测试精确搜索无结果时的近似匹配
+func TestSkillMenuTool_Fuzzy(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	originalRepo := repo
+	setRepoForTest(repo)
+	defer func() {
+		setRepoForTest(originalRepo)
+	}()
+
+	ctx := context.Background()
+	createTestSkill(t, repo, "excel-parser", "解析Excel表格")
+	createTestSkill(t, repo, "pdf-processing", "提取PDF文件中的文本")
+	deleted := createTestSkill(t, repo, "excel-writer", "生成Excel表格")
+	if err := repo.DeleteSkill(ctx, deleted.ID); err != nil {
+		t.Fatalf("删除技能失败: %v", err)
+	}
+
+	tests := []struct {
+		keyword string
+		skill   string
+		match   string
+	}{
+		{"excle", "excel-parser", "excle→excel(拼写)"},
+		{"procesing", "pdf-processing", "procesing→processing(拼写)"},
+		{"proc", "pdf-processing", "proc→processing(前缀)"},
+		{"wenjian", "pdf-processing", "wenjian→文件(拼音)"},
+	}
+	for _, tt := range tests {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"keyword": tt.keyword}
+		result, err := skillMenuTool(ctx, request)
+		if err != nil {
+			t.Fatalf("工具调用失败: %v", err)
+		}
+		text := result.Content[0].(mcp.TextContent).Text
+		if !strings.Contains(text, "[近似] name: "+tt.skill) || !strings.Contains(text, tt.match) {
+			t.Errorf("搜索%q期望近似匹配%s（%s），实际:\n%s", tt.keyword, tt.skill, tt.match, text)
+		}
+		if strings.Contains(text, "excel-writer") {
+			t.Errorf("回收站中的技能不应出现在近似匹配结果中:\n%s", text)
+		}
+	}
+
+	// 精确匹配时不做近似匹配
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"keyword": "excel"}
+	result, _ := skillMenuTool(ctx, request)
+	if text := result.Content[0].(mcp.TextContent).Text; strings.Contains(text, "[近似]") {
+		t.Errorf("精确匹配时不应标注近似:\n%s", text)
+	}
+
+	// 词表缓存在技能新建、删除后失效
+	callFuzzy := func(keyword string) string {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"keyword": keyword}
+		result, err := skillMenuTool(ctx, request)
+		if err != nil {
+			t.Fatalf("工具调用失败: %v", err)
+		}
+		return result.Content[0].(mcp.TextContent).Text
+	}
+	if text := callFuzzy("tupian"); strings.Contains(text, "image-resize") {
+		t.Errorf("新建技能前不应命中:\n%s", text)
+	}
+	image := createTestSkill(t, repo, "image-resize", "调整图片尺寸")
+	if text := callFuzzy("tupian"); !strings.Contains(text, "[近似] name: image-resize") {
+		t.Errorf("新建技能后应按新分词近似匹配:\n%s", text)
+	}
+	if err := repo.DeleteSkill(ctx, image.ID); err != nil {
+		t.Fatalf("删除技能失败: %v", err)
+	}
+	if text := callFuzzy("tupian"); strings.Contains(text, "image-resize") {
+		t.Errorf("删除技能后不应再命中:\n%s", text)
+	}
+}
+
+// TestSkillMenuTool_Hybrid 测试本地向量化的混合搜索
+func TestSkillMenuTool_Hybrid(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	originalRepo := repo
+	setRepoForTest(repo)
+	defer func() {
+		setRepoForTest(originalRepo)
+	}()
+
+	ctx := context.Background()
+	sheet := createTestSkill(t, repo, "sheet-analysis", "Analyze spreadsheet data")
+	createTestSkill(t, repo, "image-resize", "Resize images")
+	// 向量在后台计算
+	if err := repo.WaitSkillEmbeddings(ctx); err != nil {
+		t.Fatalf("等待向量计算失败: %v", err)
+	}
+
+	callHybrid := func(keyword string) string {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"keyword": keyword, "mode": "hybrid"}
+		result, err := skillMenuTool(ctx, request)
+		if err != nil {
+			t.Fatalf("工具调用失败: %v", err)
+		}
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	// 分词不同（spreadsheets/spreadsheet）但字面相近，靠向量命中
+	text := callHybrid("spreadsheets")
+	if !strings.Contains(text, "混合搜索结果") || !strings.Contains(text, "name: sheet-analysis") || strings.Contains(text, "image-resize") {
+		t.Errorf("混合搜索结果不符合预期:\n%s", text)
+	}
+	if !strings.Contains(text, "关键词 0.00") {
+		t.Errorf("只有向量命中时关键词得分应为0:\n%s", text)
+	}
+
+	// 更新技能后重新计算向量
+	sheet.Description = "Merge PDF documents"
+	if err := repo.UpdateSkill(ctx, sheet); err != nil {
+		t.Fatalf("更新技能失败: %v", err)
+	}
+	if err := repo.WaitSkillEmbeddings(ctx); err != nil {
+		t.Fatalf("等待向量计算失败: %v", err)
+	}
+	if text := callHybrid("spreadsheets"); strings.Contains(text, "sheet-analysis") {
+		t.Errorf("更新后不应再按旧描述命中:\n%s", text)
+	}
+
+	// 彻底删除技能时删除向量
+	if err := repo.PermanentDeleteSkill(ctx, sheet.ID); err != nil {
+		t.Fatalf("删除技能失败: %v", err)
+	}
+	var count int64
+	repo.GetDB().Model(&models.SkillEmbedding{}).Where("skill_id = ?", sheet.ID).Count(&count)
+	if count != 0 {
+		t.Errorf("彻底删除技能后应删除向量，实际还有%d条", count)
+	}
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"keyword": "pdf", "mode": "vector"}
+	result, _ := skillMenuTool(ctx, request)
+	if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "搜索方式错误") {
+		t.Errorf("无效的搜索方式应报错:\n%s", text)
+	}
+}
+
+// TestSkillMenuTool_HybridOpenAI 测试OpenAI兼容接口的向量化
+func TestSkillMenuTool_HybridOpenAI(t *testing.T) {
+	// 桩服务按是否涉及表格返回两个方向的向量，模拟语义向量
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" || r.Header.Get("Authorization") != "Bearer test-key" {
+			http.Error(w, `{"error":{"message":"unauthorized"}}`, http.StatusUnauthorized)
+			return
+		}
+		var body struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		var data []map[string]any
+		for i, text := range body.Input {
+			vector := []float32{0, 1}
+			if text = strings.ToLower(text); strings.Contains(text, "excel") || strings.Contains(text, "spreadsheet") {
+				vector = []float32{1, 0.1}
+			}
+			data = append(data, map[string]any{"index": i, "embedding": vector})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": data})
+	}))
+	defer server.Close()
+
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+	repo.SetEmbedder(embedding.NewOpenAIEmbedder(server.URL+"/v1", "test-key", "stub-model", 0))
+
+	originalRepo := repo
+	setRepoForTest(repo)
+	defer func() {
+		setRepoForTest(originalRepo)
+	}()
+
+	ctx := context.Background()
+	createTestSkill(t, repo, "excel-analysis", "Excel data analysis")
+	createTestSkill(t, repo, "image-resize", "Resize images")
+	if err := repo.WaitSkillEmbeddings(ctx); err != nil {
+		t.Fatalf("等待向量计算失败: %v", err)
+	}
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"keyword": "summarize a spreadsheet", "mode": "hybrid"}
+	result, err := skillMenuTool(ctx, request)
+	if err != nil {
+		t.Fatalf("工具调用失败: %v", err)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	if !strings.Contains(text, "name: excel-analysis") || strings.Contains(text, "image-resize") {
+		t.Errorf("语义搜索结果不符合预期:\n%s", text)
+	}
+
+	// 认证失败时技能照常保存，搜索退回只按分词，并标记下次启动时重建索引
+	repo.SetEmbedder(embedding.NewOpenAIEmbedder(server.URL+"/v1", "wrong-key", "stub-model", 0))
+	if err := repo.MarkSearchIndexCurrent(ctx); err != nil {
+		t.Fatalf("记录索引配置失败: %v", err)
+	}
+	createTestSkill(t, repo, "resize-batch", "Batch resize images")
+	if err := repo.WaitSkillEmbeddings(ctx); err != nil {
+		t.Fatalf("等待向量计算失败: %v", err)
+	}
+	if !repo.SearchIndexOutdated(ctx) {
+		t.Error("向量计算失败后应标记搜索索引需要重建")
+	}
+	hits, err := repo.SearchSkillsHybrid(ctx, "resize", 0)
+	if err != nil {
+		t.Fatalf("混合搜索失败: %v", err)
+	}
+	if len(hits) != 2 {
+		t.Errorf("向量化不可用时应按分词返回2个技能，实际%d个", len(hits))
+	}
+}
+
+// TestSkillMenuTool_Paging 测试技能查询的标签筛选、排序、分页和JSON输出
+func TestSkillMenuTool_Paging(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	originalRepo := repo
+	setRepoForTest(repo)
+	defer func() {
+		setRepoForTest(originalRepo)
+	}()
+
+	ctx := context.Background()
+	office := &models.Tag{Name: "office"}
+	pdf := &models.Tag{Name: "pdf"}
+	for _, tag := range []*models.Tag{office, pdf} {
+		if err := repo.CreateTag(ctx, tag); err != nil {
+			t.Fatalf("创建标签失败: %v", err)
+		}
+	}
+	// 25个技能，偶数带office标签，3的倍数带pdf标签
+	for i := range 25 {
+		skill := createTestSkill(t, repo, fmt.Sprintf("report-%02d", 24-i), "生成报告")
+		if i%2 == 0 {
+			repo.AddTagToSkill(ctx, skill.ID, office.ID)
+		}
+		if i%3 == 0 {
+			repo.AddTagToSkill(ctx, skill.ID, pdf.ID)
+		}
+	}
+
+	call := func(args map[string]interface{}) string {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := skillMenuTool(ctx, request)
+		if err != nil {
+			t.Fatalf("工具调用失败: %v", err)
+		}
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	// 文本输出默认20条，并提示下一页的offset
+	text := call(map[string]interface{}{"keyword": "报告"})
+	if strings.Count(text, "name: ") != 20 || !strings.Contains(text, "共25个，第1-20个") || !strings.Contains(text, "传 offset=20 查看后续") {
+		t.Errorf("默认分页不符合预期:\n%s", text)
+	}
+	if !strings.Contains(text, "得分: ") {
+		t.Errorf("关键词搜索应输出得分:\n%s", text)
+	}
+
+	// JSON输出按nextOffset逐页获取，不重复不遗漏
+	seen := make(map[string]bool)
+	var names []string
+	for offset := 0; ; {
+		var page skillPage
+		raw := call(map[string]interface{}{"keyword": "报告", "sort": "name", "format": "json", "offset": offset, "limit": 7})
+		if err := json.Unmarshal([]byte(raw), &page); err != nil {
+			t.Fatalf("解析JSON失败: %v, %s", err, raw)
+		}
+		if page.Total != 25 {
+			t.Fatalf("期望共25个，实际%d个", page.Total)
+		}
+		for _, item := range page.Items {
+			if seen[item.Name] {
+				t.Errorf("技能%s重复出现", item.Name)
+			}
+			seen[item.Name] = true
+			names = append(names, item.Name)
+		}
+		if page.NextOffset == nil {
+			break
+		}
+		offset = *page.NextOffset
+	}
+	if len(names) != 25 || !slices.IsSorted(names) {
+		t.Errorf("分页结果应按名称排序且共25个，实际: %v", names)
+	}
+
+	// 标签筛选与关键词组合
+	var page skillPage
+	json.Unmarshal([]byte(call(map[string]interface{}{"keyword": "报告", "tags": "office,pdf", "format": "json", "limit": 100})), &page)
+	if page.Total != 17 {
+		t.Errorf("带有任一标签的技能应有17个，实际%d个", page.Total)
+	}
+	json.Unmarshal([]byte(call(map[string]interface{}{"tags": "office,pdf", "tag_match": "all", "format": "json", "limit": 100})), &page)
+	if page.Total != 5 {
+		t.Errorf("同时带有两个标签的技能应有5个，实际%d个", page.Total)
+	}
+	for _, item := range page.Items {
+		if !slices.Contains(item.Tags, "office") || !slices.Contains(item.Tags, "pdf") {
+			t.Errorf("技能%s的标签不满足条件: %v", item.Name, item.Tags)
+		}
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"limit": 0}, {"offset": -1}, {"sort": "random"}, {"format": "xml"}, {"tag_match": "none"},
+	} {
+		if text := call(args); strings.Contains(text, "name: ") {
+			t.Errorf("参数%v无效时应报错，实际:\n%s", args, text)
+		}
+	}
+	if text := call(map[string]interface{}{"offset": 100}); !strings.Contains(text, "超出结果范围") {
+		t.Errorf("offset超出范围时应提示，实际:\n%s", text)
+	}
+
+	// 标签按别名解析：docs合并到office后按docs筛选等同于按office筛选
+	docs := &models.Tag{Name: "docs"}
+	if err := repo.CreateTag(ctx, docs); err != nil {
+		t.Fatalf("创建标签失败: %v", err)
+	}
+	if err := repo.MergeTags(ctx, office.ID, []uint{docs.ID}); err != nil {
+		t.Fatalf("合并标签失败: %v", err)
+	}
+	page = skillPage{}
+	json.Unmarshal([]byte(call(map[string]interface{}{"tags": "docs,pdf", "tag_match": "all", "format": "json", "limit": 100})), &page)
+	if page.Total != 5 {
+		t.Errorf("按别名筛选时同时带有两个标签的技能应有5个，实际%d个", page.Total)
+	}
+	if text := call(map[string]interface{}{"tags": "pdf,nope"}); !strings.Contains(text, "标签不存在: nope") || !strings.Contains(text, "tag_list") {
+		t.Errorf("不存在的标签应提示调用tag_list，实际:\n%s", text)
+	}
+}
+
+// TestSkillByTagTool_IncludeDescendants 测试层级标签：包含子标签查询、移动标签和循环检查
+func TestSkillByTagTool_IncludeDescendants(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	originalRepo := repo
+	setRepoForTest(repo)
+	defer func() {
+		setRepoForTest(originalRepo)
+	}()
+
+	ctx := context.Background()
+	tagService := services.NewTagService(repo)
+	create := func(name string, parentID uint) uint {
+		tag, err := tagService.CreateTag(ctx, services.CreateTagRequest{Name: name, ParentID: parentID})
+		if err != nil {
+			t.Fatalf("创建标签%s失败: %v", name, err)
+		}
+		return tag.ID
+	}
+	// frontend → react → testing，backend为另一棵树
+	frontend := create("frontend", 0)
+	react := create("react", frontend)
+	jestTag := create("testing", react)
+	backend := create("backend", 0)
+
+	for name, tagID := range map[string]uint{"web-layout": frontend, "react-hooks": react, "jest-runner": jestTag, "api-server": backend} {
+		skill := createTestSkill(t, repo, name, name+" 技能")
+		repo.AddTagToSkill(ctx, skill.ID, tagID)
+	}
+
+	call := func(args map[string]interface{}) string {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := skillByTagMenuTool(ctx, request)
+		if err != nil {
+			t.Fatalf("工具调用失败: %v", err)
+		}
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	if text := call(map[string]interface{}{"tag": "frontend"}); strings.Count(text, "name: ") != 1 {
+		t.Errorf("不含子标签时只应返回1个技能:\n%s", text)
+	}
+	text := call(map[string]interface{}{"tag": "frontend", "include_descendants": true})
+	if strings.Count(text, "name: ") != 3 || !strings.Contains(text, "jest-runner") || strings.Contains(text, "api-server") {
+		t.Errorf("包含子标签时应返回frontend子树的3个技能:\n%s", text)
+	}
+
+	// 不能移动到自身或子标签下
+	for _, parentID := range []uint{frontend, jestTag} {
+		_, err := tagService.MoveTag(ctx, services.MoveTagRequest{ID: frontend, ParentID: parentID})
+		var appErr *errors.AppError
+		if !stderrors.As(err, &appErr) || appErr.Code != errors.ErrCodeTagCycle {
+			t.Errorf("移动到标签%d下应报循环错误，实际: %v", parentID, err)
+		}
+	}
+
+	// 把react子树移到backend下，技能关联保持不变
+	if _, err := tagService.MoveTag(ctx, services.MoveTagRequest{ID: react, ParentID: backend}); err != nil {
+		t.Fatalf("移动标签失败: %v", err)
+	}
+	text = call(map[string]interface{}{"tag": "backend", "include_descendants": true})
+	if strings.Count(text, "name: ") != 3 || !strings.Contains(text, "react-hooks") || !strings.Contains(text, "jest-runner") {
+		t.Errorf("移动后backend子树应有3个技能:\n%s", text)
+	}
+
+	listed, err := services.NewSkillService(repo, nil).ListSkills(ctx, services.ListSkillsRequest{
+		TagID: backend, IncludeDescendants: true, Page: 1, PageSize: 10,
+	})
+	if err != nil || len(listed.Items) != 3 || listed.Pagination["total"] != int64(3) {
+		t.Errorf("ListSkills包含子标签时应返回3个技能: %+v, %v", listed, err)
+	}
+
+	tree, err := tagService.GetTagTree(ctx, 0)
+	if err != nil {
+		t.Fatalf("获取标签树失败: %v", err)
+	}
+	if len(tree) != 2 || tree[0].Name != "backend" || tree[0].TotalSkillCount != 3 || tree[1].TotalSkillCount != 1 {
+		t.Fatalf("标签树不符合预期: %+v", tree)
+	}
+	if leaf := tree[0].Children[0].Children[0]; leaf.Path != "backend/react/testing" || leaf.SkillCount != 1 {
+		t.Errorf("叶子标签不符合预期: %+v", leaf)
+	}
+
+	// 删除中间标签时子标签上移一层
+	if err := tagService.DeleteTag(ctx, react); err != nil {
+		t.Fatalf("删除标签失败: %v", err)
+	}
+	if tag, _ := repo.GetTagByID(ctx, jestTag); tag == nil || tag.ParentID != backend {
+		t.Errorf("删除react后testing应挂到backend下: %+v", tag)
+	}
+
+	// 并发地互相移到对方下面时最多一个成功，不会形成循环
+	left, right := create("left", 0), create("right", 0)
+	for range 10 {
+		for _, id := range []uint{left, right} {
+			if err := repo.MoveTag(ctx, id, 0); err != nil {
+				t.Fatalf("移动标签到顶层失败: %v", err)
+			}
+		}
+		var wg sync.WaitGroup
+		errs := make([]error, 2)
+		for i, move := range [][2]uint{{left, right}, {right, left}} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, errs[i] = tagService.MoveTag(ctx, services.MoveTagRequest{ID: move[0], ParentID: move[1]})
+			}()
+		}
+		wg.Wait()
+		if errs[0] == nil && errs[1] == nil {
+			t.Fatal("并发互相移动不应同时成功")
+		}
+		for _, err := range errs {
+			var appErr *errors.AppError
+			if err != nil && (!stderrors.As(err, &appErr) || appErr.Code != errors.ErrCodeTagCycle) {
+				t.Fatalf("并发移动失败时应报循环错误，实际: %v", err)
+			}
+		}
+	}
+}
+
+// TestTagMerge 测试合并标签：技能关联迁移、别名解析和SKILL.md导入
+func TestTagMerge(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	originalRepo := repo
+	setRepoForTest(repo)
+	defer func() {
+		setRepoForTest(originalRepo)
+	}()
+
+	ctx := context.Background()
+	tagService := services.NewTagService(repo)
+	create := func(name string, parentID uint) uint {
+		tag, err := tagService.CreateTag(ctx, services.CreateTagRequest{Name: name, ParentID: parentID})
+		if err != nil {
+			t.Fatalf("创建标签%s失败: %v", name, err)
+		}
+		return tag.ID
+	}
+	upper := create("PDF", 0)
+	lower := create("pdf", 0)
+	nested := create("文档处理/PDF", 0)
+	forms := create("forms", lower)
+	// 目标标签是被合并标签的子标签时，合并后不能挂到自己下面
+	docs := create("docs", 0)
+	target := create("manuals", docs)
+
+	// pdf-merge 同时带PDF和pdf两个标签，合并后只保留一条关联
+	for name, tagIDs := range map[string][]uint{
+		"pdf-merge": {upper, lower}, "pdf-forms": {lower}, "pdf-ocr": {nested}, "pdf-sign": {forms},
+	} {
+		skill := createTestSkill(t, repo, name, name+" 技能")
+		for _, tagID := range tagIDs {
+			repo.AddTagToSkill(ctx, skill.ID, tagID)
+		}
+	}
+
+	if _, err := tagService.MergeTags(ctx, services.MergeTagsRequest{TargetID: upper, SourceIDs: []uint{upper}}); err == nil {
+		t.Error("合并到自身应报错")
+	}
+	merged, err := tagService.MergeTags(ctx, services.MergeTagsRequest{TargetID: upper, SourceIDs: []uint{lower, nested}})
+	if err != nil {
+		t.Fatalf("合并标签失败: %v", err)
+	}
+	if !slices.Equal(merged.Aliases, []string{"pdf", "文档处理/PDF"}) || len(merged.Skills) != 3 {
+		t.Errorf("合并后的标签不符合预期: aliases=%v, skills=%d", merged.Aliases, len(merged.Skills))
+	}
+	if tag, _ := repo.GetTagByID(ctx, forms); tag == nil || tag.ParentID != upper {
+		t.Errorf("被合并标签的子标签应移到目标标签下: %+v", tag)
+	}
+
+	// 按别名查询得到合并后的标签
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"tag": "文档处理/PDF"}
+	result, _ := skillByTagMenuTool(ctx, request)
+	text := result.Content[0].(mcp.TextContent).Text
+	if strings.Count(text, "name: ") != 3 || !strings.Contains(text, "已合并到「PDF」") {
+		t.Errorf("按别名查询应返回PDF标签的技能:\n%s", text)
+	}
+
+	// 导入时别名解析到合并后的标签，不会重新创建
+	content := "---\nname: pdf-split\ndescription: Split PDF files. Use when splitting PDF documents.\ntags:\n  - pdf\n  - PDF\n---\n拆分PDF"
+	skill, _, err := services.NewSkillService(repo, nil).ImportSkillMarkdown(ctx, []byte(content))
+	if err != nil {
+		t.Fatalf("导入技能失败: %v", err)
+	}
+	tags, _ := repo.GetTagsBySkillID(ctx, skill.ID)
+	if len(tags) != 1 || tags[0].ID != upper {
+		t.Errorf("导入的技能应只关联PDF标签: %+v", tags)
+	}
+
+	// 合并祖先标签：manuals的父标签docs被合并到manuals，manuals上移到顶层
+	if _, err := tagService.MergeTags(ctx, services.MergeTagsRequest{TargetID: target, SourceIDs: []uint{docs}}); err != nil {
+		t.Fatalf("合并祖先标签失败: %v", err)
+	}
+	if tag, _ := repo.GetTagByID(ctx, target); tag == nil || tag.ParentID != 0 {
+		t.Errorf("目标标签应上移到顶层: %+v", tag)
+	}
+}
+
+// TestSkillByTagTool_MultiTag 测试多标签查询：任一、全部匹配和排除标签，以及 tag_list 的技能数
+func TestSkillByTagTool_MultiTag(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	originalRepo := repo
+	setRepoForTest(repo)
+	defer func() {
+		setRepoForTest(originalRepo)
+	}()
+
+	ctx := context.Background()
+	tagService := services.NewTagService(repo)
+	create := func(name string, parentID uint) uint {
+		tag, err := tagService.CreateTag(ctx, services.CreateTagRequest{Name: name, ParentID: parentID})
+		if err != nil {
+			t.Fatalf("创建标签%s失败: %v", name, err)
+		}
+		return tag.ID
+	}
+	pdf := create("pdf", 0)
+	office := create("office", 0)
+	legacy := create("legacy", 0)
+	scanned := create("scanned", pdf)
+
+	skillIDs := map[string]uint{}
+	for name, tagIDs := range map[string][]uint{
+		"pdf-merge": {pdf}, "pdf-export": {pdf, office}, "docx-edit": {office},
+		"doc-convert": {office, legacy}, "pdf-ocr": {scanned}, "pdf-removed": {pdf, office},
+	} {
+		skill := createTestSkill(t, repo, name, name+" 技能")
+		for _, tagID := range tagIDs {
+			repo.AddTagToSkill(ctx, skill.ID, tagID)
+		}
+		skillIDs[name] = skill.ID
+	}
+	// 回收站中的技能不出现在结果和技能数中
+	if err := repo.DeleteSkill(ctx, skillIDs["pdf-removed"]); err != nil {
+		t.Fatalf("删除技能失败: %v", err)
+	}
+
+	call := func(handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]interface{}) string {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := handler(ctx, request)
+		if err != nil {
+			t.Fatalf("工具调用失败: %v", err)
+		}
+		return result.Content[0].(mcp.TextContent).Text
+	}
+	names := func(text string) []string {
+		var found []string
+		for line := range strings.SplitSeq(text, "\n") {
+			if name, ok := strings.CutPrefix(line, "name: "); ok {
+				found = append(found, strings.Fields(name)[0])
+			}
+		}
+		slices.Sort(found)
+		return found
+	}
+
+	for _, tc := range []struct {
+		args map[string]interface{}
+		want []string
+	}{
+		{map[string]interface{}{"tag": "pdf, office"}, []string{"doc-convert", "docx-edit", "pdf-export", "pdf-merge"}},
+		{map[string]interface{}{"tag": "pdf,office", "match": "all"}, []string{"pdf-export"}},
+		{map[string]interface{}{"tag": "office", "exclude": "legacy,pdf"}, []string{"docx-edit"}},
+		{map[string]interface{}{"tag": "pdf", "include_descendants": true}, []string{"pdf-export", "pdf-merge", "pdf-ocr"}},
+		{map[string]interface{}{"tag": "pdf,office", "exclude": "pdf", "include_descendants": true}, []string{"doc-convert", "docx-edit"}},
+	} {
+		if got := names(call(skillByTagMenuTool, tc.args)); !slices.Equal(got, tc.want) {
+			t.Errorf("参数%v应返回%v，实际%v", tc.args, tc.want, got)
+		}
+	}
+	if text := call(skillByTagMenuTool, map[string]interface{}{"tag": "pdf,word"}); !strings.Contains(text, "word") || !strings.Contains(text, "tag_list") {
+		t.Errorf("标签不存在时应提示调用tag_list:\n%s", text)
+	}
+	if text := call(skillByTagMenuTool, map[string]interface{}{"tag": "pdf", "match": "none"}); strings.Contains(text, "name: ") {
+		t.Errorf("匹配方式无效时应报错:\n%s", text)
+	}
+
+	text := call(tagListTool, map[string]interface{}{})
+	for _, line := range []string{"name: pdf skills: 2 total: 3", "name: scanned path: pdf/scanned skills: 1", "name: office skills: 3"} {
+		if !strings.Contains(text, line+"\n") {
+			t.Errorf("标签列表应包含%q:\n%s", line, text)
+		}
+	}
+	if text := call(tagListTool, map[string]interface{}{"keyword": "SCAN"}); !slices.Equal(names(text), []string{"scanned"}) {
+		t.Errorf("按关键词筛选应只返回scanned:\n%s", text)
+	}
+}
+
+// TestTrash 测试回收站：标签伪删除和恢复、批量彻底删除的级联清理、按保留天数自动清理
+func TestTrash(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	store := storage.NewSkillFileStore(t.TempDir(), config.DefaultSkillMaxFileSize)
+	tagService := services.NewTagService(repo)
+	trashService := services.NewTrashService(repo, services.NewSkillService(repo, store), config.TrashConfig{RetentionDays: 30})
+
+	pdf, _ := tagService.CreateTag(ctx, services.CreateTagRequest{Name: "pdf"})
+	office, _ := tagService.CreateTag(ctx, services.CreateTagRequest{Name: "office"})
+	merge := createTestSkill(t, repo, "pdf-merge", "merge pdf files")
+	split := createTestSkill(t, repo, "pdf-split", "split pdf files")
+	for _, skill := range []*models.Skill{merge, split} {
+		repo.AddTagToSkill(ctx, skill.ID, pdf.ID)
+		repo.AddTagToSkill(ctx, skill.ID, office.ID)
+	}
+	oldJob := &models.JobTask{JobNo: "JT-A-1", Project: "a", Type: models.JobTaskTypeBugFix, Goal: "old", Status: "已创建"}
+	newJob := &models.JobTask{JobNo: "JT-A-2", Project: "a", Type: models.JobTaskTypeBugFix, Goal: "new", Status: "已创建"}
+	for _, job := range []*models.JobTask{oldJob, newJob} {
+		if err := repo.CreateJobTask(ctx, job); err != nil {
+			t.Fatalf("创建任务失败: %v", err)
+		}
+		repo.DeleteJobTask(ctx, job.ID)
+	}
+	repo.DeleteSkill(ctx, merge.ID)
+
+	// 删除的标签进入回收站，技能上不再显示，同名标签不能再创建
+	if err := tagService.DeleteTag(ctx, office.ID); err != nil {
+		t.Fatalf("删除标签失败: %v", err)
+	}
+	if tags, _ := repo.GetTagsBySkillID(ctx, split.ID); len(tags) != 1 || tags[0].ID != pdf.ID {
+		t.Errorf("回收站中的标签不应显示在技能上: %+v", tags)
+	}
+	if skill, _ := repo.GetSkillByID(ctx, split.ID); len(skill.Tags) != 1 {
+		t.Errorf("预加载的标签不应包含回收站中的标签: %+v", skill.Tags)
+	}
+	if _, err := tagService.CreateTag(ctx, services.CreateTagRequest{Name: "office"}); err == nil {
+		t.Error("回收站中有同名标签时创建应报错")
+	}
+
+	list, err := trashService.ListTrash(ctx, services.ListTrashRequest{})
+	if err != nil || list.Pagination["total"] != int64(4) {
+		t.Fatalf("回收站应有4项: %+v, %v", list, err)
+	}
+	if !slices.IsSortedFunc(list.Items, func(a, b services.TrashItemResponse) int { return int(b.DeletedAt - a.DeletedAt) }) {
+		t.Errorf("回收站应按删除时间倒序排列: %+v", list.Items)
+	}
+	if !slices.ContainsFunc(list.Items, func(item services.TrashItemResponse) bool {
+		return item.Type == repositories.TrashTypeTag && item.Name == "office" && item.ExpiresAt == item.DeletedAt+30*24*3600*1000
+	}) {
+		t.Errorf("回收站应包含删除的标签及其过期时间: %+v", list.Items)
+	}
+	if list, _ = trashService.ListTrash(ctx, services.ListTrashRequest{Type: repositories.TrashTypeJobTask}); len(list.Items) != 2 {
+		t.Errorf("按类型筛选应返回2个任务: %+v", list.Items)
+	}
+	if _, err := trashService.ListTrash(ctx, services.ListTrashRequest{Type: "file"}); err == nil {
+		t.Error("无效的类型应报错")
+	}
+
+	// 恢复标签后技能关联重新生效，不在回收站中的项单独报告失败
+	result, err := trashService.Restore(ctx, services.TrashBatchRequest{Items: []services.TrashRef{
+		{Type: repositories.TrashTypeTag, ID: office.ID}, {Type: repositories.TrashTypeSkill, ID: split.ID},
+	}})
+	if err != nil || len(result.Succeeded) != 1 || len(result.Failed) != 1 || result.Failed[0].Reason != "回收站中不存在该项" {
+		t.Fatalf("批量恢复结果不符合预期: %+v, %v", result, err)
+	}
+	if tags, _ := repo.GetTagsBySkillID(ctx, split.ID); len(tags) != 2 {
+		t.Errorf("恢复标签后技能应重新带有2个标签: %+v", tags)
+	}
+
+	// 彻底删除技能和标签时级联删除标签关联和分词索引
+	repo.DeleteTag(ctx, office.ID)
+	result, _ = trashService.Purge(ctx, services.TrashBatchRequest{Items: []services.TrashRef{
+		{Type: repositories.TrashTypeSkill, ID: merge.ID}, {Type: repositories.TrashTypeTag, ID: office.ID},
+	}})
+	if len(result.Succeeded) != 2 {
+		t.Fatalf("彻底删除失败: %+v", result.Failed)
+	}
+	var skillTags, tokens int64
+	repo.GetDB().Model(&models.SkillTag{}).Where("skill_id = ? OR tag_id = ?", merge.ID, office.ID).Count(&skillTags)
+	repo.GetDB().Model(&models.SkillToken{}).Where("skill_id = ?", merge.ID).Count(&tokens)
+	if skillTags != 0 || tokens != 0 {
+		t.Errorf("彻底删除后应清理标签关联和分词索引: skill_tags=%d, skill_tokens=%d", skillTags, tokens)
+	}
+	if _, err := tagService.CreateTag(ctx, services.CreateTagRequest{Name: "office"}); err != nil {
+		t.Errorf("彻底删除后应能创建同名标签: %v", err)
+	}
+
+	// 只自动清理超过保留天数的项
+	repo.GetDB().Model(&models.JobTask{}).Where("id = ?", oldJob.ID).Update("deleted_at", time.Now().AddDate(0, 0, -31).UnixMilli())
+	result, err = trashService.PurgeExpired(ctx)
+	if err != nil || len(result.Succeeded) != 1 || result.Succeeded[0].ID != oldJob.ID {
+		t.Fatalf("应只清理过期的任务: %+v, %v", result, err)
+	}
+	if list, _ = trashService.ListTrash(ctx, services.ListTrashRequest{}); len(list.Items) != 1 || list.Items[0].Name != "JT-A-2" {
+		t.Errorf("未过期的任务应保留在回收站中: %+v", list.Items)
+	}
+}
+
 // TestSkillMenuTool_WithoutRepo 测试仓库未初始化时的处理
 func TestSkillMenuTool_WithoutRepo(t *testing.T) {
 	// 临时保存原repo
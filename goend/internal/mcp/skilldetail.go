@@ -53,6 +53,17 @@ func detailTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallTool
 			if skill.Compatibility != "" {
 				skillDetail += "兼容性: " + skill.Compatibility + "\n"
 			}
+			if len(skill.Metadata) > 0 {
+				skillDetail += "元数据:\n"
+				for _, key := range skill.Metadata.Keys() {
+					skillDetail += "  " + key + ": " + skill.Metadata[key] + "\n"
+				}
+			}
+			// 技能脚本需在技能目录下执行，提供绝对路径便于定位
+			skillDetail += "基准目录: " + fileStore.BaseDir() + "\n"
+			if skillDir, err := fileStore.SkillDir(skill.ResourceDir); err == nil {
+				skillDetail += "技能目录: " + skillDir + "\n"
+			}
 		}
 	}
 
@@ -0,0 +1,95 @@
+package mcp
+
+import (
+	"aiflow/internal/services"
+	"aiflow/internal/utils/logx"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// initTag 初始化标签查询工具
+func initTag(server *server.MCPServer) {
+	server.AddTool(mcp.Tool{
+		Name:        "tag_list",
+		Description: "查标签，列出所有标签及其技能数，用于确定 skill_by_tag 的标签名称",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"keyword": map[string]any{
+					"type":        "string",
+					"description": "按关键词筛选标签路径或别名，不区分大小写，不传则列出全部标签",
+				},
+			},
+			Required: []string{},
+		},
+	}, tagListTool)
+}
+
+// tagListTool 按层级列出标签及其技能数和别名
+func tagListTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	keyword := strings.TrimSpace(request.GetString("keyword", ""))
+
+	logx.Debug("tag list: keyword=%s", keyword)
+
+	var tagList string
+	if repo == nil {
+		tagList = "数据库未初始化，无法获取标签列表"
+	} else if tree, err := services.NewTagService(repo).GetTagTree(ctx, 0); err != nil {
+		logx.Error("获取标签树失败: %v", err)
+		tagList = "获取标签列表失败: " + err.Error()
+	} else if aliases, err := repo.ListAllTagAliases(ctx); err != nil {
+		logx.Error("获取标签别名失败: %v", err)
+		tagList = "获取标签列表失败: " + err.Error()
+	} else {
+		tagList = formatTagList(tree, aliases, keyword)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: tagList,
+			},
+		},
+	}, nil
+}
+
+// formatTagList 按标签树的先序顺序格式化标签列表，每行为标签名称、子标签的路径和直接关联的技能数，
+// 含子标签的技能数与之不同时一并列出，有别名时列出别名
+func formatTagList(tree []*services.TagTreeNode, aliases map[uint][]string, keyword string) string {
+	keyword = strings.ToLower(keyword)
+	var lines []string
+	var walk func(nodes []*services.TagTreeNode)
+	walk = func(nodes []*services.TagTreeNode) {
+		for _, node := range nodes {
+			if keyword == "" || strings.Contains(strings.ToLower(node.Path), keyword) ||
+				strings.Contains(strings.ToLower(strings.Join(aliases[node.ID], ",")), keyword) {
+				line := "name: " + node.Name
+				if node.Path != node.Name {
+					line += " path: " + node.Path
+				}
+				line += fmt.Sprintf(" skills: %d", node.SkillCount)
+				if node.TotalSkillCount != node.SkillCount {
+					line += fmt.Sprintf(" total: %d", node.TotalSkillCount)
+				}
+				if len(aliases[node.ID]) > 0 {
+					line += " aliases: " + strings.Join(aliases[node.ID], ", ")
+				}
+				lines = append(lines, line)
+			}
+			walk(node.Children)
+		}
+	}
+	walk(tree)
+
+	if len(lines) == 0 {
+		return "未找到匹配的标签"
+	}
+	return "标签列表（skills为直接关联的技能数，total为含子标签的技能数）：\n" +
+		strings.Join(lines, "\n") +
+		"\n\n请调用 skill_by_tag 按name查技能，多个标签用逗号分隔"
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"context"
+	"encoding/json"
 	"log"
 
 	"gorm.io/gorm"
@@ -18,6 +19,108 @@ func MigrateData(db *gorm.DB) error {
 	// 2. 删除job_tasks表的废弃module_path字段
 	_ = migrateDropModulePathColumn(db, ctx)
 
+	// 3. 将job_tasks表中JSON格式的执行记录迁移到独立的执行记录表
+	_ = migrateExecutionRecordsToTable(db, ctx)
+
+	// 4. 将技能和技能修订中的元数据文本转换为JSON键值对
+	_ = migrateSkillMetadataToJSON(db, ctx, "skills")
+	_ = migrateSkillMetadataToJSON(db, ctx, "skill_revisions")
+
+	return nil
+}
+
+// migrateSkillMetadataToJSON 将表中metadata字段的旧版文本转换为JSON对象
+// 可解析为JSON或YAML键值对的按键值对保存，其他文本保存在raw键下；已是规范JSON的记录不做修改
+func migrateSkillMetadataToJSON(db *gorm.DB, ctx context.Context, table string) error {
+	type legacyMetadata struct {
+		ID       uint
+		Metadata string
+	}
+
+	var rows []legacyMetadata
+	err := db.WithContext(ctx).Table(table).Select("id, metadata").
+		Where("metadata IS NOT NULL AND metadata != ''").Scan(&rows).Error
+	if err != nil {
+		log.Printf("查询%s表的元数据失败: %v", table, err)
+		return nil
+	}
+
+	migrated := 0
+	for _, row := range rows {
+		normalized := ParseSkillMetadata(row.Metadata).String()
+		if normalized == row.Metadata {
+			continue
+		}
+		if err := db.WithContext(ctx).Table(table).Where("id = ?", row.ID).Update("metadata", normalized).Error; err != nil {
+			log.Printf("转换%s表记录 %d 的元数据失败，保留原数据: %v", table, row.ID, err)
+			continue
+		}
+		migrated++
+	}
+
+	if migrated > 0 {
+		log.Printf("数据迁移完成: 已将%s表中%d条记录的元数据转换为JSON键值对", table, migrated)
+	}
+	return nil
+}
+
+// migrateExecutionRecordsToTable 将job_tasks.execution_records中的JSON数组迁移到job_execution_records表
+// 迁移成功后清空原字段；JSON解析失败或写入失败时保留原字段内容，避免丢失数据
+func migrateExecutionRecordsToTable(db *gorm.DB, ctx context.Context) error {
+	type legacyJobTask struct {
+		ID               uint
+		ExecutionRecords string
+	}
+
+	var tasks []legacyJobTask
+	err := db.WithContext(ctx).Raw(
+		"SELECT id, execution_records FROM job_tasks WHERE execution_records IS NOT NULL AND execution_records != ''",
+	).Scan(&tasks).Error
+	if err != nil {
+		log.Printf("查询待迁移的执行记录失败: %v", err)
+		return nil
+	}
+
+	if len(tasks) == 0 {
+		log.Println("无需数据迁移: 未发现JSON格式的执行记录")
+		return nil
+	}
+
+	log.Printf("开始数据迁移: 迁移%d个任务的执行记录到job_execution_records表", len(tasks))
+
+	migrated := 0
+	for _, task := range tasks {
+		var records []ExecutionRecord
+		if err := json.Unmarshal([]byte(task.ExecutionRecords), &records); err != nil {
+			log.Printf("任务 %d 的执行记录JSON格式错误，保留原数据: %v", task.ID, err)
+			continue
+		}
+
+		err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			// 已迁移过的执行记录不重复写入
+			var count int64
+			if err := tx.Model(&ExecutionRecord{}).Where("job_id = ?", task.ID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				for i := range records {
+					records[i].ID = 0
+					records[i].JobID = task.ID
+					if err := tx.Create(&records[i]).Error; err != nil {
+						return err
+					}
+				}
+			}
+			return tx.Exec("UPDATE job_tasks SET execution_records = '' WHERE id = ?", task.ID).Error
+		})
+		if err != nil {
+			log.Printf("迁移任务 %d 的执行记录失败，保留原数据: %v", task.ID, err)
+			continue
+		}
+		migrated++
+	}
+
+	log.Printf("数据迁移完成: 已迁移%d个任务的执行记录", migrated)
 	return nil
 }
 
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"encoding/json"
+
 	"gorm.io/gorm"
 )
 
@@ -12,15 +14,26 @@ type SkillToken struct {
 }
 
 // Tag 标签模型
+// 标签可以按 ParentID 组成树，如 frontend → react → testing
 type Tag struct {
-	ID        uint    `gorm:"primaryKey;autoIncrement" json:"id"`
-	Name      string  `gorm:"type:varchar(100);not null;uniqueIndex" json:"name"`
+	ID   uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name string `gorm:"type:varchar(100);not null;uniqueIndex" json:"name"`
+	// ParentID 父标签ID，0表示顶层标签
+	ParentID  uint    `gorm:"not null;default:0;index" json:"parentId"`
 	CreatedAt int64   `gorm:"index" json:"createdAt"`
 	UpdatedAt int64   `json:"updatedAt"`
 	DeletedAt int64   `gorm:"index" json:"-"`
 	Skills    []Skill `gorm:"many2many:skill_tags;" json:"skills,omitempty"`
 }
 
+// TagAlias 标签别名表
+// 标签合并后，被合并标签的名称作为别名指向保留的标签，按名称查找标签时会解析别名
+type TagAlias struct {
+	Alias     string `gorm:"type:varchar(100);primaryKey" json:"alias"`
+	TagID     uint   `gorm:"not null;index" json:"tagId"`
+	CreatedAt int64  `json:"createdAt"`
+}
+
 // SkillTag 技能标签关联表
 type SkillTag struct {
 	SkillID uint `gorm:"primaryKey;index:idx_skill_tags_skill_id"`
@@ -35,17 +48,17 @@ type SkillTag struct {
 // metadata	❌	任意键值对；用于版本、作者等附加信息	metadata: {author: example-org, version: "1.0"}
 // allowed-tools	❌	空格分隔的预批准工具列表（实验性）	allowed-tools: Bash(git:*) Bash(jq:*) Read
 type Skill struct {
-	ID            uint   `gorm:"primaryKey;autoIncrement" json:"id"`
-	Name          string `gorm:"type:varchar(100);not null;uniqueIndex" json:"name"`
-	ResourceDir   string `gorm:"type:varchar(100);not null;uniqueIndex" json:"resourceDir"`
-	Description   string `gorm:"type:text" json:"description"`
-	License       string `gorm:"type:varchar(100)" json:"license"`
-	Version       string `gorm:"type:varchar(50)" json:"version"`
-	Compatibility string `gorm:"type:text" json:"compatibility"`
-	Metadata      string `gorm:"type:text" json:"metadata"`
-	AllowedTools  string `gorm:"type:text;column:allowed_tools" json:"allowedTools"`
-	Detail        string `json:"detail,omitempty"`
-	Tags          []Tag  `gorm:"many2many:skill_tags;" json:"tags,omitempty"`
+	ID            uint          `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name          string        `gorm:"type:varchar(100);not null;uniqueIndex" json:"name"`
+	ResourceDir   string        `gorm:"type:varchar(100);not null;uniqueIndex" json:"resourceDir"`
+	Description   string        `gorm:"type:text" json:"description"`
+	License       string        `gorm:"type:varchar(100)" json:"license"`
+	Version       string        `gorm:"type:varchar(50)" json:"version"`
+	Compatibility string        `gorm:"type:text" json:"compatibility"`
+	Metadata      SkillMetadata `gorm:"type:text" json:"metadata"`
+	AllowedTools  string        `gorm:"type:text;column:allowed_tools" json:"allowedTools"`
+	Detail        string        `json:"detail,omitempty"`
+	Tags          []Tag         `gorm:"many2many:skill_tags;" json:"tags,omitempty"`
 
 	CreatedAt int64 `gorm:"index" json:"createdAt"`
 	UpdatedAt int64 `json:"updatedAt"`
@@ -71,7 +84,7 @@ const (
 // 关联模块: 需要处理的相关模块路径
 // 验收状态: 是否通过验收
 // 完成阶段: 已创建、处理中、处理失败、处理完成、验收通过
-// 执行记录: JSON格式数组，记录每次执行的状态和结果
+// 执行记录: 存储在job_execution_records表，记录每次执行的状态和结果
 // 统一用伪删除，避免删除数据后导致的问题
 type JobTask struct {
 	ID                      uint   `gorm:"primaryKey;autoIncrement" json:"id"`
@@ -81,25 +94,158 @@ type JobTask struct {
 	Goal                    string `gorm:"type:text;not null" json:"goal"`                    // 任务目标
 	PassAcceptStd           bool   `gorm:"type:boolean;default:false" json:"passAcceptStd"`   // 验收状态
 	Status                  string `gorm:"type:varchar(20);not null" json:"status"`           // 完成阶段
-	ExecutionRecords        string `gorm:"type:text" json:"executionRecords"`                 // 执行记录(JSON数组)
+	ExecutionRecords        string `gorm:"type:text" json:"executionRecords"`                 // 执行记录(JSON数组)，仅用于兼容旧数据和接口响应
 	ActiveExecutionSequence int    `gorm:"type:int;default:0" json:"activeExecutionSequence"` // 当前活跃执行序号
 
+	Executions []ExecutionRecord `gorm:"foreignKey:JobID" json:"-"` // 执行记录，按执行序号升序
+
 	CreatedAt int64 `gorm:"index" json:"createdAt"`
 	UpdatedAt int64 `json:"updatedAt"`
 	DeletedAt int64 `gorm:"index" json:"-"`
 }
 
+// ActiveExecution 获取当前活跃的执行记录，不存在时返回nil
+func (t *JobTask) ActiveExecution() *ExecutionRecord {
+	for i := range t.Executions {
+		if t.Executions[i].Sequence == t.ActiveExecutionSequence {
+			return &t.Executions[i]
+		}
+	}
+	return nil
+}
+
+// FillExecutionRecordsJSON 将执行记录序列化到ExecutionRecords字段
+// 保持接口响应中executionRecords为JSON数组字符串的兼容格式
+func (t *JobTask) FillExecutionRecordsJSON() {
+	executions := t.Executions
+	if executions == nil {
+		executions = []ExecutionRecord{}
+	}
+	data, err := json.Marshal(executions)
+	if err != nil {
+		return
+	}
+	t.ExecutionRecords = string(data)
+}
+
+// JobNoSequence 任务编号序号表
+// 按项目代号和日期分别计数，保证同一项目同一天内的任务编号序号连续且不重复
+type JobNoSequence struct {
+	ProjectCode string `gorm:"type:varchar(100);primaryKey"` // 规范化后的项目代号
+	Date        string `gorm:"type:varchar(8);primaryKey"`   // 日期，格式20060102
+	Seq         int    `gorm:"type:int;not null;default:0"`  // 当日已分配的最大序号
+	UpdatedAt   int64  // 更新时间（毫秒级时间戳）
+}
+
 // ExecutionRecord 单次执行结果记录
+// 以任务ID和执行序号唯一标识，关联文件和技能列表以JSON数组存储，可通过json_each查询
 type ExecutionRecord struct {
-	Sequence     int      `json:"sequence"`     // 执行序号
-	Status       string   `json:"status"`       // 执行状态
-	Result       string   `json:"result"`       // 执行结果描述
-	Solution     string   `json:"solution"`     // 解决方案
-	RelatedFiles []string `json:"relatedFiles"` // 关联文件列表
-	AcceptStd    string   `json:"acceptStd"`    // 验收标准
-	Skills       []string `json:"skills"`       // 使用的技能列表
-	CreatedAt    int64    `json:"createdAt"`    // 创建时间（毫秒级时间戳）
-	UpdatedAt    int64    `json:"updatedAt"`    // 更新时间（毫秒级时间戳）
+	ID           uint     `gorm:"primaryKey;autoIncrement" json:"-"`
+	JobID        uint     `gorm:"not null;uniqueIndex:idx_job_execution_records_job_seq" json:"-"`                 // 所属任务ID
+	Sequence     int      `gorm:"type:int;not null;uniqueIndex:idx_job_execution_records_job_seq" json:"sequence"` // 执行序号
+	Status       string   `gorm:"type:varchar(20);index" json:"status"`                                            // 执行状态
+	Result       string   `gorm:"type:text" json:"result"`                                                         // 执行结果描述
+	Solution     string   `gorm:"type:text" json:"solution"`                                                       // 解决方案
+	RelatedFiles []string `gorm:"type:text;serializer:json" json:"relatedFiles"`                                   // 关联文件列表
+	AcceptStd    string   `gorm:"type:varchar(50)" json:"acceptStd"`                                               // 验收标准
+	Skills       []string `gorm:"type:text;serializer:json" json:"skills"`                                         // 使用的技能列表
+	CreatedAt    int64    `gorm:"index" json:"createdAt"`                                                          // 创建时间（毫秒级时间戳）
+	UpdatedAt    int64    `json:"updatedAt"`                                                                       // 更新时间（毫秒级时间戳）
+}
+
+// TableName 指定执行记录表名，避免与job_tasks表的旧字段execution_records混淆
+func (ExecutionRecord) TableName() string {
+	return "job_execution_records"
+}
+
+// 技能同步状态常量
+const (
+	SkillSyncStatusSynced   = "synced"   // 已同步
+	SkillSyncStatusConflict = "conflict" // 文件和数据库在上次同步后都有修改，等待人工处理
+)
+
+// SkillSyncState 技能目录同步状态
+// 记录同步目录下的技能文件夹与数据库技能的对应关系，以及上次同步时双方内容的哈希
+// 文件哈希和数据库哈希都与上次同步不同时判定为冲突，不自动覆盖任何一方
+type SkillSyncState struct {
+	ID             uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	Folder         string `gorm:"type:varchar(255);not null;uniqueIndex" json:"folder"` // 技能文件夹名（同步目录下的一级目录）
+	SkillID        uint   `gorm:"index" json:"skillId"`                                 // 关联的技能ID
+	SkillName      string `gorm:"type:varchar(100)" json:"skillName"`                   // 技能名称
+	FileHash       string `gorm:"type:varchar(64)" json:"fileHash"`                     // 上次同步时SKILL.md的内容哈希
+	DBHash         string `gorm:"type:varchar(64)" json:"dbHash"`                       // 上次同步时数据库技能的内容哈希
+	Status         string `gorm:"type:varchar(20);index" json:"status"`                 // 同步状态：synced、conflict
+	ConflictReason string `gorm:"type:text" json:"conflictReason"`                      // 冲突原因
+	SyncedAt       int64  `json:"syncedAt"`                                             // 上次成功同步时间（毫秒级时间戳）
+	UpdatedAt      int64  `gorm:"autoUpdateTime:milli" json:"updatedAt"`                // 更新时间（毫秒级时间戳）
+}
+
+// 技能修订来源常量
+const (
+	SkillRevisionSourceWeb      = "web"      // Web管理界面（REST接口）
+	SkillRevisionSourceMCP      = "mcp"      // MCP工具
+	SkillRevisionSourceImport   = "import"   // SKILL.md、zip包导入或目录同步
+	SkillRevisionSourceRollback = "rollback" // 回滚到历史修订
+	SkillRevisionSourceBaseline = "baseline" // 第一次更新前的原始内容
+)
+
+// SkillRevision 技能修订快照
+// 每次创建或更新技能后保存一份完整快照，用于查看变更历史、对比和回滚
+type SkillRevision struct {
+	ID            uint          `gorm:"primaryKey;autoIncrement" json:"id"`
+	SkillID       uint          `gorm:"not null;uniqueIndex:idx_skill_revision" json:"skillId"`  // 技能ID
+	Revision      int           `gorm:"not null;uniqueIndex:idx_skill_revision" json:"revision"` // 修订号，每个技能从1开始递增
+	Source        string        `gorm:"type:varchar(20)" json:"source"`                          // 修订来源：web、mcp、import、rollback、baseline
+	Note          string        `gorm:"type:varchar(255)" json:"note,omitempty"`                 // 修订说明，如回滚来源
+	Name          string        `gorm:"type:varchar(100)" json:"name"`
+	ResourceDir   string        `gorm:"type:varchar(100)" json:"resourceDir"`
+	Description   string        `gorm:"type:text" json:"description"`
+	License       string        `gorm:"type:varchar(100)" json:"license"`
+	Version       string        `gorm:"type:varchar(50)" json:"version"`
+	Compatibility string        `gorm:"type:text" json:"compatibility"`
+	Metadata      SkillMetadata `gorm:"type:text" json:"metadata"`
+	AllowedTools  string        `gorm:"type:text" json:"allowedTools"`
+	Detail        string        `json:"detail,omitempty"`
+	Tags          []string      `gorm:"type:text;serializer:json" json:"tags"` // 标签名称，按名称排序
+	CreatedAt     int64         `gorm:"index" json:"createdAt"`                // 修订时间（毫秒级时间戳）
+}
+
+// SkillEmbedding 技能的语义向量，每个技能一条
+// 向量由Model标识的向量化方式计算，与当前方式不同的向量在搜索时忽略，重建索引时重新计算
+type SkillEmbedding struct {
+	SkillID    uint   `gorm:"primaryKey" json:"skillId"`
+	Model      string `gorm:"type:varchar(100);index" json:"model"` // 向量化方式和模型的标识
+	Dimensions int    `json:"dimensions"`                           // 向量维度
+	Vector     []byte `json:"-"`                                    // 单位向量，float32小端序
+	UpdatedAt  int64  `json:"updatedAt"`                            // 计算时间（毫秒级时间戳）
+}
+
+// 标签建议状态常量
+const (
+	TagSuggestionStatusPending  = "pending"  // 待处理
+	TagSuggestionStatusAccepted = "accepted" // 已采纳，标签已关联到技能
+	TagSuggestionStatusRejected = "rejected" // 已拒绝，之后不再建议
+)
+
+// SkillTagSuggestion 为技能自动建议的标签
+// 每个技能和标签只有一条，已采纳或已拒绝的建议不会因重新生成而改变状态
+type SkillTagSuggestion struct {
+	ID        uint    `gorm:"primaryKey;autoIncrement" json:"id"`
+	SkillID   uint    `gorm:"not null;uniqueIndex:idx_skill_tag_suggestion" json:"skillId"`     // 技能ID
+	TagID     uint    `gorm:"not null;uniqueIndex:idx_skill_tag_suggestion;index" json:"tagId"` // 建议的标签ID
+	Score     float64 `json:"score"`                                                            // 综合得分，0~1
+	Reason    string  `gorm:"type:varchar(255)" json:"reason"`                                  // 建议依据，如命中的标签名称分词或相关分词
+	Status    string  `gorm:"type:varchar(20);index" json:"status"`                             // 状态：pending、accepted、rejected
+	CreatedAt int64   `json:"createdAt"`                                                        // 创建时间（毫秒级时间戳）
+	UpdatedAt int64   `json:"updatedAt"`                                                        // 更新时间（毫秒级时间戳）
+}
+
+// AppSetting 应用内部状态的键值设置
+// 用于保存需要跨进程重启保留的少量状态，例如搜索索引所用分词配置的指纹
+type AppSetting struct {
+	Key       string `gorm:"primaryKey;type:varchar(100)" json:"key"`
+	Value     string `gorm:"type:text" json:"value"`
+	UpdatedAt int64  `json:"updatedAt"` // 更新时间（毫秒级时间戳）
 }
 
 // CreateIndexes 创建数据库索引优化查询性能
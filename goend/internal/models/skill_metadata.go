@@ -0,0 +1,244 @@
+package models
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SkillMetadataRawKey 无法解析为键值对的旧版元数据文本保存在该键下
+const SkillMetadataRawKey = "raw"
+
+// SkillMetadata 技能元数据键值对，如 {author: example-org, version: "1.0"}
+// 在skills.metadata列中以JSON对象存储，为空时存空字符串
+// 值统一保存为字符串，数字、布尔值保留原文（如 version: 1.10 保存为 "1.10"），嵌套结构转为JSON文本
+type SkillMetadata map[string]string
+
+// NewSkillMetadata 将任意键值对转换为技能元数据
+func NewSkillMetadata(raw map[string]any) SkillMetadata {
+	if len(raw) == 0 {
+		return nil
+	}
+	metadata := make(SkillMetadata, len(raw))
+	for key, value := range raw {
+		metadata[key] = metadataValueString(value)
+	}
+	return metadata
+}
+
+// NewSkillMetadataFromYAML 将YAML映射节点转换为技能元数据，标量值保留原文
+// 节点不是映射时返回false
+func NewSkillMetadataFromYAML(node *yaml.Node) (SkillMetadata, bool) {
+	if node.Kind == yaml.DocumentNode && len(node.Content) == 1 {
+		node = node.Content[0]
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil, false
+	}
+	if len(node.Content) == 0 {
+		return nil, true
+	}
+	metadata := make(SkillMetadata, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		metadata[node.Content[i].Value] = yamlNodeString(node.Content[i+1])
+	}
+	return metadata, true
+}
+
+// ParseSkillMetadata 解析元数据文本
+// 依次尝试JSON对象和YAML映射（含 {author: x} 这种流式写法），都不是时整段文本保存在raw键下，保证旧数据不丢失
+func ParseSkillMetadata(text string) SkillMetadata {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+
+	if raw, err := decodeJSONObject([]byte(text)); err == nil {
+		return NewSkillMetadata(raw)
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(text), &node); err == nil {
+		if metadata, ok := NewSkillMetadataFromYAML(&node); ok {
+			return metadata
+		}
+	}
+	return SkillMetadata{SkillMetadataRawKey: text}
+}
+
+// decodeJSONObject 解析JSON对象，数字保留原文
+func decodeJSONObject(data []byte) (map[string]any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var raw map[string]any
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("JSON对象后有多余内容")
+	}
+	return raw, nil
+}
+
+// yamlNodeString 将YAML值节点转换为字符串，标量保留原文，null为空字符串
+func yamlNodeString(node *yaml.Node) string {
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	if node.Kind == yaml.ScalarNode {
+		if node.ShortTag() == "!!null" {
+			return ""
+		}
+		return node.Value
+	}
+	var value any
+	if err := node.Decode(&value); err != nil {
+		return ""
+	}
+	return metadataValueString(value)
+}
+
+// metadataValueString 将元数据值转换为字符串
+func metadataValueString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool, int, int64, uint64:
+		return fmt.Sprint(v)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
+
+// Keys 返回按字母排序的元数据键
+func (m SkillMetadata) Keys() []string {
+	return slices.Sorted(maps.Keys(m))
+}
+
+// String 返回元数据的JSON文本，为空时返回空字符串
+func (m SkillMetadata) String() string {
+	if len(m) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(map[string]string(m))
+	return string(data)
+}
+
+// Value 实现driver.Valuer，以JSON对象写入数据库
+func (m SkillMetadata) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// Scan 实现sql.Scanner，兼容迁移前以任意文本保存的元数据
+func (m *SkillMetadata) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*m = nil
+	case string:
+		*m = ParseSkillMetadata(v)
+	case []byte:
+		*m = ParseSkillMetadata(string(v))
+	default:
+		return fmt.Errorf("不支持的元数据类型: %T", value)
+	}
+	return nil
+}
+
+// UnmarshalJSON 支持JSON对象和旧版的元数据文本两种写法
+func (m *SkillMetadata) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*m = ParseSkillMetadata(text)
+		return nil
+	}
+
+	raw, err := decodeJSONObject(data)
+	if err != nil {
+		return fmt.Errorf("元数据必须是键值对对象: %w", err)
+	}
+	*m = NewSkillMetadata(raw)
+	return nil
+}
+
+// SkillMetadataFilter 按元数据筛选技能的条件
+// 只有键时要求技能包含该键，同时有值时要求该键的值相等（不区分大小写）
+type SkillMetadataFilter struct {
+	Key   string
+	Value string
+	// MatchValue 为true时比较值，否则只要求键存在
+	MatchValue bool
+}
+
+// ParseSkillMetadataFilter 解析 "key" 或 "key:value" 形式的元数据筛选条件
+func ParseSkillMetadataFilter(text string) (SkillMetadataFilter, error) {
+	key, value, matchValue := strings.Cut(text, ":")
+	filter := SkillMetadataFilter{
+		Key:        strings.TrimSpace(key),
+		Value:      strings.TrimSpace(value),
+		MatchValue: matchValue,
+	}
+	if filter.Key == "" {
+		return filter, fmt.Errorf("元数据筛选条件 %q 缺少键名", text)
+	}
+	// 键名会拼入JSON路径，不允许包含双引号
+	if strings.Contains(filter.Key, `"`) {
+		return filter, fmt.Errorf("元数据键名 %q 不能包含双引号", filter.Key)
+	}
+	return filter, nil
+}
+
+// ParseSkillMetadataFilters 解析逗号分隔的多个元数据筛选条件，如 "author:alice,team"
+func ParseSkillMetadataFilters(text string) ([]SkillMetadataFilter, error) {
+	var filters []SkillMetadataFilter
+	for _, part := range strings.Split(text, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		filter, err := ParseSkillMetadataFilter(part)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+	return filters, nil
+}
+
+// JSONPath 返回筛选键对应的SQLite JSON路径
+func (f SkillMetadataFilter) JSONPath() string {
+	return `$."` + f.Key + `"`
+}
+
+// Match 判断元数据是否满足筛选条件
+func (f SkillMetadataFilter) Match(m SkillMetadata) bool {
+	value, ok := m[f.Key]
+	if !ok {
+		return false
+	}
+	return !f.MatchValue || strings.EqualFold(value, f.Value)
+}
+
+// MatchAll 判断元数据是否满足全部筛选条件
+func (m SkillMetadata) MatchAll(filters []SkillMetadataFilter) bool {
+	for _, filter := range filters {
+		if !filter.Match(m) {
+			return false
+		}
+	}
+	return true
+}
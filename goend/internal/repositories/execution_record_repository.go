@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"context"
+
+	"aiflow/internal/models"
+)
+
+// ExecutionRecordFilter 执行记录查询条件
+// 所有条件均为可选，零值表示不限制
+type ExecutionRecordFilter struct {
+	JobID     uint   // 所属任务ID
+	Status    string // 执行状态
+	Skill     string // 使用过的技能名称
+	StartDate int64  // 创建时间起始（毫秒级时间戳）
+	EndDate   int64  // 创建时间截止（毫秒级时间戳）
+}
+
+// ListExecutionRecords 按条件查询执行记录（不包含已删除任务的执行记录）
+// 技能条件通过json_each展开skills数组匹配，结果按创建时间倒序排列
+// 参数:
+//   - ctx: 上下文
+//   - filter: 查询条件
+//
+// 返回:
+//   - []models.ExecutionRecord: 执行记录列表
+//   - error: 错误信息
+func (r *Repository) ListExecutionRecords(ctx context.Context, filter ExecutionRecordFilter) ([]models.ExecutionRecord, error) {
+	query := r.db.WithContext(ctx).
+		Model(&models.ExecutionRecord{}).
+		Joins("JOIN job_tasks ON job_tasks.id = job_execution_records.job_id").
+		Where("job_tasks.deleted_at = ?", 0)
+
+	if filter.JobID > 0 {
+		query = query.Where("job_execution_records.job_id = ?", filter.JobID)
+	}
+	if filter.Status != "" {
+		query = query.Where("job_execution_records.status = ?", filter.Status)
+	}
+	if filter.Skill != "" {
+		query = query.Where("EXISTS (SELECT 1 FROM json_each(job_execution_records.skills) WHERE json_each.value = ?)", filter.Skill)
+	}
+	if filter.StartDate > 0 {
+		query = query.Where("job_execution_records.created_at >= ?", filter.StartDate)
+	}
+	if filter.EndDate > 0 {
+		query = query.Where("job_execution_records.created_at <= ?", filter.EndDate)
+	}
+
+	var records []models.ExecutionRecord
+	err := query.Order("job_execution_records.created_at DESC").Find(&records).Error
+	return records, err
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // JobTask缓存相关常量定义
@@ -31,32 +32,97 @@ func clearJobTaskProjectCache() {
 	jobTaskProjectCache.Delete(jobTaskProjectListCacheKey())
 }
 
+// preloadExecutions 预加载执行记录，按执行序号升序
+func preloadExecutions(db *gorm.DB) *gorm.DB {
+	return db.Order("sequence ASC")
+}
+
+// fillExecutionRecordsJSON 为任务列表填充兼容格式的执行记录JSON
+func fillExecutionRecordsJSON(jobTasks []models.JobTask) {
+	for i := range jobTasks {
+		jobTasks[i].FillExecutionRecordsJSON()
+	}
+}
+
 // JobTask CRUD 操作
 
 // CreateJobTask 创建任务
+// jobTask.Executions中的执行记录会在同一事务中一并写入执行记录表
 func (r *Repository) CreateJobTask(ctx context.Context, jobTask *models.JobTask) error {
 	// 设置时间戳，毫秒级精度
 	timestamp := time.Now().UnixMilli()
 	jobTask.CreatedAt = timestamp
 	jobTask.UpdatedAt = timestamp
 
-	err := r.db.WithContext(ctx).Create(jobTask).Error
+	// 执行记录以独立表为准，不再写入旧的JSON字段
+	err := r.db.WithContext(ctx).Omit("execution_records").Create(jobTask).Error
 	if err != nil {
 		return err
 	}
+	jobTask.FillExecutionRecordsJSON()
 
 	// 清除项目列表缓存（新增任务可能引入新项目）
 	clearJobTaskProjectCache()
 	return nil
 }
 
+// NextJobNoSequence 在事务中为项目当日分配下一个任务编号序号
+// 先执行自增写入再读取结果，写锁保证多个实例并发分配时序号不重复
+// 参数:
+//   - ctx: 上下文
+//   - projectCode: 规范化后的项目代号
+//   - date: 日期，格式20060102
+//
+// 返回:
+//   - int: 分配到的序号，从1开始
+//   - error: 错误信息
+func (r *Repository) NextJobNoSequence(ctx context.Context, projectCode, date string) (int, error) {
+	// 检查数据库连接是否初始化
+	if r.db == nil {
+		return 0, fmt.Errorf("数据库未初始化")
+	}
+
+	var seq int
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		timestamp := time.Now().UnixMilli()
+		// 不存在则插入序号1，已存在则序号加1
+		err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "project_code"}, {Name: "date"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"seq":        gorm.Expr("seq + 1"),
+				"updated_at": timestamp,
+			}),
+		}).Create(&models.JobNoSequence{
+			ProjectCode: projectCode,
+			Date:        date,
+			Seq:         1,
+			UpdatedAt:   timestamp,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		var sequence models.JobNoSequence
+		if err := tx.Where("project_code = ? AND date = ?", projectCode, date).First(&sequence).Error; err != nil {
+			return err
+		}
+		seq = sequence.Seq
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return seq, nil
+}
+
 // GetJobTaskByID 根据ID获取任务（不包含已删除的）
 func (r *Repository) GetJobTaskByID(ctx context.Context, id uint) (*models.JobTask, error) {
 	var jobTask models.JobTask
-	err := r.db.WithContext(ctx).Where("deleted_at = ?", 0).First(&jobTask, id).Error
+	err := r.db.WithContext(ctx).Preload("Executions", preloadExecutions).Where("deleted_at = ?", 0).First(&jobTask, id).Error
 	if err != nil {
 		return nil, err
 	}
+	jobTask.FillExecutionRecordsJSON()
 	return &jobTask, nil
 }
 
@@ -68,10 +134,11 @@ func (r *Repository) GetJobTaskByJobNo(ctx context.Context, jobNo string) (*mode
 	}
 
 	var jobTask models.JobTask
-	err := r.db.WithContext(ctx).Where("job_no = ? AND deleted_at = ?", jobNo, 0).First(&jobTask).Error
+	err := r.db.WithContext(ctx).Preload("Executions", preloadExecutions).Where("job_no = ? AND deleted_at = ?", jobNo, 0).First(&jobTask).Error
 	if err != nil {
 		return nil, err
 	}
+	jobTask.FillExecutionRecordsJSON()
 	return &jobTask, nil
 }
 
@@ -114,11 +181,12 @@ func (r *Repository) ListJobTasks(ctx context.Context, page, pageSize int, proje
 
 	// 分页查询
 	offset := (page - 1) * pageSize
-	err := query.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&jobTasks).Error
+	err := query.Preload("Executions", preloadExecutions).Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&jobTasks).Error
 	if err != nil {
 		return nil, 0, err
 	}
 
+	fillExecutionRecordsJSON(jobTasks)
 	return jobTasks, total, nil
 }
 
@@ -127,21 +195,82 @@ func (r *Repository) UpdateJobTask(ctx context.Context, jobTask *models.JobTask)
 	// 更新时间戳，毫秒级精度
 	jobTask.UpdatedAt = time.Now().UnixMilli()
 
-	// 防止更新项目字段、类型、目标字段
-	// 这些字段在创建后不允许修改，使用Select指定只更新允许的字段
-	err := r.db.WithContext(ctx).Model(jobTask).Select(
+	if err := updateJobTaskFields(r.db.WithContext(ctx), jobTask); err != nil {
+		return err
+	}
+
+	// 清除项目列表缓存（更新可能修改项目字段）
+	clearJobTaskProjectCache()
+	return nil
+}
+
+// updateJobTaskFields 更新任务允许修改的字段
+// 项目、类型、目标字段在创建后不允许修改，使用Select指定只更新允许的字段，不涉及执行记录
+func updateJobTaskFields(db *gorm.DB, jobTask *models.JobTask) error {
+	return db.Model(jobTask).Select(
 		"updated_at",
 		"status",
 		"pass_accept_std",
-		"execution_records",
 		"active_execution_sequence",
 	).Updates(jobTask).Error
+}
+
+// SaveJobTaskExecution 在同一事务中更新任务并保存执行记录
+// 执行记录ID为0时新增，否则更新
+// 参数:
+//   - ctx: 上下文
+//   - jobTask: 任务，需包含允许修改的字段
+//   - record: 执行记录
+//
+// 返回:
+//   - error: 错误信息
+func (r *Repository) SaveJobTaskExecution(ctx context.Context, jobTask *models.JobTask, record *models.ExecutionRecord) error {
+	timestamp := time.Now().UnixMilli()
+	jobTask.UpdatedAt = timestamp
+	record.JobID = jobTask.ID
+	record.UpdatedAt = timestamp
+	if record.ID == 0 {
+		record.CreatedAt = timestamp
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := updateJobTaskFields(tx, jobTask); err != nil {
+			return err
+		}
+		if record.ID == 0 {
+			return tx.Create(record).Error
+		}
+		return tx.Save(record).Error
+	})
+}
+
+// ReplaceJobTaskExecutions 在同一事务中更新任务并整体替换其执行记录
+// 用于兼容通过接口提交完整执行记录JSON的场景
+func (r *Repository) ReplaceJobTaskExecutions(ctx context.Context, jobTask *models.JobTask, records []models.ExecutionRecord) error {
+	jobTask.UpdatedAt = time.Now().UnixMilli()
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := updateJobTaskFields(tx, jobTask); err != nil {
+			return err
+		}
+		if err := tx.Where("job_id = ?", jobTask.ID).Delete(&models.ExecutionRecord{}).Error; err != nil {
+			return err
+		}
+		for i := range records {
+			records[i].ID = 0
+			records[i].JobID = jobTask.ID
+			if err := tx.Create(&records[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
 		return err
 	}
 
-	// 清除项目列表缓存（更新可能修改项目字段）
-	clearJobTaskProjectCache()
+	jobTask.Executions = records
+	jobTask.FillExecutionRecordsJSON()
 	return nil
 }
 
@@ -172,11 +301,12 @@ func (r *Repository) ListDeletedJobTasks(ctx context.Context, page, pageSize int
 
 	// 分页查询，按删除时间倒序
 	offset := (page - 1) * pageSize
-	err := query.Order("deleted_at DESC").Offset(offset).Limit(pageSize).Find(&jobTasks).Error
+	err := query.Preload("Executions", preloadExecutions).Order("deleted_at DESC").Offset(offset).Limit(pageSize).Find(&jobTasks).Error
 	if err != nil {
 		return nil, 0, err
 	}
 
+	fillExecutionRecordsJSON(jobTasks)
 	return jobTasks, total, nil
 }
 
@@ -195,14 +325,20 @@ func (r *Repository) RestoreJobTask(ctx context.Context, id uint) error {
 	return nil
 }
 
-// PermanentDeleteJobTask 彻底删除任务（真删除）
+// PermanentDeleteJobTask 彻底删除任务（真删除），同时删除其执行记录
 func (r *Repository) PermanentDeleteJobTask(ctx context.Context, id uint) error {
-	result := r.db.WithContext(ctx).Where("id = ? AND deleted_at > ?", id, 0).Delete(&models.JobTask{})
-	if result.Error != nil {
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
-		return gorm.ErrRecordNotFound
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND deleted_at > ?", id, 0).Delete(&models.JobTask{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("job_id = ?", id).Delete(&models.ExecutionRecord{}).Error
+	})
+	if err != nil {
+		return err
 	}
 
 	// 清除项目列表缓存（删除可能影响项目列表）
@@ -254,11 +390,13 @@ func (r *Repository) GetAllJobTaskProjects(ctx context.Context) ([]string, error
 func (r *Repository) GetJobTasksByIDs(ctx context.Context, ids []uint) ([]models.JobTask, error) {
 	var jobTasks []models.JobTask
 	err := r.db.WithContext(ctx).
+		Preload("Executions", preloadExecutions).
 		Where("id IN ? AND deleted_at = ?", ids, 0).
 		Find(&jobTasks).Error
 	if err != nil {
 		return nil, err
 	}
+	fillExecutionRecordsJSON(jobTasks)
 	return jobTasks, nil
 }
 
@@ -272,11 +410,13 @@ func (r *Repository) GetJobTasksByIDs(ctx context.Context, ids []uint) ([]models
 func (r *Repository) GetAllJobTasks(ctx context.Context) ([]models.JobTask, error) {
 	var jobTasks []models.JobTask
 	err := r.db.WithContext(ctx).
+		Preload("Executions", preloadExecutions).
 		Where("deleted_at = ?", 0).
 		Order("created_at DESC").
 		Find(&jobTasks).Error
 	if err != nil {
 		return nil, err
 	}
+	fillExecutionRecordsJSON(jobTasks)
 	return jobTasks, nil
 }
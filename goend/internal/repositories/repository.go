@@ -2,8 +2,10 @@ package repositories
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
+	"aiflow/internal/embedding"
 	"aiflow/internal/models"
 
 	"gorm.io/driver/sqlite"
@@ -14,6 +16,23 @@ import (
 // Repository 数据库操作仓库
 type Repository struct {
 	db *gorm.DB
+
+	// skillChangeHooks 技能变更回调列表
+	skillChangeHooks []func()
+	hooksMu          sync.RWMutex
+
+	// ftsEnabled SQLite支持FTS5时为true，技能写入时同步维护全文检索索引
+	ftsEnabled bool
+
+	// embedder 技能向量化方式，技能写入后重新计算向量
+	embedder embedding.Embedder
+
+	// embedQueue 技能保存后待重新计算向量的技能，在后台计算，不阻塞保存
+	embedQueue embeddingQueue
+
+	// fuzzyVocab 近似匹配的词表和拼音缓存，技能变更或重建索引时清空
+	fuzzyVocab   *fuzzyVocabulary
+	fuzzyVocabMu sync.Mutex
 }
 
 // NewRepository 创建新的数据库仓库实例
@@ -25,7 +44,8 @@ func NewRepository(dbPath string) (*Repository, error) {
 	// _cache_size=10000: 设置缓存页数，提升查询性能
 	// _synchronous=NORMAL: 同步模式，平衡性能和数据安全
 	// _temp_store=MEMORY: 临时表存储在内存中
-	dsn := fmt.Sprintf("%s?_journal_mode=WAL&_busy_timeout=5000&_cache_size=10000&_synchronous=NORMAL&_temp_store=MEMORY",
+	// _txlock=immediate: 事务开始即获取写锁，多个进程（如stdio与HTTP实例）共享数据库时由忙等待排队，避免锁升级失败
+	dsn := fmt.Sprintf("%s?_journal_mode=WAL&_busy_timeout=5000&_cache_size=10000&_synchronous=NORMAL&_temp_store=MEMORY&_txlock=immediate",
 		dbPath)
 
 	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
@@ -50,9 +70,17 @@ func NewRepository(dbPath string) (*Repository, error) {
 	err = db.AutoMigrate(
 		&models.Skill{},
 		&models.Tag{},
+		&models.TagAlias{},
 		&models.SkillTag{},
 		&models.SkillToken{},
 		&models.JobTask{},
+		&models.ExecutionRecord{},
+		&models.JobNoSequence{},
+		&models.SkillSyncState{},
+		&models.SkillRevision{},
+		&models.SkillEmbedding{},
+		&models.SkillTagSuggestion{},
+		&models.AppSetting{},
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to migrate database: %w", err)
@@ -68,7 +96,11 @@ func NewRepository(dbPath string) (*Repository, error) {
 		return nil, fmt.Errorf("failed to create indexes: %w", err)
 	}
 
-	return &Repository{db: db}, nil
+	repo := &Repository{db: db, embedder: embedding.NewHashEmbedder(0)}
+	// 创建全文检索索引（SQLite未编译FTS5时跳过）
+	repo.initSkillFTS()
+
+	return repo, nil
 }
 
 // GetDB 获取数据库连接
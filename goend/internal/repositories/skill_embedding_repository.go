@@ -0,0 +1,281 @@
+package repositories
+
+import (
+	"cmp"
+	"context"
+	"encoding/binary"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"aiflow/internal/embedding"
+	"aiflow/internal/models"
+	"aiflow/internal/utils/logx"
+
+	"gorm.io/gorm"
+)
+
+// embeddingMaxRunes 计算向量时技能文本的最大字符数，详细说明过长时截断
+const embeddingMaxRunes = 2000
+
+// 混合搜索中分词得分和向量得分的权重，以及只有向量命中时要求的最低相似度
+const (
+	hybridTokenWeight    = 0.5
+	hybridVectorWeight   = 0.5
+	hybridMinVectorScore = 0.25
+)
+
+// SkillHybridHit 混合搜索命中的技能
+type SkillHybridHit struct {
+	Skill models.Skill
+	// Score 综合得分，为分词得分和向量得分的加权和
+	Score float64
+	// TokenScore 分词得分，命中的查询分词占全部查询分词的比例
+	TokenScore float64
+	// VectorScore 向量得分，查询与技能向量的余弦相似度
+	VectorScore float64
+}
+
+// SetEmbedder 设置技能向量化方式，创建仓库时默认使用本地哈希向量化
+// 应在启动时、处理请求前设置
+func (r *Repository) SetEmbedder(embedder embedding.Embedder) {
+	r.embedder = embedder
+}
+
+// Embedder 获取当前的技能向量化方式
+func (r *Repository) Embedder() embedding.Embedder {
+	return r.embedder
+}
+
+// embeddingText 技能用于计算向量的文本：名称、描述和截断后的详细说明
+func embeddingText(skill *models.Skill) string {
+	text := skill.Name + "\n" + skill.Description + "\n" + skill.Detail
+	if utf8.RuneCountInString(text) > embeddingMaxRunes {
+		text = string([]rune(text)[:embeddingMaxRunes])
+	}
+	return text
+}
+
+// EmbedSkills 计算并保存一批技能的向量，覆盖已有的向量
+func (r *Repository) EmbedSkills(ctx context.Context, skills []models.Skill) error {
+	if r.embedder == nil || len(skills) == 0 {
+		return nil
+	}
+	texts := make([]string, len(skills))
+	for i := range skills {
+		texts[i] = embeddingText(&skills[i])
+	}
+	vectors, err := r.embedder.Embed(ctx, texts)
+	if err != nil {
+		return err
+	}
+
+	timestamp := time.Now().UnixMilli()
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i := range skills {
+			row := models.SkillEmbedding{
+				SkillID:    skills[i].ID,
+				Model:      r.embedder.Name(),
+				Dimensions: len(vectors[i]),
+				Vector:     encodeVector(vectors[i]),
+				UpdatedAt:  timestamp,
+			}
+			if err := tx.Save(&row).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// embeddingQueue 待重新计算向量的技能队列，由一个后台协程批量处理
+type embeddingQueue struct {
+	mu      sync.Mutex
+	pending map[uint]struct{}
+	// done 后台协程运行中时不为nil，队列处理完毕后关闭
+	done chan struct{}
+}
+
+// refreshSkillEmbedding 技能保存后在后台重新计算向量，不阻塞保存
+// 短时间内多次保存同一技能只计算一次；向量只影响语义搜索，计算失败（如向量化服务不可用）不影响技能本身的保存，
+// 失败时标记搜索索引需要重建，下次启动时由索引重建补齐
+func (r *Repository) refreshSkillEmbedding(skillID uint) {
+	if r.embedder == nil {
+		return
+	}
+	q := &r.embedQueue
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.pending == nil {
+		q.pending = make(map[uint]struct{})
+	}
+	q.pending[skillID] = struct{}{}
+	if q.done == nil {
+		q.done = make(chan struct{})
+		go r.runEmbeddingQueue(q.done)
+	}
+}
+
+// runEmbeddingQueue 批量计算队列中技能的向量，直到队列为空
+func (r *Repository) runEmbeddingQueue(done chan struct{}) {
+	q := &r.embedQueue
+	for {
+		q.mu.Lock()
+		if len(q.pending) == 0 {
+			q.done = nil
+			q.mu.Unlock()
+			close(done)
+			return
+		}
+		ids := slices.Collect(maps.Keys(q.pending))
+		clear(q.pending)
+		q.mu.Unlock()
+
+		// 按最新内容计算，已彻底删除的技能自然跳过
+		ctx := context.Background()
+		var skills []models.Skill
+		err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&skills).Error
+		if err == nil {
+			err = r.EmbedSkills(ctx, skills)
+		}
+		if err != nil {
+			logx.Warn("计算%d个技能的向量失败，将在下次重建索引时补齐: %v", len(ids), err)
+			r.markSearchIndexStale(ctx)
+		}
+	}
+}
+
+// WaitSkillEmbeddings 等待后台的向量计算完成，用于退出前尽量完成已保存技能的向量计算
+// ctx结束时仍未完成则标记搜索索引需要重建，返回ctx的错误
+func (r *Repository) WaitSkillEmbeddings(ctx context.Context) error {
+	r.embedQueue.mu.Lock()
+	done := r.embedQueue.done
+	r.embedQueue.mu.Unlock()
+	if done == nil {
+		return nil
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		r.markSearchIndexStale(context.Background())
+		return ctx.Err()
+	}
+}
+
+// markSearchIndexStale 清除已记录的索引配置，下次启动时重建搜索索引
+func (r *Repository) markSearchIndexStale(ctx context.Context) {
+	if err := r.SetSetting(ctx, SettingSearchAnalyzer, ""); err != nil {
+		logx.Warn("标记技能搜索索引需要重建失败: %v", err)
+	}
+}
+
+// SearchSkillsHybrid 综合分词索引和语义向量搜索未删除的技能，按综合得分降序排列
+// 命中任一查询分词，或与查询的向量相似度不低于0.25的技能会被返回；查询向量计算失败时只按分词得分排序
+// 参数:
+//
+//	ctx: 上下文
+//	keyword: 搜索关键词
+//	limit: 最多返回的条数，0表示不限制
+//
+// 返回:
+//
+//	[]SkillHybridHit: 命中的技能和各项得分
+//	error: 错误信息
+func (r *Repository) SearchSkillsHybrid(ctx context.Context, keyword string, limit int) ([]SkillHybridHit, error) {
+	keyword = strings.TrimSpace(keyword)
+	if keyword == "" {
+		return []SkillHybridHit{}, nil
+	}
+
+	scores := make(map[uint]*SkillHybridHit)
+	hitOf := func(id uint) *SkillHybridHit {
+		if scores[id] == nil {
+			scores[id] = &SkillHybridHit{}
+		}
+		return scores[id]
+	}
+
+	// 分词得分：命中的查询分词占比
+	if terms := queryTerms(keyword); len(terms) > 0 {
+		counts, err := r.countMatchedTerms(ctx, terms)
+		if err != nil {
+			return nil, err
+		}
+		for id, matched := range counts {
+			hitOf(id).TokenScore = float64(matched) / float64(len(terms))
+		}
+	}
+
+	// 向量得分：与当前向量化方式计算的技能向量的余弦相似度
+	if r.embedder != nil {
+		vectors, err := r.embedder.Embed(ctx, []string{keyword})
+		if err != nil {
+			logx.Warn("计算搜索关键词的向量失败，只按分词搜索: %v", err)
+		} else {
+			var rows []models.SkillEmbedding
+			if err := r.db.WithContext(ctx).
+				Select("skill_embeddings.skill_id, skill_embeddings.vector").
+				Joins("JOIN skills ON skills.id = skill_embeddings.skill_id").
+				Where("skills.deleted_at = 0 AND skill_embeddings.model = ?", r.embedder.Name()).
+				Find(&rows).Error; err != nil {
+				return nil, err
+			}
+			for _, row := range rows {
+				similarity := embedding.Cosine(vectors[0], decodeVector(row.Vector))
+				if similarity >= hybridMinVectorScore || scores[row.SkillID] != nil {
+					hitOf(row.SkillID).VectorScore = max(similarity, 0)
+				}
+			}
+		}
+	}
+	if len(scores) == 0 {
+		return []SkillHybridHit{}, nil
+	}
+
+	ids := make([]uint, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	var skills []models.Skill
+	if err := r.db.WithContext(ctx).Preload("Tags", LiveTags).Where("id IN ?", ids).Find(&skills).Error; err != nil {
+		return nil, err
+	}
+
+	hits := make([]SkillHybridHit, 0, len(skills))
+	for _, skill := range skills {
+		hit := *scores[skill.ID]
+		hit.Skill = skill
+		hit.Score = hybridTokenWeight*hit.TokenScore + hybridVectorWeight*hit.VectorScore
+		hits = append(hits, hit)
+	}
+	slices.SortFunc(hits, func(a, b SkillHybridHit) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), cmp.Compare(a.Skill.ID, b.Skill.ID))
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// encodeVector 将向量编码为float32小端序字节
+func encodeVector(vector []float32) []byte {
+	data := make([]byte, 4*len(vector))
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(data[4*i:], math.Float32bits(v))
+	}
+	return data
+}
+
+// decodeVector 解码float32小端序字节为向量
+func decodeVector(data []byte) []float32 {
+	vector := make([]float32, len(data)/4)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:]))
+	}
+	return vector
+}
@@ -0,0 +1,255 @@
+package repositories
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"aiflow/internal/models"
+	"aiflow/internal/utils/logx"
+
+	"gorm.io/gorm"
+)
+
+// 全文检索各字段的BM25权重，名称命中最重要，详细说明最次
+const (
+	ftsWeightName        = 10.0
+	ftsWeightDescription = 5.0
+	ftsWeightDetail      = 1.0
+)
+
+// 全文检索摘要的高亮标记和长度（按分词计）
+const (
+	ftsHighlightOpen  = "**"
+	ftsHighlightClose = "**"
+	ftsSnippetTokens  = 16
+)
+
+// createSkillFTSTable 技能全文检索虚拟表，rowid即技能ID
+// 各字段保存gse预分词后以空格连接的文本，由unicode61分词器按空格切分，从而支持中文检索
+const createSkillFTSTable = `CREATE VIRTUAL TABLE IF NOT EXISTS skills_fts USING fts5(
+	name, description, detail,
+	tokenize = 'unicode61 remove_diacritics 2'
+)`
+
+// SkillSearchHit 全文检索命中的技能
+type SkillSearchHit struct {
+	Skill models.Skill
+	// Score 相关度得分，越大越相关（BM25得分取反）
+	Score float64
+	// Snippet 命中位置的摘要，命中的词用**包围
+	Snippet string
+}
+
+// initSkillFTS 创建全文检索虚拟表，索引条数与技能数不一致时重建索引
+// SQLite未编译FTS5模块（构建时未加 -tags sqlite_fts5）时关闭全文检索，搜索退回分词索引
+func (r *Repository) initSkillFTS() {
+	if err := r.db.Exec(createSkillFTSTable).Error; err != nil {
+		logx.Warn("SQLite不支持FTS5，全文检索不可用，将使用分词索引搜索: %v", err)
+		return
+	}
+	r.ftsEnabled = true
+
+	var indexed, total int64
+	r.db.Raw("SELECT COUNT(*) FROM skills_fts").Scan(&indexed)
+	r.db.Model(&models.Skill{}).Count(&total)
+	if indexed == total {
+		return
+	}
+	if err := r.RebuildSkillFTS(context.Background()); err != nil {
+		logx.Warn("重建技能全文检索索引失败: %v", err)
+	}
+}
+
+// FullTextSearchEnabled 全文检索是否可用
+func (r *Repository) FullTextSearchEnabled() bool {
+	return r.ftsEnabled
+}
+
+// RebuildSkillFTS 清空并重建所有技能（含回收站中的技能）的全文检索索引
+func (r *Repository) RebuildSkillFTS(ctx context.Context) error {
+	if !r.ftsEnabled {
+		return nil
+	}
+	var skills []models.Skill
+	if err := r.db.WithContext(ctx).Find(&skills).Error; err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM skills_fts").Error; err != nil {
+			return err
+		}
+		for i := range skills {
+			if err := r.indexSkillFTS(tx, &skills[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// indexSkillFTS 写入或覆盖技能的全文检索索引，全文检索不可用时不做处理
+func (r *Repository) indexSkillFTS(tx *gorm.DB, skill *models.Skill) error {
+	if !r.ftsEnabled {
+		return nil
+	}
+	if err := r.deleteSkillFTS(tx, skill.ID); err != nil {
+		return err
+	}
+	return tx.Exec("INSERT INTO skills_fts(rowid, name, description, detail) VALUES (?, ?, ?, ?)",
+		skill.ID, pretokenize(skill.Name), pretokenize(skill.Description), pretokenize(skill.Detail)).Error
+}
+
+// deleteSkillFTS 删除技能的全文检索索引
+func (r *Repository) deleteSkillFTS(tx *gorm.DB, skillID uint) error {
+	if !r.ftsEnabled {
+		return nil
+	}
+	return tx.Exec("DELETE FROM skills_fts WHERE rowid = ?", skillID).Error
+}
+
+// SearchSkillsFullText 全文检索未删除的技能，按BM25相关度降序排列
+// 关键词经gse分词后任一词命中即返回，命中的词越多、越集中在名称和描述中排名越靠前
+// 参数:
+//
+//	ctx: 上下文
+//	keyword: 搜索关键词
+//	limit: 最多返回的条数，0表示不限制
+//
+// 返回:
+//
+//	[]SkillSearchHit: 命中的技能、得分和摘要
+//	error: 错误信息
+func (r *Repository) SearchSkillsFullText(ctx context.Context, keyword string, limit int) ([]SkillSearchHit, error) {
+	query := ftsMatchQuery(keyword)
+	if query == "" {
+		return []SkillSearchHit{}, nil
+	}
+
+	type ftsRow struct {
+		SkillID uint
+		Rank    float64
+		Snippet string
+	}
+	var rows []ftsRow
+	sql := `SELECT skills_fts.rowid AS skill_id,
+			bm25(skills_fts, ?, ?, ?) AS rank,
+			snippet(skills_fts, -1, ?, ?, '…', ?) AS snippet
+		FROM skills_fts
+		JOIN skills ON skills.id = skills_fts.rowid
+		WHERE skills_fts MATCH ? AND skills.deleted_at = 0
+		ORDER BY rank`
+	args := []any{ftsWeightName, ftsWeightDescription, ftsWeightDetail,
+		ftsHighlightOpen, ftsHighlightClose, ftsSnippetTokens, query}
+	if limit > 0 {
+		sql += " LIMIT ?"
+		args = append(args, limit)
+	}
+	if err := r.db.WithContext(ctx).Raw(sql, args...).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return []SkillSearchHit{}, nil
+	}
+
+	ids := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.SkillID)
+	}
+	var skills []models.Skill
+	if err := r.db.WithContext(ctx).Preload("Tags", LiveTags).Where("id IN ?", ids).Find(&skills).Error; err != nil {
+		return nil, err
+	}
+	skillMap := make(map[uint]models.Skill, len(skills))
+	for _, skill := range skills {
+		skillMap[skill.ID] = skill
+	}
+
+	hits := make([]SkillSearchHit, 0, len(rows))
+	for _, row := range rows {
+		skill, ok := skillMap[row.SkillID]
+		if !ok {
+			continue
+		}
+		hits = append(hits, SkillSearchHit{
+			Skill:   skill,
+			Score:   -row.Rank,
+			Snippet: compactSnippet(row.Snippet),
+		})
+	}
+	return hits, nil
+}
+
+// pretokenize 使用gse分词并以空格连接
+// 保留原文的大小写和标点（unicode61分词器会忽略标点并转小写），使摘要尽量接近原文
+func pretokenize(text string) string {
+	var tokens []string
+	for _, token := range seg.Cut(text, true) {
+		if token = strings.TrimSpace(token); token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	return strings.Join(tokens, " ")
+}
+
+// searchTerms 使用gse分词，返回过滤停用词、同义词归一后包含字母或数字的小写分词
+func searchTerms(text string) []string {
+	var terms []string
+	for _, token := range analyzeTokens(seg.Cut(strings.ToLower(text), true)) {
+		if strings.IndexFunc(token, func(c rune) bool { return unicode.IsLetter(c) || unicode.IsNumber(c) }) >= 0 {
+			terms = append(terms, token)
+		}
+	}
+	return terms
+}
+
+// ftsMatchQuery 将关键词转换为FTS5查询，每个分词作为短语用OR连接
+// 全文检索索引保存原文，分词属于同义词组时组内每个词都作为短语参与匹配
+func ftsMatchQuery(keyword string) string {
+	terms := searchTerms(keyword)
+	seen := make(map[string]bool, len(terms))
+	phrases := make([]string, 0, len(terms))
+	for _, term := range terms {
+		for _, word := range synonymGroup(term) {
+			if seen[word] {
+				continue
+			}
+			seen[word] = true
+			// 同义词可能由多个分词组成，按索引的预分词方式切分后作为短语
+			phrases = append(phrases, `"`+strings.ReplaceAll(pretokenize(word), `"`, `""`)+`"`)
+		}
+	}
+	return strings.Join(phrases, " OR ")
+}
+
+// compactSnippet 去掉预分词插入的多余空格，使摘要恢复为正常文本
+// 中文和全角标点之间的空格、英文标点前的空格都会被去掉，英文单词之间的空格保留
+func compactSnippet(snippet string) string {
+	runes := []rune(snippet)
+	var sb strings.Builder
+	for i, c := range runes {
+		if c == ' ' {
+			prev, next := snippetNeighbor(runes, i, -1), snippetNeighbor(runes, i, 1)
+			if (isCJKRune(prev) && isCJKRune(next)) || strings.ContainsRune(",.;:!?)]}", next) || strings.ContainsRune("([{", prev) {
+				continue
+			}
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
+
+// snippetNeighbor 返回空格在指定方向上跳过高亮标记后的相邻字符，没有时返回0
+func snippetNeighbor(runes []rune, i, step int) rune {
+	for j := i + step; j >= 0 && j < len(runes); j += step {
+		if runes[j] != '*' {
+			return runes[j]
+		}
+	}
+	return 0
+}
+
+// isCJKRune 判断字符是否为中日韩文字或全角标点
+func isCJKRune(c rune) bool {
+	return unicode.Is(unicode.Han, c) || (c >= 0x3000 && c <= 0x303F) || (c >= 0xFF00 && c <= 0xFFEF)
+}
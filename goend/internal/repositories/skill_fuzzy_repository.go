@@ -0,0 +1,295 @@
+package repositories
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"aiflow/internal/models"
+
+	"github.com/mozillazg/go-pinyin"
+)
+
+// 近似匹配的方式
+const (
+	FuzzyMatchTypo   = "typo"   // 编辑距离内的拼写错误，如 excle → excel
+	FuzzyMatchPrefix = "prefix" // 关键词是分词的前缀，如 proc → processing
+	FuzzyMatchPinyin = "pinyin" // 拼音与中文分词相同或相近，如 wenjian → 文件
+)
+
+// 近似匹配的限制
+const (
+	fuzzyMinPrefixLen   = 2   // 前缀匹配要求的最少字符数
+	fuzzyTermsPerQuery  = 20  // 每个查询词最多匹配的分词数
+	fuzzyPinyinDiscount = 0.9 // 拼音匹配的相似度折扣，使其排在同等程度的直接匹配之后
+)
+
+// fuzzyVocabTTL 词表缓存的有效期，本进程的技能变更会立即清空缓存，其他进程的变更最迟在有效期后生效
+const fuzzyVocabTTL = 5 * time.Minute
+
+// FuzzyMatch 查询词与分词的一次近似匹配
+type FuzzyMatch struct {
+	Query string  `json:"query"` // 查询词
+	Term  string  `json:"term"`  // 命中的分词
+	Kind  string  `json:"kind"`  // 匹配方式：typo、prefix、pinyin
+	Score float64 `json:"score"` // 相似度，0~1
+}
+
+// SkillFuzzyHit 近似匹配命中的技能
+type SkillFuzzyHit struct {
+	Skill models.Skill
+	// Score 相关度得分，为各查询词最佳匹配的相似度之和
+	Score float64
+	// Matches 各查询词的最佳匹配
+	Matches []FuzzyMatch
+}
+
+// SearchSkillsFuzzy 在分词索引的词表上近似匹配关键词，作为精确搜索无结果时的兜底
+// 依次尝试编辑距离内的拼写错误、前缀和中文分词的拼音，只返回未删除的技能，按得分降序排列
+// 词表及其拼音缓存在仓库实例中，技能变更或重建索引后重新加载
+// 参数:
+//
+//	ctx: 上下文
+//	keyword: 搜索关键词
+//
+// 返回:
+//
+//	[]SkillFuzzyHit: 命中的技能和匹配详情
+//	error: 错误信息
+func (r *Repository) SearchSkillsFuzzy(ctx context.Context, keyword string) ([]SkillFuzzyHit, error) {
+	queries := slices.Compact(slices.Sorted(slices.Values(searchTerms(keyword))))
+	if len(queries) == 0 {
+		return []SkillFuzzyHit{}, nil
+	}
+
+	vocab, err := r.fuzzyVocabulary(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pinyinArgs := pinyin.NewArgs()
+
+	// 每个查询词保留相似度最高的若干个分词
+	termMatches := make(map[string][]FuzzyMatch)
+	var matchedTerms []string
+	for _, query := range queries {
+		queryPinyin := query
+		if isHanWord(query) {
+			queryPinyin = strings.Join(pinyin.LazyPinyin(query, pinyinArgs), "")
+		}
+		var candidates []FuzzyMatch
+		for _, term := range vocab.terms {
+			if match, ok := fuzzyMatchTerm(query, term, queryPinyin, vocab.pinyin[term]); ok {
+				candidates = append(candidates, match)
+			}
+		}
+		slices.SortFunc(candidates, func(a, b FuzzyMatch) int {
+			return cmp.Or(cmp.Compare(b.Score, a.Score), strings.Compare(a.Term, b.Term))
+		})
+		for _, match := range candidates[:min(len(candidates), fuzzyTermsPerQuery)] {
+			if _, ok := termMatches[match.Term]; !ok {
+				matchedTerms = append(matchedTerms, match.Term)
+			}
+			termMatches[match.Term] = append(termMatches[match.Term], match)
+		}
+	}
+	if len(matchedTerms) == 0 {
+		return []SkillFuzzyHit{}, nil
+	}
+
+	type tokenRow struct {
+		SkillID uint
+		Term    string
+	}
+	var rows []tokenRow
+	if err := r.db.WithContext(ctx).Model(&models.SkillToken{}).
+		Select("skill_tokens.skill_id, skill_tokens.term").
+		Joins("JOIN skills ON skills.id = skill_tokens.skill_id").
+		Where("skills.deleted_at = 0 AND skill_tokens.term IN ?", matchedTerms).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	// 每个技能按查询词取最佳匹配，得分为各查询词最佳相似度之和
+	best := make(map[uint]map[string]FuzzyMatch)
+	for _, row := range rows {
+		if best[row.SkillID] == nil {
+			best[row.SkillID] = make(map[string]FuzzyMatch)
+		}
+		for _, match := range termMatches[row.Term] {
+			if current, ok := best[row.SkillID][match.Query]; !ok || match.Score > current.Score {
+				best[row.SkillID][match.Query] = match
+			}
+		}
+	}
+
+	ids := make([]uint, 0, len(best))
+	for id := range best {
+		ids = append(ids, id)
+	}
+	var skills []models.Skill
+	if err := r.db.WithContext(ctx).Preload("Tags", LiveTags).Where("id IN ?", ids).Find(&skills).Error; err != nil {
+		return nil, err
+	}
+
+	hits := make([]SkillFuzzyHit, 0, len(skills))
+	for _, skill := range skills {
+		hit := SkillFuzzyHit{Skill: skill}
+		for _, query := range queries {
+			if match, ok := best[skill.ID][query]; ok {
+				hit.Score += match.Score
+				hit.Matches = append(hit.Matches, match)
+			}
+		}
+		hits = append(hits, hit)
+	}
+	slices.SortFunc(hits, func(a, b SkillFuzzyHit) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), cmp.Compare(a.Skill.ID, b.Skill.ID))
+	})
+	return hits, nil
+}
+
+// fuzzyVocabulary 近似匹配使用的词表：未删除技能的全部分词及中文分词的拼音
+type fuzzyVocabulary struct {
+	terms    []string
+	pinyin   map[string]string
+	loadedAt time.Time
+}
+
+// fuzzyVocabulary 获取近似匹配的词表，缓存不存在或已过期时从分词索引加载并计算中文分词的拼音
+func (r *Repository) fuzzyVocabulary(ctx context.Context) (*fuzzyVocabulary, error) {
+	r.fuzzyVocabMu.Lock()
+	defer r.fuzzyVocabMu.Unlock()
+	if r.fuzzyVocab != nil && time.Since(r.fuzzyVocab.loadedAt) < fuzzyVocabTTL {
+		return r.fuzzyVocab, nil
+	}
+
+	vocab := &fuzzyVocabulary{pinyin: make(map[string]string), loadedAt: time.Now()}
+	if err := r.db.WithContext(ctx).Model(&models.SkillToken{}).
+		Distinct("skill_tokens.term").
+		Joins("JOIN skills ON skills.id = skill_tokens.skill_id").
+		Where("skills.deleted_at = 0").
+		Pluck("skill_tokens.term", &vocab.terms).Error; err != nil {
+		return nil, err
+	}
+	pinyinArgs := pinyin.NewArgs()
+	for _, term := range vocab.terms {
+		if isHanWord(term) {
+			vocab.pinyin[term] = strings.Join(pinyin.LazyPinyin(term, pinyinArgs), "")
+		}
+	}
+	r.fuzzyVocab = vocab
+	return vocab, nil
+}
+
+// clearFuzzyVocabulary 清空近似匹配的词表缓存，下次近似匹配时重新加载
+func (r *Repository) clearFuzzyVocabulary() {
+	r.fuzzyVocabMu.Lock()
+	defer r.fuzzyVocabMu.Unlock()
+	r.fuzzyVocab = nil
+}
+
+// fuzzyMatchTerm 近似匹配查询词和分词，返回相似度最高的匹配方式
+// queryPinyin为查询词的拼音（非中文时为查询词本身），termPinyin为分词的拼音（非中文时为空）
+func fuzzyMatchTerm(query, term, queryPinyin, termPinyin string) (FuzzyMatch, bool) {
+	match := FuzzyMatch{Query: query, Term: term}
+	if query == term {
+		return match, false
+	}
+	if score := typoScore(query, term); score > match.Score {
+		match.Kind, match.Score = FuzzyMatchTypo, score
+	}
+	if score := prefixScore(query, term); score > match.Score {
+		match.Kind, match.Score = FuzzyMatchPrefix, score
+	}
+	// 拼音查询中文分词（wenjian → 文件），或中文同音错字（文建 → 文件）
+	if termPinyin != "" {
+		score := 0.0
+		if queryPinyin == termPinyin {
+			score = 1
+		} else {
+			score = max(typoScore(queryPinyin, termPinyin), prefixScore(queryPinyin, termPinyin))
+		}
+		if score *= fuzzyPinyinDiscount; score > match.Score {
+			match.Kind, match.Score = FuzzyMatchPinyin, score
+		}
+	}
+	return match, match.Score > 0
+}
+
+// typoScore 编辑距离在允许范围内时返回相似度（1 - 距离/较长词的长度），否则返回0
+func typoScore(a, b string) float64 {
+	la, lb := utf8.RuneCountInString(a), utf8.RuneCountInString(b)
+	allowed := maxTypoEdits(min(la, lb))
+	if allowed == 0 || max(la, lb)-min(la, lb) > allowed {
+		return 0
+	}
+	distance := editDistance([]rune(a), []rune(b))
+	if distance > allowed {
+		return 0
+	}
+	return 1 - float64(distance)/float64(max(la, lb))
+}
+
+// maxTypoEdits 按词长允许的最大编辑距离，过短的词不做拼写纠错
+func maxTypoEdits(length int) int {
+	switch {
+	case length < 4:
+		return 0
+	case length < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// prefixScore 查询词是分词的前缀时返回相似度（查询词长度/分词长度），否则返回0
+func prefixScore(query, term string) float64 {
+	lq := utf8.RuneCountInString(query)
+	if lq < fuzzyMinPrefixLen || !strings.HasPrefix(term, query) {
+		return 0
+	}
+	return float64(lq) / float64(utf8.RuneCountInString(term))
+}
+
+// editDistance 计算两个字符串的编辑距离（相邻字符交换计为一次编辑）
+func editDistance(a, b []rune) int {
+	// prev2、prev、curr 分别为前两行、前一行和当前行
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(b)]
+}
+
+// isHanWord 判断文本是否全部由汉字组成
+func isHanWord(text string) bool {
+	if text == "" {
+		return false
+	}
+	for _, c := range text {
+		if !unicode.Is(unicode.Han, c) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"context"
+
+	"aiflow/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// SkillIndexEntry 重建索引时需要的技能信息
+type SkillIndexEntry struct {
+	ID   uint
+	Name string
+}
+
+// ListSkillIndexEntries 获取所有技能（含回收站中的技能）的ID和名称，按ID排序
+func (r *Repository) ListSkillIndexEntries(ctx context.Context) ([]SkillIndexEntry, error) {
+	var entries []SkillIndexEntry
+	err := r.db.WithContext(ctx).Model(&models.Skill{}).Select("id, name").Order("id ASC").Scan(&entries).Error
+	return entries, err
+}
+
+// ReindexSkills 在一个事务中重建一批技能的分词索引和全文检索索引，之后批量重新计算语义向量
+// 每个技能先删除旧索引再重建，重复执行结果相同；已不存在的技能跳过
+func (r *Repository) ReindexSkills(ctx context.Context, ids []uint) error {
+	var skills []models.Skill
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&skills).Error; err != nil {
+		return err
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i := range skills {
+			skill := &skills[i]
+			if err := tx.Where("skill_id = ?", skill.ID).Delete(&models.SkillToken{}).Error; err != nil {
+				return err
+			}
+			if err := r.buildSkillTokens(tx, skill.ID, skill.Name+" "+skill.Description); err != nil {
+				return err
+			}
+			if err := r.indexSkillFTS(tx, skill); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	r.clearFuzzyVocabulary()
+	return r.EmbedSkills(ctx, skills)
+}
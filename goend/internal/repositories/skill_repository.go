@@ -1,7 +1,9 @@
 package repositories
 
 import (
+	"cmp"
 	"context"
+	"slices"
 	"strings"
 	"time"
 
@@ -20,6 +22,27 @@ func init() {
 	seg.LoadDict()
 }
 
+// OnSkillChange 注册技能变更回调
+// 技能创建、更新、删除、恢复、彻底删除成功后同步调用，用于刷新MCP资源列表等
+func (r *Repository) OnSkillChange(fn func()) {
+	r.hooksMu.Lock()
+	defer r.hooksMu.Unlock()
+	r.skillChangeHooks = append(r.skillChangeHooks, fn)
+}
+
+// notifySkillChange 清空近似匹配的词表缓存并通知所有技能变更回调
+func (r *Repository) notifySkillChange() {
+	r.clearFuzzyVocabulary()
+
+	r.hooksMu.RLock()
+	hooks := r.skillChangeHooks
+	r.hooksMu.RUnlock()
+
+	for _, fn := range hooks {
+		fn()
+	}
+}
+
 // Skill CRUD 操作
 
 // CreateSkill 创建技能
@@ -46,13 +69,24 @@ func (r *Repository) CreateSkill(ctx context.Context, skill *models.Skill) error
 		return err
 	}
 
-	return tx.Commit().Error
+	// 建立全文检索索引
+	if err := r.indexSkillFTS(tx, skill); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+	r.refreshSkillEmbedding(skill.ID)
+	r.notifySkillChange()
+	return nil
 }
 
 // GetSkillByID 根据ID获取技能
 func (r *Repository) GetSkillByID(ctx context.Context, id uint) (*models.Skill, error) {
 	var skill models.Skill
-	err := r.db.WithContext(ctx).Preload("Tags").First(&skill, id).Error
+	err := r.db.WithContext(ctx).Preload("Tags", LiveTags).First(&skill, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -62,7 +96,7 @@ func (r *Repository) GetSkillByID(ctx context.Context, id uint) (*models.Skill,
 // GetSkillByName 根据名称获取技能
 func (r *Repository) GetSkillByName(ctx context.Context, name string) (*models.Skill, error) {
 	var skill models.Skill
-	err := r.db.WithContext(ctx).Preload("Tags").Where("name = ?", name).First(&skill).Error
+	err := r.db.WithContext(ctx).Preload("Tags", LiveTags).Where("name = ?", name).First(&skill).Error
 	if err != nil {
 		return nil, err
 	}
@@ -76,8 +110,58 @@ func (r *Repository) ListAllSkills(ctx context.Context) ([]models.Skill, error)
 	return skills, err
 }
 
+// ListSkillsForExport 获取待导出的未删除技能（含标签），按ID排序
+// 参数:
+//   - ctx: 上下文
+//   - ids: 技能ID列表，为空时不限制
+//   - tagIDs: 标签ID列表，技能带有其中任一标签即导出，为空时不限制
+//   - startDate: 创建时间起始（毫秒级时间戳），0表示不限制
+//   - endDate: 创建时间截止（毫秒级时间戳），0表示不限制
+//
+// 返回:
+//   - []models.Skill: 技能列表
+//   - error: 错误信息
+func (r *Repository) ListSkillsForExport(ctx context.Context, ids, tagIDs []uint, startDate, endDate int64) ([]models.Skill, error) {
+	query := r.db.WithContext(ctx).Model(&models.Skill{}).Where("deleted_at = ?", 0)
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	if len(tagIDs) > 0 {
+		query = query.Where("id IN (?)", r.db.Model(&models.SkillTag{}).Select("skill_id").Where("tag_id IN ?", tagIDs))
+	}
+	if startDate > 0 {
+		query = query.Where("created_at >= ?", startDate)
+	}
+	if endDate > 0 {
+		query = query.Where("created_at <= ?", endDate)
+	}
+
+	var skills []models.Skill
+	err := query.Preload("Tags", LiveTags).Order("id ASC").Find(&skills).Error
+	return skills, err
+}
+
 // UpdateSkill 更新技能
 func (r *Repository) UpdateSkill(ctx context.Context, skill *models.Skill) error {
+	return r.updateSkill(ctx, skill, nil)
+}
+
+// UpdateSkillWithTags 更新技能并按名称替换其标签，两者在同一事务中完成
+// 标签的查找和创建规则同 SetSkillTagsByName
+func (r *Repository) UpdateSkillWithTags(ctx context.Context, skill *models.Skill, tagNames []string) error {
+	err := r.updateSkill(ctx, skill, func(tx *gorm.DB) error {
+		_, err := setSkillTagsByName(tx, skill.ID, tagNames)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	clearTagCache()
+	return nil
+}
+
+// updateSkill 在事务中更新技能并重建索引，withTx不为nil时在同一事务中执行额外的更新
+func (r *Repository) updateSkill(ctx context.Context, skill *models.Skill, withTx func(tx *gorm.DB) error) error {
 	// 检查 skill的 ResourceDir 是否存在, 如果不存在，随机4个字母 + 时间戳 作为目录名
 	if skill.ResourceDir == "" {
 		skill.ResourceDir = utils.GenerateRandomDirName()
@@ -87,6 +171,13 @@ func (r *Repository) UpdateSkill(ctx context.Context, skill *models.Skill) error
 
 	// 使用事务更新技能并重建分词索引
 	tx := r.db.WithContext(ctx).Begin()
+
+	// 第一次更新前保存原始内容作为基线修订
+	if err := r.ensureBaselineRevision(tx, skill.ID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	if err := tx.Save(skill).Error; err != nil {
 		tx.Rollback()
 		return err
@@ -104,39 +195,103 @@ func (r *Repository) UpdateSkill(ctx context.Context, skill *models.Skill) error
 		return err
 	}
 
-	return tx.Commit().Error
+	// 重建全文检索索引
+	if err := r.indexSkillFTS(tx, skill); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if withTx != nil {
+		if err := withTx(tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+	r.refreshSkillEmbedding(skill.ID)
+	r.notifySkillChange()
+	return nil
 }
 
 // DeleteSkill 删除技能（伪删除，进入回收站）
 func (r *Repository) DeleteSkill(ctx context.Context, id uint) error {
 	// 伪删除：设置 deleted_at 时间戳
-	return r.db.WithContext(ctx).Model(&models.Skill{}).Where("id = ?", id).Update("deleted_at", time.Now().UnixMilli()).Error
+	if err := r.db.WithContext(ctx).Model(&models.Skill{}).Where("id = ?", id).Update("deleted_at", time.Now().UnixMilli()).Error; err != nil {
+		return err
+	}
+	r.notifySkillChange()
+	return nil
 }
 
-// RestoreSkill 恢复回收站中的技能
+// RestoreSkill 恢复回收站中的技能，技能不存在或不在回收站中时返回 gorm.ErrRecordNotFound
 func (r *Repository) RestoreSkill(ctx context.Context, id uint) error {
 	// 恢复：清空 deleted_at 时间戳
-	return r.db.WithContext(ctx).Model(&models.Skill{}).Where("id = ?", id).Update("deleted_at", 0).Error
+	result := r.db.WithContext(ctx).Model(&models.Skill{}).Where("id = ? AND deleted_at > ?", id, 0).Update("deleted_at", 0)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	r.notifySkillChange()
+	return nil
 }
 
 // PermanentDeleteSkill 彻底删除技能
 func (r *Repository) PermanentDeleteSkill(ctx context.Context, id uint) error {
-	// 使用事务彻底删除技能及其分词索引
+	// 使用事务彻底删除技能及其标签关联、分词索引、全文检索索引、语义向量、修订历史、标签建议
 	tx := r.db.WithContext(ctx).Begin()
 
+	// 删除标签关联
+	if err := tx.Where("skill_id = ?", id).Delete(&models.SkillTag{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	// 删除分词索引
 	if err := tx.Where("skill_id = ?", id).Delete(&models.SkillToken{}).Error; err != nil {
 		tx.Rollback()
 		return err
 	}
 
+	// 删除全文检索索引
+	if err := r.deleteSkillFTS(tx, id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// 删除语义向量
+	if err := tx.Where("skill_id = ?", id).Delete(&models.SkillEmbedding{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// 删除修订历史
+	if err := tx.Where("skill_id = ?", id).Delete(&models.SkillRevision{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// 删除标签建议
+	if err := tx.Where("skill_id = ?", id).Delete(&models.SkillTagSuggestion{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	// 彻底删除技能
 	if err := tx.Unscoped().Delete(&models.Skill{}, id).Error; err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	return tx.Commit().Error
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+	r.notifySkillChange()
+	return nil
 }
 
 // buildSkillTokens 为技能建立分词索引
@@ -150,16 +305,13 @@ func (r *Repository) PermanentDeleteSkill(ctx context.Context, id uint) error {
 //
 //	error: 错误信息
 func (r *Repository) buildSkillTokens(tx *gorm.DB, skillID uint, text string) error {
-	// 对文本进行分词
-	tokens := seg.Cut(text, true)
+	// 对文本进行分词，过滤停用词并将同义词归一为标准词
+	tokens := analyzeTokens(seg.Cut(text, true))
 
 	// 去重后的分词集合
 	termMap := make(map[string]bool)
 	for _, token := range tokens {
-		token = strings.TrimSpace(token)
-		if token != "" {
-			termMap[strings.ToLower(token)] = true
-		}
+		termMap[token] = true
 	}
 
 	// 批量插入分词索引
@@ -187,17 +339,8 @@ func (r *Repository) buildSkillTokens(tx *gorm.DB, skillID uint, text string) er
 //	[]models.Skill: 匹配的技能列表，按匹配度降序排列
 //	error: 错误信息
 func (r *Repository) SearchSkillsByTokens(ctx context.Context, keyword string) ([]models.Skill, error) {
-	// 先转小写再分词，确保大小写不敏感
-	queryTokens := seg.Cut(strings.ToLower(keyword), true)
-
-	// 过滤空白分词
-	var terms []string
-	for _, token := range queryTokens {
-		token = strings.TrimSpace(token)
-		if token != "" {
-			terms = append(terms, strings.ToLower(token))
-		}
-	}
+	// 先转小写再分词，确保大小写不敏感；与建立索引时一样过滤停用词并归一同义词
+	terms := analyzeTokens(seg.Cut(strings.ToLower(keyword), true))
 
 	if len(terms) == 0 {
 		return r.ListAllSkills(ctx)
@@ -206,7 +349,7 @@ func (r *Repository) SearchSkillsByTokens(ctx context.Context, keyword string) (
 	// 使用 SQL 查询匹配的技能，按匹配分词数量降序排列
 	var skills []models.Skill
 	err := r.db.WithContext(ctx).
-		Preload("Tags").
+		Preload("Tags", LiveTags).
 		Select("skills.*, COUNT(skill_tokens.term) as match_score").
 		Joins("JOIN skill_tokens ON skill_tokens.skill_id = skills.id").
 		Where("skill_tokens.term IN ?", terms).
@@ -216,3 +359,76 @@ func (r *Repository) SearchSkillsByTokens(ctx context.Context, keyword string) (
 
 	return skills, err
 }
+
+// SearchSkillTokenHits 根据关键词分词搜索未删除的技能并给出得分，按得分降序排列
+// 得分为命中的查询分词占全部查询分词的比例，关键词没有有效分词时返回空列表
+func (r *Repository) SearchSkillTokenHits(ctx context.Context, keyword string) ([]SkillSearchHit, error) {
+	terms := queryTerms(keyword)
+	if len(terms) == 0 {
+		return []SkillSearchHit{}, nil
+	}
+	counts, err := r.countMatchedTerms(ctx, terms)
+	if err != nil || len(counts) == 0 {
+		return []SkillSearchHit{}, err
+	}
+
+	ids := make([]uint, 0, len(counts))
+	for id := range counts {
+		ids = append(ids, id)
+	}
+	var skills []models.Skill
+	if err := r.db.WithContext(ctx).Preload("Tags", LiveTags).Where("id IN ?", ids).Find(&skills).Error; err != nil {
+		return nil, err
+	}
+
+	hits := make([]SkillSearchHit, 0, len(skills))
+	for _, skill := range skills {
+		hits = append(hits, SkillSearchHit{Skill: skill, Score: float64(counts[skill.ID]) / float64(len(terms))})
+	}
+	slices.SortFunc(hits, func(a, b SkillSearchHit) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), cmp.Compare(a.Skill.ID, b.Skill.ID))
+	})
+	return hits, nil
+}
+
+// queryTerms 关键词转小写后分词，过滤停用词、归一同义词并去重
+func queryTerms(keyword string) []string {
+	return slices.Compact(slices.Sorted(slices.Values(analyzeTokens(seg.Cut(strings.ToLower(keyword), true)))))
+}
+
+// countMatchedTerms 统计每个未删除技能命中的查询分词数
+func (r *Repository) countMatchedTerms(ctx context.Context, terms []string) (map[uint]int, error) {
+	type tokenRow struct {
+		SkillID uint
+		Matched int
+	}
+	var rows []tokenRow
+	if err := r.db.WithContext(ctx).Model(&models.SkillToken{}).
+		Select("skill_tokens.skill_id, COUNT(DISTINCT skill_tokens.term) AS matched").
+		Joins("JOIN skills ON skills.id = skill_tokens.skill_id").
+		Where("skills.deleted_at = 0 AND skill_tokens.term IN ?", terms).
+		Group("skill_tokens.skill_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	counts := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		counts[row.SkillID] = row.Matched
+	}
+	return counts, nil
+}
+
+// WhereSkillMetadata 为技能查询添加元数据筛选条件，多个条件之间为且的关系
+// 元数据不是合法JSON（迁移前的旧数据）的技能视为不匹配
+func WhereSkillMetadata(query *gorm.DB, filters []models.SkillMetadataFilter) *gorm.DB {
+	for _, filter := range filters {
+		if filter.MatchValue {
+			query = query.Where("LOWER(CASE WHEN json_valid(skills.metadata) THEN json_extract(skills.metadata, ?) END) = LOWER(?)",
+				filter.JSONPath(), filter.Value)
+		} else {
+			query = query.Where("CASE WHEN json_valid(skills.metadata) THEN json_type(skills.metadata, ?) END IS NOT NULL",
+				filter.JSONPath())
+		}
+	}
+	return query
+}
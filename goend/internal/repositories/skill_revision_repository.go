@@ -0,0 +1,130 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"sort"
+	"time"
+
+	"aiflow/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// CreateSkillRevision 按技能当前内容（含标签）保存一份修订快照
+// 内容与最新修订相同时不重复保存（回滚除外），返回最新修订和是否新建
+func (r *Repository) CreateSkillRevision(ctx context.Context, skillID uint, source, note string) (*models.SkillRevision, bool, error) {
+	var skill models.Skill
+	if err := r.db.WithContext(ctx).Preload("Tags", LiveTags).First(&skill, skillID).Error; err != nil {
+		return nil, false, err
+	}
+	revision := newSkillRevision(&skill, source, note)
+
+	latest, err := r.GetLatestSkillRevision(ctx, skillID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+	if latest != nil {
+		if source != models.SkillRevisionSourceRollback && sameSkillRevisionContent(latest, revision) {
+			return latest, false, nil
+		}
+		revision.Revision = latest.Revision + 1
+	}
+
+	if err := r.db.WithContext(ctx).Create(revision).Error; err != nil {
+		return nil, false, err
+	}
+	return revision, true, nil
+}
+
+// ensureBaselineRevision 技能还没有任何修订时，将数据库中的当前内容保存为基线修订
+// 在覆盖技能之前调用，避免修订功能上线前创建的技能在第一次更新时丢失原始内容
+func (r *Repository) ensureBaselineRevision(tx *gorm.DB, skillID uint) error {
+	var count int64
+	if err := tx.Model(&models.SkillRevision{}).Where("skill_id = ?", skillID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	var skill models.Skill
+	if err := tx.Preload("Tags", LiveTags).First(&skill, skillID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	return tx.Create(newSkillRevision(&skill, models.SkillRevisionSourceBaseline, "")).Error
+}
+
+// ListSkillRevisions 获取技能的修订列表（不含详细说明），按修订号倒序
+func (r *Repository) ListSkillRevisions(ctx context.Context, skillID uint) ([]models.SkillRevision, error) {
+	var revisions []models.SkillRevision
+	err := r.db.WithContext(ctx).Omit("detail").Where("skill_id = ?", skillID).Order("revision DESC").Find(&revisions).Error
+	return revisions, err
+}
+
+// GetSkillRevision 获取技能的指定修订
+func (r *Repository) GetSkillRevision(ctx context.Context, skillID uint, revision int) (*models.SkillRevision, error) {
+	var result models.SkillRevision
+	err := r.db.WithContext(ctx).Where("skill_id = ? AND revision = ?", skillID, revision).First(&result).Error
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetLatestSkillRevision 获取技能的最新修订
+func (r *Repository) GetLatestSkillRevision(ctx context.Context, skillID uint) (*models.SkillRevision, error) {
+	var result models.SkillRevision
+	err := r.db.WithContext(ctx).Where("skill_id = ?", skillID).Order("revision DESC").First(&result).Error
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// newSkillRevision 根据技能生成修订快照（修订号为1，由调用方调整）
+func newSkillRevision(skill *models.Skill, source, note string) *models.SkillRevision {
+	tagNames := make([]string, 0, len(skill.Tags))
+	for _, tag := range skill.Tags {
+		tagNames = append(tagNames, tag.Name)
+	}
+	sort.Strings(tagNames)
+
+	return &models.SkillRevision{
+		SkillID:       skill.ID,
+		Revision:      1,
+		Source:        source,
+		Note:          note,
+		Name:          skill.Name,
+		ResourceDir:   skill.ResourceDir,
+		Description:   skill.Description,
+		License:       skill.License,
+		Version:       skill.Version,
+		Compatibility: skill.Compatibility,
+		Metadata:      skill.Metadata,
+		AllowedTools:  skill.AllowedTools,
+		Detail:        skill.Detail,
+		Tags:          tagNames,
+		CreatedAt:     time.Now().UnixMilli(),
+	}
+}
+
+// sameSkillRevisionContent 判断两个修订的技能内容是否相同（不比较来源和时间）
+func sameSkillRevisionContent(a, b *models.SkillRevision) bool {
+	if a.Name != b.Name || a.ResourceDir != b.ResourceDir || a.Description != b.Description ||
+		a.License != b.License || a.Version != b.Version || a.Compatibility != b.Compatibility ||
+		!maps.Equal(a.Metadata, b.Metadata) || a.AllowedTools != b.AllowedTools || a.Detail != b.Detail ||
+		len(a.Tags) != len(b.Tags) {
+		return false
+	}
+	for i := range a.Tags {
+		if a.Tags[i] != b.Tags[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,43 @@
+package repositories
+
+import (
+	"context"
+
+	"aiflow/internal/models"
+)
+
+// GetSkillSyncStateByFolder 根据技能文件夹名获取同步状态
+func (r *Repository) GetSkillSyncStateByFolder(ctx context.Context, folder string) (*models.SkillSyncState, error) {
+	var state models.SkillSyncState
+	err := r.db.WithContext(ctx).Where("folder = ?", folder).First(&state).Error
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// GetSkillSyncStateByID 根据ID获取同步状态
+func (r *Repository) GetSkillSyncStateByID(ctx context.Context, id uint) (*models.SkillSyncState, error) {
+	var state models.SkillSyncState
+	err := r.db.WithContext(ctx).First(&state, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// ListSkillSyncStates 获取同步状态列表，status为空时返回全部，按文件夹名排序
+func (r *Repository) ListSkillSyncStates(ctx context.Context, status string) ([]models.SkillSyncState, error) {
+	var states []models.SkillSyncState
+	query := r.db.WithContext(ctx).Model(&models.SkillSyncState{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("folder ASC").Find(&states).Error
+	return states, err
+}
+
+// SaveSkillSyncState 保存同步状态，ID为0时新建
+func (r *Repository) SaveSkillSyncState(ctx context.Context, state *models.SkillSyncState) error {
+	return r.db.WithContext(ctx).Save(state).Error
+}
@@ -0,0 +1,121 @@
+package repositories
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"aiflow/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ListTagAliases 获取标签的所有别名，按别名排序
+func (r *Repository) ListTagAliases(ctx context.Context, tagID uint) ([]string, error) {
+	aliases := []string{}
+	err := r.db.WithContext(ctx).Model(&models.TagAlias{}).
+		Where("tag_id = ?", tagID).Order("alias").
+		Pluck("alias", &aliases).Error
+	return aliases, err
+}
+
+// ListAllTagAliases 获取所有标签的别名，键为标签ID，别名按名称排序
+func (r *Repository) ListAllTagAliases(ctx context.Context) (map[uint][]string, error) {
+	var rows []models.TagAlias
+	if err := r.db.WithContext(ctx).Order("alias").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	aliases := make(map[uint][]string)
+	for _, row := range rows {
+		aliases[row.TagID] = append(aliases[row.TagID], row.Alias)
+	}
+	return aliases, nil
+}
+
+// MergeTags 将多个标签合并到目标标签，在一个事务中完成
+// 被合并标签关联的技能改为关联目标标签，被合并标签的名称及其原有别名成为目标标签的别名，
+// 子标签移到目标标签下（子标签是目标标签的祖先时移到被合并标签的父标签下，避免形成循环），最后删除被合并标签
+// 参数:
+//
+//	ctx: 上下文
+//	targetID: 保留的目标标签ID
+//	sourceIDs: 被合并的标签ID，不能包含目标标签
+//
+// 返回:
+//
+//	error: 错误信息
+func (r *Repository) MergeTags(ctx context.Context, targetID uint, sourceIDs []uint) error {
+	timestamp := time.Now().UnixMilli()
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, sourceID := range sourceIDs {
+			var source models.Tag
+			if err := tx.Select("id", "name", "parent_id").First(&source, sourceID).Error; err != nil {
+				return err
+			}
+
+			// 技能关联改到目标标签，已关联目标标签的技能跳过
+			if err := tx.Exec(`INSERT OR IGNORE INTO skill_tags (skill_id, tag_id)
+				SELECT skill_id, ? FROM skill_tags WHERE tag_id = ?`, targetID, sourceID).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("tag_id = ?", sourceID).Delete(&models.SkillTag{}).Error; err != nil {
+				return err
+			}
+
+			// 子标签移到目标标签下，目标标签的祖先（含目标标签自身）改为上移一层
+			ancestorIDs, err := listTagAncestorIDs(tx, targetID)
+			if err != nil {
+				return err
+			}
+			var childIDs []uint
+			if err := tx.Model(&models.Tag{}).Where("parent_id = ?", sourceID).Pluck("id", &childIDs).Error; err != nil {
+				return err
+			}
+			for _, childID := range childIDs {
+				parentID := targetID
+				if slices.Contains(ancestorIDs, childID) {
+					parentID = source.ParentID
+				}
+				if err := tx.Model(&models.Tag{}).Where("id = ?", childID).
+					Updates(map[string]any{"parent_id": parentID, "updated_at": timestamp}).Error; err != nil {
+					return err
+				}
+			}
+
+			// 原有别名和被合并标签的名称都指向目标标签
+			if err := tx.Model(&models.TagAlias{}).Where("tag_id = ?", sourceID).Update("tag_id", targetID).Error; err != nil {
+				return err
+			}
+			if err := tx.Save(&models.TagAlias{Alias: source.Name, TagID: targetID, CreatedAt: timestamp}).Error; err != nil {
+				return err
+			}
+
+			// 被合并标签的建议直接删除，重新生成时会按目标标签建议
+			if err := tx.Where("tag_id = ?", sourceID).Delete(&models.SkillTagSuggestion{}).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Delete(&models.Tag{}, sourceID).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.Tag{}).Where("id = ?", targetID).Update("updated_at", timestamp).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	clearTagCache()
+	return nil
+}
+
+// listTagAncestorIDs 获取标签及其所有祖先标签的ID
+func listTagAncestorIDs(tx *gorm.DB, id uint) ([]uint, error) {
+	var ids []uint
+	err := tx.Raw(`WITH RECURSIVE chain(id, parent_id) AS (
+		SELECT id, parent_id FROM tags WHERE id = ?
+		UNION
+		SELECT tags.id, tags.parent_id FROM tags JOIN chain ON tags.id = chain.parent_id
+	) SELECT id FROM chain`, id).Scan(&ids).Error
+	return ids, err
+}
@@ -2,11 +2,16 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"aiflow/internal/cache"
 	"aiflow/internal/models"
+
+	"gorm.io/gorm"
 )
 
 // 缓存相关常量定义
@@ -40,6 +45,11 @@ func clearTagCache() {
 	tagCache.DeleteByPrefix("tag:")
 }
 
+// LiveTags 预加载技能标签的条件，排除回收站中的标签，用法为 Preload("Tags", LiveTags)
+func LiveTags(db *gorm.DB) *gorm.DB {
+	return db.Where("deleted_at = ?", 0)
+}
+
 // Tag CRUD 操作
 
 // CreateTag 创建标签
@@ -71,7 +81,7 @@ func (r *Repository) GetTagByID(ctx context.Context, id uint) (*models.Tag, erro
 
 	// 缓存未命中，查数据库
 	var tag models.Tag
-	err := r.db.WithContext(ctx).Preload("Skills").First(&tag, id).Error
+	err := r.db.WithContext(ctx).Preload("Skills").Where("deleted_at = ?", 0).First(&tag, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -81,20 +91,51 @@ func (r *Repository) GetTagByID(ctx context.Context, id uint) (*models.Tag, erro
 	return &tag, nil
 }
 
-// GetTagByName 根据名称获取标签
+// GetTagByName 根据名称获取标签，名称是已合并标签的别名时返回合并后的标签，不含回收站中的标签
 func (r *Repository) GetTagByName(ctx context.Context, name string) (*models.Tag, error) {
 	var tag models.Tag
-	err := r.db.WithContext(ctx).Where("name = ?", name).Preload("Skills").First(&tag).Error
-	if err != nil {
+	if err := r.findTagByName(r.db.WithContext(ctx).Preload("Skills"), name, &tag); err != nil {
 		return nil, err
 	}
 	return &tag, nil
 }
 
-// ListTags 获取所有标签
+// ResolveTagNames 按名称或别名查找未删除的标签，不预加载技能
+// 返回找到的标签（与名称顺序一致）和不存在的名称
+func (r *Repository) ResolveTagNames(ctx context.Context, names []string) ([]models.Tag, []string, error) {
+	tags := make([]models.Tag, 0, len(names))
+	var missing []string
+	for _, name := range names {
+		var tag models.Tag
+		err := r.findTagByName(r.db.WithContext(ctx), name, &tag)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			missing = append(missing, name)
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, missing, nil
+}
+
+// findTagByName 按名称查找未删除的标签，名称不存在时按别名查找
+func (r *Repository) findTagByName(query *gorm.DB, name string, tag *models.Tag) error {
+	err := query.Session(&gorm.Session{}).Where("name = ? AND deleted_at = ?", name, 0).First(tag).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = query.Session(&gorm.Session{}).
+			Where("id = (?)", r.db.Model(&models.TagAlias{}).Select("tag_id").Where("alias = ?", name)).
+			Where("deleted_at = ?", 0).
+			First(tag).Error
+	}
+	return err
+}
+
+// ListTags 获取所有标签，不含回收站中的标签
 func (r *Repository) ListTags(ctx context.Context) ([]models.Tag, error) {
 	var tags []models.Tag
-	err := r.db.WithContext(ctx).Find(&tags).Error
+	err := r.db.WithContext(ctx).Where("deleted_at = ?", 0).Find(&tags).Error
 	return tags, err
 }
 
@@ -121,14 +162,14 @@ func (r *Repository) ListTagsWithPagination(ctx context.Context, page, pageSize
 
 	// 查询总数
 	var total int64
-	err := r.db.WithContext(ctx).Model(&models.Tag{}).Count(&total).Error
+	err := r.db.WithContext(ctx).Model(&models.Tag{}).Where("deleted_at = ?", 0).Count(&total).Error
 	if err != nil {
 		return nil, 0, err
 	}
 
 	// 查询标签列表
 	var tags []models.Tag
-	err = r.db.WithContext(ctx).Offset(offset).Limit(pageSize).Find(&tags).Error
+	err = r.db.WithContext(ctx).Where("deleted_at = ?", 0).Offset(offset).Limit(pageSize).Find(&tags).Error
 	if err != nil {
 		return nil, 0, err
 	}
@@ -156,15 +197,45 @@ func (r *Repository) UpdateTag(ctx context.Context, tag *models.Tag) error {
 	return nil
 }
 
-// DeleteTag 删除标签
+// DeleteTag 删除标签（伪删除，进入回收站）
+// 标签的子标签移到被删除标签的父标签下，不会一并删除；技能关联、别名和标签建议保留到彻底删除时，恢复后重新生效
 func (r *Repository) DeleteTag(ctx context.Context, id uint) error {
-	// 先删除关联关系
-	if err := r.db.WithContext(ctx).Where("tag_id = ?", id).Delete(&models.SkillTag{}).Error; err != nil {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var tag models.Tag
+		if err := tx.Select("id", "parent_id").Where("deleted_at = ?", 0).First(&tag, id).Error; err != nil {
+			return err
+		}
+
+		// 子标签上移一层
+		timestamp := time.Now().UnixMilli()
+		if err := tx.Model(&models.Tag{}).Where("parent_id = ?", id).
+			Updates(map[string]any{"parent_id": tag.ParentID, "updated_at": timestamp}).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Tag{}).Where("id = ?", id).Update("deleted_at", timestamp).Error
+	})
+	if err != nil {
 		return err
 	}
 
-	// 再删除标签
-	if err := r.db.WithContext(ctx).Delete(&models.Tag{}, id).Error; err != nil {
+	// 清除相关缓存
+	tagCache.Delete(tagCacheKey(id))
+	clearTagCache()
+	return nil
+}
+
+// RestoreTag 恢复回收站中的标签
+// 原父标签已不存在或也在回收站中时恢复为顶层标签，删除时移走的子标签不会移回
+func (r *Repository) RestoreTag(ctx context.Context, id uint) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var tag models.Tag
+		if err := tx.Where("deleted_at > ?", 0).First(&tag, id).Error; err != nil {
+			return err
+		}
+		return restoreTag(tx, &tag)
+	})
+	if err != nil {
 		return err
 	}
 
@@ -174,6 +245,173 @@ func (r *Repository) DeleteTag(ctx context.Context, id uint) error {
 	return nil
 }
 
+// GetTrashedTagByName 获取回收站中指定名称的标签
+func (r *Repository) GetTrashedTagByName(ctx context.Context, name string) (*models.Tag, error) {
+	var tag models.Tag
+	if err := r.db.WithContext(ctx).Where("name = ? AND deleted_at > ?", name, 0).First(&tag).Error; err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// restoreTag 清除标签的删除时间，原父标签不可用时移到顶层
+func restoreTag(tx *gorm.DB, tag *models.Tag) error {
+	if tag.ParentID > 0 {
+		var count int64
+		if err := tx.Model(&models.Tag{}).Where("id = ? AND deleted_at = ?", tag.ParentID, 0).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			tag.ParentID = 0
+		}
+	}
+	tag.DeletedAt = 0
+	tag.UpdatedAt = time.Now().UnixMilli()
+	return tx.Model(&models.Tag{}).Where("id = ?", tag.ID).
+		Updates(map[string]any{"parent_id": tag.ParentID, "deleted_at": 0, "updated_at": tag.UpdatedAt}).Error
+}
+
+// PermanentDeleteTag 彻底删除回收站中的标签，同时删除其技能关联、别名和标签建议
+func (r *Repository) PermanentDeleteTag(ctx context.Context, id uint) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var tag models.Tag
+		if err := tx.Select("id", "parent_id").Where("deleted_at > ?", 0).First(&tag, id).Error; err != nil {
+			return err
+		}
+
+		// 删除后挂到该标签下的子标签上移一层
+		if err := tx.Model(&models.Tag{}).Where("parent_id = ?", id).
+			Updates(map[string]any{"parent_id": tag.ParentID, "updated_at": time.Now().UnixMilli()}).Error; err != nil {
+			return err
+		}
+
+		// 删除关联关系、别名和标签建议
+		if err := tx.Where("tag_id = ?", id).Delete(&models.SkillTag{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("tag_id = ?", id).Delete(&models.TagAlias{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("tag_id = ?", id).Delete(&models.SkillTagSuggestion{}).Error; err != nil {
+			return err
+		}
+
+		// 再删除标签
+		return tx.Delete(&models.Tag{}, id).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	// 清除相关缓存
+	tagCache.Delete(tagCacheKey(id))
+	clearTagCache()
+	return nil
+}
+
+// ListTagDescendantIDs 获取标签及其所有后代标签的ID，第一个为标签自身
+func (r *Repository) ListTagDescendantIDs(ctx context.Context, id uint) ([]uint, error) {
+	return listTagDescendantIDs(r.db.WithContext(ctx), id)
+}
+
+// listTagDescendantIDs 在指定连接或事务中获取标签及其所有后代标签的ID
+func listTagDescendantIDs(db *gorm.DB, id uint) ([]uint, error) {
+	// UNION 会去重，即使数据中出现循环也能结束
+	var ids []uint
+	err := db.Raw(`WITH RECURSIVE subtree(id, depth) AS (
+		SELECT ?, 0
+		UNION
+		SELECT tags.id, subtree.depth + 1 FROM tags JOIN subtree ON tags.parent_id = subtree.id WHERE tags.deleted_at = 0
+	) SELECT id FROM subtree GROUP BY id ORDER BY MIN(depth), id`, id).Scan(&ids).Error
+	return ids, err
+}
+
+// ErrTagCycle 移动标签的目标父标签是标签自身或其后代
+var ErrTagCycle = errors.New("不能移动到标签自身或其子标签下")
+
+// MoveTag 将标签连同其子标签移动到新的父标签下，parentID为0表示移到顶层
+// 只修改标签的 parent_id，技能与标签的关联保持不变
+// 循环检查和更新在同一事务中完成，并发移动也不会形成循环；会形成循环时返回 ErrTagCycle
+func (r *Repository) MoveTag(ctx context.Context, id, parentID uint) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if parentID > 0 {
+			descendantIDs, err := listTagDescendantIDs(tx, id)
+			if err != nil {
+				return err
+			}
+			if slices.Contains(descendantIDs, parentID) {
+				return ErrTagCycle
+			}
+		}
+		return tx.Model(&models.Tag{}).Where("id = ?", id).
+			Updates(map[string]any{"parent_id": parentID, "updated_at": time.Now().UnixMilli()}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	// 清除相关缓存
+	tagCache.Delete(tagCacheKey(id))
+	clearTagCache()
+	return nil
+}
+
+// ListSkillIDsByTag 获取每个未删除标签直接关联的未删除技能ID，没有技能的标签不在结果中
+func (r *Repository) ListSkillIDsByTag(ctx context.Context) (map[uint][]uint, error) {
+	var rows []models.SkillTag
+	err := r.db.WithContext(ctx).Model(&models.SkillTag{}).
+		Select("skill_tags.skill_id, skill_tags.tag_id").
+		Joins("JOIN skills ON skills.id = skill_tags.skill_id").
+		Joins("JOIN tags ON tags.id = skill_tags.tag_id").
+		Where("skills.deleted_at = ? AND tags.deleted_at = ?", 0, 0).
+		Order("skill_tags.tag_id, skill_tags.skill_id").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	skillIDs := make(map[uint][]uint)
+	for _, row := range rows {
+		skillIDs[row.TagID] = append(skillIDs[row.TagID], row.SkillID)
+	}
+	return skillIDs, nil
+}
+
+// SkillTagFilter 按标签筛选技能的条件
+type SkillTagFilter struct {
+	// TagGroups 要匹配的标签，每组对应一个查询的标签，包含子标签时为该标签子树中所有标签的ID
+	TagGroups [][]uint
+	// MatchAll 为true时要求技能匹配每一组，否则匹配任一组即可
+	MatchAll bool
+	// ExcludeTagIDs 关联其中任一标签的技能被排除
+	ExcludeTagIDs []uint
+}
+
+// ListSkillsByTagFilter 按标签筛选未删除的技能，筛选在SQL中完成，每个技能只出现一次，按ID排序
+func (r *Repository) ListSkillsByTagFilter(ctx context.Context, filter SkillTagFilter) ([]models.Skill, error) {
+	skills := []models.Skill{}
+	if len(filter.TagGroups) == 0 {
+		return skills, nil
+	}
+	skillsWithTags := func(tagIDs []uint) *gorm.DB {
+		return r.db.Model(&models.SkillTag{}).Select("skill_id").Where("tag_id IN ?", tagIDs)
+	}
+
+	query := r.db.WithContext(ctx).Preload("Tags", LiveTags).Where("deleted_at = ?", 0)
+	if filter.MatchAll {
+		for _, group := range filter.TagGroups {
+			query = query.Where("id IN (?)", skillsWithTags(group))
+		}
+	} else {
+		query = query.Where("id IN (?)", skillsWithTags(slices.Concat(filter.TagGroups...)))
+	}
+	if len(filter.ExcludeTagIDs) > 0 {
+		query = query.Where("id NOT IN (?)", skillsWithTags(filter.ExcludeTagIDs))
+	}
+	err := query.Order("id").Find(&skills).Error
+	return skills, err
+}
+
 // AddTagToSkill 为技能添加标签
 func (r *Repository) AddTagToSkill(ctx context.Context, skillID, tagID uint) error {
 	skillTag := &models.SkillTag{
@@ -183,14 +421,81 @@ func (r *Repository) AddTagToSkill(ctx context.Context, skillID, tagID uint) err
 	return r.db.WithContext(ctx).Create(skillTag).Error
 }
 
+// SetSkillTagsByName 按名称设置技能的标签，替换原有标签
+// 名称是已合并标签的别名时使用合并后的标签，回收站中的同名标签会被恢复，不存在的标签自动创建，解析到同一标签的名称只关联一次
+// 返回技能关联的标签，顺序与名称一致
+func (r *Repository) SetSkillTagsByName(ctx context.Context, skillID uint, names []string) ([]models.Tag, error) {
+	var tags []models.Tag
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		tags, err = setSkillTagsByName(tx, skillID, names)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	clearTagCache()
+	return tags, nil
+}
+
+// setSkillTagsByName 在事务中按名称替换技能的标签，返回关联后的标签
+func setSkillTagsByName(tx *gorm.DB, skillID uint, names []string) ([]models.Tag, error) {
+	tags := []models.Tag{}
+	if err := tx.Where("skill_id = ?", skillID).Delete(&models.SkillTag{}).Error; err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		tag, err := findOrCreateTag(tx, name)
+		if err != nil {
+			return nil, fmt.Errorf("创建标签'%s'失败: %w", name, err)
+		}
+		if slices.ContainsFunc(tags, func(t models.Tag) bool { return t.ID == tag.ID }) {
+			continue
+		}
+		if err := tx.Create(&models.SkillTag{SkillID: skillID, TagID: tag.ID}).Error; err != nil {
+			return nil, fmt.Errorf("关联标签'%s'到技能失败: %w", name, err)
+		}
+		tags = append(tags, *tag)
+	}
+	return tags, nil
+}
+
+// findOrCreateTag 按名称或别名查找标签，同名标签在回收站中时将其恢复，都不存在时创建顶层标签
+func findOrCreateTag(tx *gorm.DB, name string) (*models.Tag, error) {
+	var tag models.Tag
+	err := tx.Where("name = ?", name).First(&tag).Error
+	if err == nil && tag.DeletedAt > 0 {
+		err = restoreTag(tx, &tag)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = tx.Where("id = (?)", tx.Model(&models.TagAlias{}).Select("tag_id").Where("alias = ?", name)).
+			Where("deleted_at = ?", 0).First(&tag).Error
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		timestamp := time.Now().UnixMilli()
+		tag = models.Tag{Name: name, CreatedAt: timestamp, UpdatedAt: timestamp}
+		err = tx.Create(&tag).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
 // RemoveTagFromSkill 从技能中移除标签
 func (r *Repository) RemoveTagFromSkill(ctx context.Context, skillID, tagID uint) error {
 	return r.db.WithContext(ctx).Where("skill_id = ? AND tag_id = ?", skillID, tagID).Delete(&models.SkillTag{}).Error
 }
 
-// GetTagsBySkillID 获取技能的所有标签
+// GetTagsBySkillID 获取技能的所有标签，不含回收站中的标签
 func (r *Repository) GetTagsBySkillID(ctx context.Context, skillID uint) ([]models.Tag, error) {
 	var tags []models.Tag
-	err := r.db.WithContext(ctx).Joins("JOIN skill_tags ON skill_tags.tag_id = tags.id").Where("skill_tags.skill_id = ?", skillID).Find(&tags).Error
+	err := r.db.WithContext(ctx).Joins("JOIN skill_tags ON skill_tags.tag_id = tags.id").
+		Where("skill_tags.skill_id = ? AND tags.deleted_at = ?", skillID, 0).Find(&tags).Error
 	return tags, err
 }
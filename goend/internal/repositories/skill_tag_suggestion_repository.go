@@ -0,0 +1,409 @@
+package repositories
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"aiflow/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// 标签建议的参数
+const (
+	// tagSuggestionMinScore 建议标签的最低综合得分
+	tagSuggestionMinScore = 0.3
+	// tagSuggestionMaxPerSkill 每个技能最多建议的标签数
+	tagSuggestionMaxPerSkill = 5
+	// tagSuggestionMaxTerms 建议依据中最多列出的分词数
+	tagSuggestionMaxTerms = 5
+)
+
+// TagCandidate 为技能建议的标签
+type TagCandidate struct {
+	Tag models.Tag
+	// Score 综合得分，名称得分和共现得分任一较高即可得到较高的综合得分：1-(1-名称得分)(1-共现得分)
+	Score float64
+	// NameScore 标签名称（或别名）的分词出现在技能分词中的比例的平方，部分命中时降权
+	NameScore float64
+	// CooccurrenceScore 技能的分词在已打标签的技能中与该标签同时出现的程度，按分词的逆文档频率加权平均
+	CooccurrenceScore float64
+	// NameTerms 命中的标签名称分词
+	NameTerms []string
+	// RelatedTerms 与该标签同时出现最多的技能分词
+	RelatedTerms []string
+}
+
+// Reason 建议依据的说明
+func (c TagCandidate) Reason() string {
+	var parts []string
+	if len(c.NameTerms) > 0 {
+		parts = append(parts, "名称匹配: "+strings.Join(c.NameTerms, ", "))
+	}
+	if len(c.RelatedTerms) > 0 {
+		parts = append(parts, "相关分词: "+strings.Join(c.RelatedTerms, ", "))
+	}
+	return strings.Join(parts, "；")
+}
+
+// TagSuggestionView 标签建议及其技能、标签名称
+type TagSuggestionView struct {
+	models.SkillTagSuggestion
+	SkillName string
+	TagName   string
+}
+
+// tagSuggestionModel 计算标签建议所需的统计数据
+type tagSuggestionModel struct {
+	tags []models.Tag
+	// nameTerms 每个标签的名称及别名各自的分词
+	nameTerms map[uint][][]string
+	// termSkills 每个分词出现在多少个已打标签的技能中
+	termSkills map[string]int
+	// termTags 每个分词与每个标签同时出现的技能数
+	termTags map[string]map[uint]int
+	// taggedSkills 已打标签的技能数
+	taggedSkills int
+}
+
+// isSuggestionTerm 判断分词是否参与标签建议，不含字母和数字的分词（如连字符、标点）不参与
+func isSuggestionTerm(term string) bool {
+	return strings.IndexFunc(term, func(c rune) bool { return unicode.IsLetter(c) || unicode.IsNumber(c) }) >= 0
+}
+
+// suggestionTerms 过滤出参与标签建议的分词
+func suggestionTerms(terms []string) []string {
+	return slices.DeleteFunc(terms, func(term string) bool { return !isSuggestionTerm(term) })
+}
+
+// loadTagSuggestionModel 从已打标签的未删除技能的分词和标签统计共现关系
+func (r *Repository) loadTagSuggestionModel(ctx context.Context) (*tagSuggestionModel, error) {
+	tags, err := r.ListTags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var aliases []models.TagAlias
+	if err := r.db.WithContext(ctx).Find(&aliases).Error; err != nil {
+		return nil, err
+	}
+	tagSkills, err := r.ListSkillIDsByTag(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	model := &tagSuggestionModel{
+		tags:       tags,
+		nameTerms:  make(map[uint][][]string, len(tags)),
+		termSkills: make(map[string]int),
+		termTags:   make(map[string]map[uint]int),
+	}
+	for _, tag := range tags {
+		if terms := suggestionTerms(queryTerms(tag.Name)); len(terms) > 0 {
+			model.nameTerms[tag.ID] = append(model.nameTerms[tag.ID], terms)
+		}
+	}
+	for _, alias := range aliases {
+		if terms := suggestionTerms(queryTerms(alias.Alias)); len(terms) > 0 {
+			model.nameTerms[alias.TagID] = append(model.nameTerms[alias.TagID], terms)
+		}
+	}
+
+	skillTags := make(map[uint][]uint)
+	for tagID, skillIDs := range tagSkills {
+		for _, skillID := range skillIDs {
+			skillTags[skillID] = append(skillTags[skillID], tagID)
+		}
+	}
+	model.taggedSkills = len(skillTags)
+	if len(skillTags) == 0 {
+		return model, nil
+	}
+
+	var tokens []models.SkillToken
+	if err := r.db.WithContext(ctx).
+		Where("skill_id IN (?)", r.db.Model(&models.SkillTag{}).Select("skill_id")).
+		Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	for _, token := range tokens {
+		tagIDs, ok := skillTags[token.SkillID]
+		if !ok || !isSuggestionTerm(token.Term) {
+			continue
+		}
+		model.termSkills[token.Term]++
+		if model.termTags[token.Term] == nil {
+			model.termTags[token.Term] = make(map[uint]int)
+		}
+		for _, tagID := range tagIDs {
+			model.termTags[token.Term][tagID]++
+		}
+	}
+	return model, nil
+}
+
+// suggest 按技能的分词计算建议标签，跳过技能已有的标签，按综合得分降序排列
+func (m *tagSuggestionModel) suggest(terms []string, existing []uint) []TagCandidate {
+	termSet := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		termSet[term] = true
+	}
+
+	// 共现得分：P(标签|分词) 按逆文档频率加权平均，只统计在已打标签的技能中出现过的分词
+	// P(标签|分词) 的分母加1平滑，避免只出现过一次的分词给出过高的概率
+	var totalWeight float64
+	cooccurrence := make(map[uint]float64)
+	related := make(map[uint][]string)
+	for term := range termSet {
+		df := m.termSkills[term]
+		if df == 0 {
+			continue
+		}
+		weight := math.Log(1 + float64(m.taggedSkills)/float64(df))
+		totalWeight += weight
+		for tagID, count := range m.termTags[term] {
+			cooccurrence[tagID] += weight * float64(count) / float64(df+1)
+			related[tagID] = append(related[tagID], term)
+		}
+	}
+
+	var candidates []TagCandidate
+	for _, tag := range m.tags {
+		if slices.Contains(existing, tag.ID) {
+			continue
+		}
+		candidate := TagCandidate{Tag: tag}
+		if totalWeight > 0 {
+			candidate.CooccurrenceScore = cooccurrence[tag.ID] / totalWeight
+		}
+		for _, nameTerms := range m.nameTerms[tag.ID] {
+			var matched []string
+			for _, term := range nameTerms {
+				if termSet[term] {
+					matched = append(matched, term)
+				}
+			}
+			ratio := float64(len(matched)) / float64(len(nameTerms))
+			if score := ratio * ratio; score > candidate.NameScore {
+				candidate.NameScore = score
+				candidate.NameTerms = matched
+			}
+		}
+		candidate.Score = 1 - (1-candidate.NameScore)*(1-candidate.CooccurrenceScore)
+		if candidate.Score < tagSuggestionMinScore {
+			continue
+		}
+
+		// 相关分词按与该标签同时出现的比例排序
+		terms := related[tag.ID]
+		slices.SortFunc(terms, func(a, b string) int {
+			pa := float64(m.termTags[a][tag.ID]) / float64(m.termSkills[a])
+			pb := float64(m.termTags[b][tag.ID]) / float64(m.termSkills[b])
+			return cmp.Or(cmp.Compare(pb, pa), cmp.Compare(a, b))
+		})
+		candidate.RelatedTerms = terms[:min(len(terms), tagSuggestionMaxTerms)]
+		candidates = append(candidates, candidate)
+	}
+
+	slices.SortFunc(candidates, func(a, b TagCandidate) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), cmp.Compare(a.Tag.Name, b.Tag.Name))
+	})
+	if len(candidates) > tagSuggestionMaxPerSkill {
+		candidates = candidates[:tagSuggestionMaxPerSkill]
+	}
+	return candidates
+}
+
+// SuggestSkillTags 结合已打标签技能的分词与标签的共现关系、标签名称匹配，为技能计算建议标签
+// 参数:
+//
+//	ctx: 上下文
+//	skillIDs: 要计算的技能ID，为空时计算所有未删除且没有标签的技能
+//
+// 返回:
+//
+//	map[uint][]TagCandidate: 每个计算的技能的建议标签，按综合得分降序排列，没有建议时为空列表
+//	error: 错误信息
+func (r *Repository) SuggestSkillTags(ctx context.Context, skillIDs []uint) (map[uint][]TagCandidate, error) {
+	query := r.db.WithContext(ctx).Preload("Tags", LiveTags).Where("deleted_at = ?", 0)
+	if len(skillIDs) > 0 {
+		query = query.Where("id IN ?", skillIDs)
+	} else {
+		query = query.Where("id NOT IN (?)", r.db.Model(&models.SkillTag{}).Select("skill_tags.skill_id").
+			Joins("JOIN tags ON tags.id = skill_tags.tag_id").Where("tags.deleted_at = ?", 0))
+	}
+	var skills []models.Skill
+	if err := query.Find(&skills).Error; err != nil {
+		return nil, err
+	}
+
+	result := make(map[uint][]TagCandidate)
+	if len(skills) == 0 {
+		return result, nil
+	}
+	model, err := r.loadTagSuggestionModel(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, len(skills))
+	for i := range skills {
+		ids[i] = skills[i].ID
+	}
+	var tokens []models.SkillToken
+	if err := r.db.WithContext(ctx).Where("skill_id IN ?", ids).Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	skillTerms := make(map[uint][]string, len(skills))
+	for _, token := range tokens {
+		skillTerms[token.SkillID] = append(skillTerms[token.SkillID], token.Term)
+	}
+
+	for _, skill := range skills {
+		existing := make([]uint, len(skill.Tags))
+		for i, tag := range skill.Tags {
+			existing[i] = tag.ID
+		}
+		result[skill.ID] = model.suggest(suggestionTerms(skillTerms[skill.ID]), existing)
+	}
+	return result, nil
+}
+
+// SaveTagSuggestions 保存技能的建议标签，candidates为 SuggestSkillTags 的计算结果
+// 新的建议保存为待处理，已有的待处理建议更新得分和依据，不再建议的待处理建议删除；已采纳或已拒绝的建议保持不变
+func (r *Repository) SaveTagSuggestions(ctx context.Context, candidates map[uint][]TagCandidate) error {
+	if len(candidates) == 0 {
+		return nil
+	}
+	skillIDs := slices.Collect(maps.Keys(candidates))
+	timestamp := time.Now().UnixMilli()
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing []models.SkillTagSuggestion
+		if err := tx.Where("skill_id IN ?", skillIDs).Find(&existing).Error; err != nil {
+			return err
+		}
+		byKey := make(map[string]*models.SkillTagSuggestion, len(existing))
+		for i := range existing {
+			byKey[fmt.Sprintf("%d:%d", existing[i].SkillID, existing[i].TagID)] = &existing[i]
+		}
+
+		kept := make(map[uint]bool)
+		for skillID, list := range candidates {
+			for _, candidate := range list {
+				suggestion := byKey[fmt.Sprintf("%d:%d", skillID, candidate.Tag.ID)]
+				if suggestion == nil {
+					suggestion = &models.SkillTagSuggestion{
+						SkillID:   skillID,
+						TagID:     candidate.Tag.ID,
+						Status:    models.TagSuggestionStatusPending,
+						CreatedAt: timestamp,
+					}
+				} else if suggestion.Status != models.TagSuggestionStatusPending {
+					continue
+				}
+				suggestion.Score = candidate.Score
+				suggestion.Reason = truncateRunes(candidate.Reason(), 255)
+				suggestion.UpdatedAt = timestamp
+				if err := tx.Save(suggestion).Error; err != nil {
+					return err
+				}
+				kept[suggestion.ID] = true
+			}
+		}
+
+		for _, suggestion := range existing {
+			if suggestion.Status == models.TagSuggestionStatusPending && !kept[suggestion.ID] {
+				if err := tx.Delete(&models.SkillTagSuggestion{}, suggestion.ID).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// ListTagSuggestions 分页获取标签建议，按技能ID和得分排序，不含已删除技能和回收站中标签的建议
+// status、skillIDs为空时不筛选，pageSize不大于0时返回全部
+// 返回值: 建议列表, 总数, 错误
+func (r *Repository) ListTagSuggestions(ctx context.Context, status string, skillIDs []uint, page, pageSize int) ([]TagSuggestionView, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.SkillTagSuggestion{}).
+		Joins("JOIN skills ON skills.id = skill_tag_suggestions.skill_id").
+		Joins("JOIN tags ON tags.id = skill_tag_suggestions.tag_id").
+		Where("skills.deleted_at = ? AND tags.deleted_at = ?", 0, 0)
+	if status != "" {
+		query = query.Where("skill_tag_suggestions.status = ?", status)
+	}
+	if len(skillIDs) > 0 {
+		query = query.Where("skill_tag_suggestions.skill_id IN ?", skillIDs)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	query = query.Select("skill_tag_suggestions.*, skills.name AS skill_name, tags.name AS tag_name").
+		Order("skill_tag_suggestions.skill_id, skill_tag_suggestions.score DESC, skill_tag_suggestions.id")
+	if pageSize > 0 {
+		query = query.Offset((page - 1) * pageSize).Limit(pageSize)
+	}
+	views := []TagSuggestionView{}
+	err := query.Scan(&views).Error
+	return views, total, err
+}
+
+// GetTagSuggestion 根据ID获取标签建议，建议的标签在回收站中时视为不存在
+func (r *Repository) GetTagSuggestion(ctx context.Context, id uint) (*TagSuggestionView, error) {
+	var view TagSuggestionView
+	err := r.db.WithContext(ctx).Model(&models.SkillTagSuggestion{}).
+		Select("skill_tag_suggestions.*, skills.name AS skill_name, tags.name AS tag_name").
+		Joins("JOIN skills ON skills.id = skill_tag_suggestions.skill_id").
+		Joins("JOIN tags ON tags.id = skill_tag_suggestions.tag_id").
+		Where("skill_tag_suggestions.id = ? AND tags.deleted_at = ?", id, 0).
+		Take(&view).Error
+	if err != nil {
+		return nil, err
+	}
+	return &view, nil
+}
+
+// ResolveTagSuggestion 采纳或拒绝标签建议，采纳时在同一事务中将标签关联到技能
+func (r *Repository) ResolveTagSuggestion(ctx context.Context, id uint, accept bool) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var suggestion models.SkillTagSuggestion
+		if err := tx.First(&suggestion, id).Error; err != nil {
+			return err
+		}
+		status := models.TagSuggestionStatusRejected
+		if accept {
+			status = models.TagSuggestionStatusAccepted
+			if err := tx.Exec("INSERT OR IGNORE INTO skill_tags (skill_id, tag_id) VALUES (?, ?)",
+				suggestion.SkillID, suggestion.TagID).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&suggestion).Updates(map[string]any{"status": status, "updated_at": time.Now().UnixMilli()}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	if accept {
+		clearTagCache()
+	}
+	return nil
+}
+
+// truncateRunes 将字符串截断到最多n个字符
+func truncateRunes(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n])
+	}
+	return s
+}
@@ -0,0 +1,250 @@
+package repositories
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"aiflow/internal/config"
+	"aiflow/internal/models"
+)
+
+// textAnalyzerVersion 分词处理逻辑的版本，修改buildSkillTokens等分词逻辑后递增，使已有索引在下次启动时重建
+const textAnalyzerVersion = 1
+
+// userDictDefaultFreq 用户词典未写词频时使用的词频，取较大值使领域词优先于默认词典
+const userDictDefaultFreq = 10000
+
+// SettingSearchAnalyzer 记录当前搜索索引所用分词配置指纹（含向量化方式）的设置项
+const SettingSearchAnalyzer = "search.analyzer_fingerprint"
+
+// textAnalyzer 分词后的停用词过滤和同义词归一
+type textAnalyzer struct {
+	stopwords   map[string]bool
+	canonical   map[string]string   // 词 → 所在同义词组的标准词
+	groups      map[string][]string // 标准词 → 同义词组的全部词（含标准词）
+	fingerprint string
+}
+
+var (
+	analyzerMu sync.RWMutex
+	analyzer   = newTextAnalyzer(config.SearchConfig{}, "")
+)
+
+// ConfigureTextAnalyzer 按配置加载用户词典、停用词和同义词，在创建仓库前调用
+// 用户词典每行格式为 "词 [词频] [词性]"，停用词文件每行一个词，#开头的行为注释
+// 词典加载到全局分词器后无法卸载，配置变化后需重启生效
+func ConfigureTextAnalyzer(cfg config.SearchConfig) error {
+	digest := sha256.New()
+	fmt.Fprintf(digest, "version:%d\n", textAnalyzerVersion)
+
+	var errs []error
+	for _, file := range cfg.UserDicts {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("读取用户词典 %s 失败: %w", file, err))
+			continue
+		}
+		digest.Write(data)
+		if err := loadUserDict(string(data)); err != nil {
+			errs = append(errs, fmt.Errorf("加载用户词典 %s 失败: %w", file, err))
+		}
+	}
+	if len(cfg.UserDicts) > 0 {
+		seg.CalcToken()
+	}
+
+	stopwords := slices.Clone(cfg.Stopwords)
+	for _, file := range cfg.StopwordFiles {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("读取停用词文件 %s 失败: %w", file, err))
+			continue
+		}
+		stopwords = append(stopwords, dictLines(string(data))...)
+	}
+	cfg.Stopwords = stopwords
+
+	next := newTextAnalyzer(cfg, hex.EncodeToString(digest.Sum(nil)))
+	analyzerMu.Lock()
+	analyzer = next
+	analyzerMu.Unlock()
+	return errors.Join(errs...)
+}
+
+// newTextAnalyzer 创建分词处理器，dictDigest为用户词典内容的摘要
+func newTextAnalyzer(cfg config.SearchConfig, dictDigest string) *textAnalyzer {
+	a := &textAnalyzer{
+		stopwords: make(map[string]bool),
+		canonical: make(map[string]string),
+		groups:    make(map[string][]string),
+	}
+	for _, word := range cfg.Stopwords {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			a.stopwords[word] = true
+		}
+	}
+
+	// 按标准词排序处理，同一个词出现在多个同义词组时结果稳定
+	heads := make([]string, 0, len(cfg.Synonyms))
+	for head := range cfg.Synonyms {
+		heads = append(heads, head)
+	}
+	slices.Sort(heads)
+	for _, head := range heads {
+		canonical := strings.ToLower(strings.TrimSpace(head))
+		if canonical == "" {
+			continue
+		}
+		if _, ok := a.canonical[canonical]; !ok {
+			a.canonical[canonical] = canonical
+			a.groups[canonical] = append(a.groups[canonical], canonical)
+		}
+		for _, word := range cfg.Synonyms[head] {
+			word = strings.ToLower(strings.TrimSpace(word))
+			if word == "" || a.canonical[word] != "" {
+				continue
+			}
+			a.canonical[word] = a.canonical[canonical]
+			a.groups[a.canonical[canonical]] = append(a.groups[a.canonical[canonical]], word)
+		}
+	}
+
+	// 指纹覆盖分词逻辑版本、用户词典、停用词和同义词，任一变化都需要重建索引
+	digest := sha256.New()
+	fmt.Fprintf(digest, "dict:%s\n", dictDigest)
+	stopwords := make([]string, 0, len(a.stopwords))
+	for word := range a.stopwords {
+		stopwords = append(stopwords, word)
+	}
+	slices.Sort(stopwords)
+	fmt.Fprintf(digest, "stop:%s\n", strings.Join(stopwords, "\x00"))
+	pairs := make([]string, 0, len(a.canonical))
+	for word, canonical := range a.canonical {
+		pairs = append(pairs, word+"="+canonical)
+	}
+	slices.Sort(pairs)
+	fmt.Fprintf(digest, "syn:%s\n", strings.Join(pairs, "\x00"))
+	a.fingerprint = hex.EncodeToString(digest.Sum(nil))
+	return a
+}
+
+// loadUserDict 将用户词典的词加入全局分词器
+func loadUserDict(text string) error {
+	for _, line := range dictLines(text) {
+		fields := strings.Fields(line)
+		freq := float64(userDictDefaultFreq)
+		if len(fields) > 1 {
+			value, err := strconv.ParseFloat(fields[1], 64)
+			if err != nil {
+				return fmt.Errorf("词频格式错误 %q", line)
+			}
+			freq = value
+		}
+		var pos []string
+		if len(fields) > 2 {
+			pos = fields[2:3]
+		}
+		// 分词前文本会转小写，同时加入小写形式保证大小写不同的写法都能切出该词
+		words := []string{fields[0]}
+		if lower := strings.ToLower(fields[0]); lower != fields[0] {
+			words = append(words, lower)
+		}
+		for _, word := range words {
+			if err := seg.AddToken(word, freq, pos...); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// dictLines 拆分词典文本为有效行，忽略空行和#开头的注释行
+func dictLines(text string) []string {
+	var lines []string
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// currentAnalyzer 获取当前的分词处理器
+func currentAnalyzer() *textAnalyzer {
+	analyzerMu.RLock()
+	defer analyzerMu.RUnlock()
+	return analyzer
+}
+
+// analyzeTokens 处理gse分词结果：去空白、转小写、过滤停用词，同义词归一为标准词
+func analyzeTokens(tokens []string) []string {
+	a := currentAnalyzer()
+	var terms []string
+	for _, token := range tokens {
+		token = strings.ToLower(strings.TrimSpace(token))
+		if token == "" || a.stopwords[token] {
+			continue
+		}
+		if canonical, ok := a.canonical[token]; ok {
+			token = canonical
+		}
+		terms = append(terms, token)
+	}
+	return terms
+}
+
+// synonymGroup 返回词所在同义词组的全部词，不在任何同义词组时只返回该词
+func synonymGroup(term string) []string {
+	a := currentAnalyzer()
+	if canonical, ok := a.canonical[term]; ok {
+		return a.groups[canonical]
+	}
+	return []string{term}
+}
+
+// SearchIndexOutdated 判断搜索索引是否由不同的分词配置或向量化方式建立，需要重建
+func (r *Repository) SearchIndexOutdated(ctx context.Context) bool {
+	value, err := r.GetSetting(ctx, SettingSearchAnalyzer)
+	return err != nil || value != r.searchIndexFingerprint()
+}
+
+// MarkSearchIndexCurrent 记录搜索索引已按当前分词配置和向量化方式重建
+func (r *Repository) MarkSearchIndexCurrent(ctx context.Context) error {
+	return r.SetSetting(ctx, SettingSearchAnalyzer, r.searchIndexFingerprint())
+}
+
+// searchIndexFingerprint 当前分词配置的指纹和向量化方式的标识
+func (r *Repository) searchIndexFingerprint() string {
+	fingerprint := currentAnalyzer().fingerprint
+	if r.embedder != nil {
+		fingerprint += ":" + r.embedder.Name()
+	}
+	return fingerprint
+}
+
+// GetSetting 获取设置项的值，不存在时返回gorm.ErrRecordNotFound
+func (r *Repository) GetSetting(ctx context.Context, key string) (string, error) {
+	var setting models.AppSetting
+	if err := r.db.WithContext(ctx).Where("key = ?", key).First(&setting).Error; err != nil {
+		return "", err
+	}
+	return setting.Value, nil
+}
+
+// SetSetting 保存设置项的值
+func (r *Repository) SetSetting(ctx context.Context, key, value string) error {
+	setting := models.AppSetting{Key: key, Value: value, UpdatedAt: time.Now().UnixMilli()}
+	return r.db.WithContext(ctx).Save(&setting).Error
+}
@@ -0,0 +1,87 @@
+package repositories
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+// 回收站中的数据类型
+const (
+	TrashTypeSkill   = "skill"   // 技能
+	TrashTypeJobTask = "jobtask" // 任务
+	TrashTypeTag     = "tag"     // 标签
+)
+
+// TrashTypes 回收站支持的全部数据类型
+var TrashTypes = []string{TrashTypeSkill, TrashTypeJobTask, TrashTypeTag}
+
+// trashSelects 各类型回收站查询，列依次为类型、ID、名称、说明和删除时间
+var trashSelects = map[string]string{
+	TrashTypeSkill:   "SELECT 'skill' AS type, id, name, description, deleted_at FROM skills WHERE deleted_at > 0",
+	TrashTypeJobTask: "SELECT 'jobtask' AS type, id, job_no AS name, goal AS description, deleted_at FROM job_tasks WHERE deleted_at > 0",
+	TrashTypeTag:     "SELECT 'tag' AS type, id, name, '' AS description, deleted_at FROM tags WHERE deleted_at > 0",
+}
+
+// TrashItem 回收站中的一项
+type TrashItem struct {
+	Type string
+	ID   uint
+	// Name 技能名称、任务编号或标签名称
+	Name string
+	// Description 技能描述或任务目标，标签为空
+	Description string
+	DeletedAt   int64
+}
+
+// ListTrash 分页获取回收站中的技能、任务和标签，按删除时间倒序排列
+// 参数:
+//
+//	ctx: 上下文
+//	types: 要查询的类型，为空时查询全部类型
+//	deletedBefore: 大于0时只查询删除时间早于该时间戳（毫秒级）的项
+//	page, pageSize: 分页参数，pageSize不大于0时返回全部
+//
+// 返回:
+//
+//	[]TrashItem: 回收站中的项
+//	int64: 总数
+//	error: 错误信息
+func (r *Repository) ListTrash(ctx context.Context, types []string, deletedBefore int64, page, pageSize int) ([]TrashItem, int64, error) {
+	if len(types) == 0 {
+		types = TrashTypes
+	}
+	var parts []string
+	var args []any
+	for _, trashType := range TrashTypes {
+		if !slices.Contains(types, trashType) {
+			continue
+		}
+		part := trashSelects[trashType]
+		if deletedBefore > 0 {
+			part += " AND deleted_at < ?"
+			args = append(args, deletedBefore)
+		}
+		parts = append(parts, part)
+	}
+	items := []TrashItem{}
+	if len(parts) == 0 {
+		return items, 0, nil
+	}
+	union := strings.Join(parts, " UNION ALL ")
+
+	var total int64
+	if err := r.db.WithContext(ctx).Raw("SELECT COUNT(*) FROM ("+union+")", args...).Scan(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	query := "SELECT * FROM (" + union + ") ORDER BY deleted_at DESC, type, id"
+	if pageSize > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, pageSize, (page-1)*pageSize)
+	}
+	if err := r.db.WithContext(ctx).Raw(query, args...).Scan(&items).Error; err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
@@ -5,6 +5,8 @@ import (
 	"aiflow/internal/models"
 	"aiflow/internal/repositories"
 	"context"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
@@ -135,12 +137,18 @@ func (s *JobTaskService) CreateJobTask(ctx context.Context, req CreateJobTaskReq
 		Goal:                    req.Goal,
 		PassAcceptStd:           req.PassAcceptStd,
 		Status:                  JobTaskStatusCreated,
-		ExecutionRecords:        req.ExecutionRecords,
 		ActiveExecutionSequence: req.ActiveExecutionSequence,
 		CreatedAt:               timestamp,
 		UpdatedAt:               timestamp,
 	}
 
+	// 执行记录以JSON数组提交，解析后写入执行记录表
+	executions, err := parseExecutionRecords(req.ExecutionRecords)
+	if err != nil {
+		return nil, err
+	}
+	jobTask.Executions = executions
+
 	if err := s.repo.CreateJobTask(ctx, jobTask); err != nil {
 		return nil, errors.NewTaskError(errors.ErrCodeTaskCreate, "创建任务失败", err)
 	}
@@ -170,14 +178,35 @@ func (s *JobTaskService) UpdateJobTask(ctx context.Context, req UpdateJobTaskReq
 		return nil, errors.NewInternalError(errors.ErrCodeInternalError, "获取任务失败", err)
 	}
 
+	// 校验状态流转，未传状态时保持原状态
+	if req.Status == "" {
+		req.Status = jobTask.Status
+	}
+	if err := ValidateJobTaskStatusTransition(jobTask.Status, req.Status); err != nil {
+		return nil, err
+	}
+
 	// 更新允许修改的字段
 	jobTask.Status = req.Status
 	jobTask.PassAcceptStd = req.PassAcceptStd
-	jobTask.ExecutionRecords = req.ExecutionRecords
-	jobTask.ActiveExecutionSequence = req.ActiveExecutionSequence
+	if req.ActiveExecutionSequence > 0 {
+		jobTask.ActiveExecutionSequence = req.ActiveExecutionSequence
+	}
 	jobTask.UpdatedAt = time.Now().UnixMilli()
 
-	if err := s.repo.UpdateJobTask(ctx, jobTask); err != nil {
+	// 未提交执行记录时保持原有记录，否则整体替换
+	if req.ExecutionRecords == "" {
+		if err := s.repo.UpdateJobTask(ctx, jobTask); err != nil {
+			return nil, errors.NewTaskError(errors.ErrCodeTaskUpdate, "更新任务失败", err)
+		}
+		return jobTask, nil
+	}
+
+	executions, err := parseExecutionRecords(req.ExecutionRecords)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.ReplaceJobTaskExecutions(ctx, jobTask, executions); err != nil {
 		return nil, errors.NewTaskError(errors.ErrCodeTaskUpdate, "更新任务失败", err)
 	}
 
@@ -294,6 +323,28 @@ func validateJobTaskRequired(jobNo, project, jobType, goal string) error {
 	return nil
 }
 
+// parseExecutionRecords 解析JSON数组格式的执行记录
+// 空字符串返回空列表，执行序号不能重复
+func parseExecutionRecords(data string) ([]models.ExecutionRecord, error) {
+	if data == "" {
+		return []models.ExecutionRecord{}, nil
+	}
+
+	var records []models.ExecutionRecord
+	if err := json.Unmarshal([]byte(data), &records); err != nil {
+		return nil, errors.NewTaskError(errors.ErrCodeTaskValidate, "执行记录格式错误", err)
+	}
+
+	sequences := make(map[int]struct{}, len(records))
+	for _, record := range records {
+		if _, ok := sequences[record.Sequence]; ok {
+			return nil, errors.NewTaskError(errors.ErrCodeTaskValidate, fmt.Sprintf("执行序号重复: %d", record.Sequence), nil)
+		}
+		sequences[record.Sequence] = struct{}{}
+	}
+	return records, nil
+}
+
 // convertToJobTaskResponse 将模型转换为响应结构
 func convertToJobTaskResponse(task *models.JobTask) JobTaskResponse {
 	return JobTaskResponse{
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"aiflow/internal/errors"
+	"fmt"
+	"strings"
+)
+
+// jobTaskStatusTransitions 任务状态流转表
+// key为当前状态，value为允许流转到的下一状态
+// 已创建的任务自带第一条执行记录，因此可直接报告处理结果；验收通过为终态
+var jobTaskStatusTransitions = map[string][]string{
+	JobTaskStatusCreated:   {JobTaskStatusRunning, JobTaskStatusFailed, JobTaskStatusCompleted},
+	JobTaskStatusRunning:   {JobTaskStatusFailed, JobTaskStatusCompleted},
+	JobTaskStatusFailed:    {JobTaskStatusRunning},
+	JobTaskStatusCompleted: {JobTaskStatusRunning, JobTaskStatusFailed, JobTaskStatusPassed},
+	JobTaskStatusPassed:    {},
+}
+
+// IsValidJobTaskStatus 判断状态值是否为合法的任务状态
+func IsValidJobTaskStatus(status string) bool {
+	_, ok := jobTaskStatusTransitions[status]
+	return ok
+}
+
+// NextJobTaskStatuses 获取指定状态允许流转到的下一状态列表
+// 未知的历史状态允许流转到任意合法状态，便于修复脏数据
+func NextJobTaskStatuses(from string) []string {
+	if next, ok := jobTaskStatusTransitions[from]; ok {
+		return next
+	}
+	return []string{
+		JobTaskStatusCreated,
+		JobTaskStatusRunning,
+		JobTaskStatusFailed,
+		JobTaskStatusCompleted,
+		JobTaskStatusPassed,
+	}
+}
+
+// ValidateJobTaskStatusTransition 校验任务状态流转是否合法
+// 状态不变视为合法；非法时返回TSK-VAL错误码，错误信息中列出允许的下一状态
+func ValidateJobTaskStatusTransition(from, to string) error {
+	if !IsValidJobTaskStatus(to) {
+		return errors.NewTaskError(errors.ErrCodeTaskStatusTransition,
+			fmt.Sprintf("无效的任务状态「%s」，允许的下一状态：%s", to, formatNextStatuses(from)), nil)
+	}
+	if from == to {
+		return nil
+	}
+
+	for _, next := range NextJobTaskStatuses(from) {
+		if next == to {
+			return nil
+		}
+	}
+
+	return errors.NewTaskError(errors.ErrCodeTaskStatusTransition,
+		fmt.Sprintf("不允许从「%s」流转到「%s」，允许的下一状态：%s", from, to, formatNextStatuses(from)), nil)
+}
+
+// formatNextStatuses 格式化允许的下一状态列表，终态时返回"无（已是终态）"
+func formatNextStatuses(from string) string {
+	next := NextJobTaskStatuses(from)
+	if len(next) == 0 {
+		return "无（已是终态）"
+	}
+	return strings.Join(next, "、")
+}
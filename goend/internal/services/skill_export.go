@@ -0,0 +1,113 @@
+package services
+
+import (
+	"aiflow/internal/errors"
+	"aiflow/internal/models"
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ExportSkillsRequest 批量导出技能的筛选条件，各条件同时生效
+type ExportSkillsRequest struct {
+	IDs       []uint // 技能ID列表，为空时不限制
+	TagIDs    []uint // 标签ID列表，带有任一标签的技能即导出
+	StartDate int64  // 创建时间起始（毫秒级时间戳）
+	EndDate   int64  // 创建时间截止（毫秒级时间戳）
+}
+
+// GetSkillsForExport 按筛选条件获取待导出的技能
+func (s *SkillService) GetSkillsForExport(ctx context.Context, req ExportSkillsRequest) ([]models.Skill, error) {
+	skills, err := s.repo.ListSkillsForExport(ctx, req.IDs, req.TagIDs, req.StartDate, req.EndDate)
+	if err != nil {
+		return nil, errors.NewInternalError(errors.ErrCodeInternalError, "获取技能失败", err)
+	}
+	return skills, nil
+}
+
+// WriteSkillsZip 将技能写为zip包，每个技能一个 <资源目录>/SKILL.md，技能文件放在同一目录下
+// 生成的zip包可直接通过上传接口重新导入
+func (s *SkillService) WriteSkillsZip(skills []models.Skill, w io.Writer) error {
+	zw := zip.NewWriter(w)
+	used := map[string]bool{}
+	for i := range skills {
+		skill := &skills[i]
+		folder := exportFolderName(skill, used)
+		if err := s.writeSkillToZip(zw, folder, skill); err != nil {
+			return fmt.Errorf("导出技能 %s 失败: %w", skill.Name, err)
+		}
+	}
+	return zw.Close()
+}
+
+// writeSkillToZip 写入单个技能的SKILL.md和技能文件
+func (s *SkillService) writeSkillToZip(zw *zip.Writer, folder string, skill *models.Skill) error {
+	modified := time.UnixMilli(skill.UpdatedAt)
+	entry, err := zw.CreateHeader(&zip.FileHeader{Name: folder + "/SKILL.md", Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return err
+	}
+	if _, err = io.WriteString(entry, buildSkillMarkdown(skill)); err != nil {
+		return err
+	}
+
+	// 资源目录非法时没有技能文件，只导出SKILL.md
+	if _, err = s.store.SkillDir(skill.ResourceDir); err != nil {
+		return nil
+	}
+	files, err := s.store.List(skill.ResourceDir)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		// SKILL.md以数据库内容为准
+		if file.Path == "SKILL.md" {
+			continue
+		}
+		if err = s.copySkillFileToZip(zw, folder, skill.ResourceDir, file.Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copySkillFileToZip 将一个技能文件复制到zip包
+func (s *SkillService) copySkillFileToZip(zw *zip.Writer, folder, resourceDir, relPath string) error {
+	file, info, err := s.store.Open(resourceDir, relPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = folder + "/" + relPath
+	header.Method = zip.Deflate
+	entry, err := zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entry, file)
+	return err
+}
+
+// exportFolderName 确定技能在zip包中的目录名
+// 优先使用资源目录，资源目录为空或非法时使用技能名称，重名时追加技能ID
+func exportFolderName(skill *models.Skill, used map[string]bool) string {
+	folder := skill.ResourceDir
+	if !isValidSyncFolder(folder) {
+		folder = generateResourceDir(skill.Name)
+	}
+	if !isValidSyncFolder(folder) {
+		folder = fmt.Sprintf("skill-%d", skill.ID)
+	}
+	if used[folder] {
+		folder = fmt.Sprintf("%s-%d", folder, skill.ID)
+	}
+	used[folder] = true
+	return folder
+}
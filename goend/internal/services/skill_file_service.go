@@ -0,0 +1,119 @@
+package services
+
+import (
+	"aiflow/internal/errors"
+	"aiflow/internal/models"
+	"aiflow/internal/repositories"
+	"aiflow/internal/storage"
+	"context"
+	stderrors "errors"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// SkillFileService 技能文件服务层
+// 管理技能资源目录下的脚本、模板等文件
+type SkillFileService struct {
+	repo  *repositories.Repository
+	store *storage.SkillFileStore
+}
+
+// SkillFilesResponse 技能文件列表响应
+type SkillFilesResponse struct {
+	BaseDir  string              `json:"baseDir"`  // 技能基准目录（绝对路径）
+	SkillDir string              `json:"skillDir"` // 技能目录（绝对路径）
+	Files    []storage.SkillFile `json:"files"`
+}
+
+// NewSkillFileService 创建技能文件服务实例
+func NewSkillFileService(repo *repositories.Repository, store *storage.SkillFileStore) *SkillFileService {
+	return &SkillFileService{repo: repo, store: store}
+}
+
+// ListFiles 获取技能的文件列表
+func (s *SkillFileService) ListFiles(ctx context.Context, skillID uint) (*SkillFilesResponse, error) {
+	skill, err := s.getSkill(ctx, skillID)
+	if err != nil {
+		return nil, err
+	}
+
+	skillDir, err := s.store.SkillDir(skill.ResourceDir)
+	if err != nil {
+		return nil, convertStorageError(err, "技能资源目录非法")
+	}
+	files, err := s.store.List(skill.ResourceDir)
+	if err != nil {
+		return nil, convertStorageError(err, "获取技能文件列表失败")
+	}
+
+	return &SkillFilesResponse{
+		BaseDir:  s.store.BaseDir(),
+		SkillDir: skillDir,
+		Files:    files,
+	}, nil
+}
+
+// SaveFile 保存技能文件，同路径文件已存在时覆盖
+func (s *SkillFileService) SaveFile(ctx context.Context, skillID uint, relPath string, reader io.Reader) (*storage.SkillFile, error) {
+	skill, err := s.getSkill(ctx, skillID)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := s.store.Save(skill.ResourceDir, relPath, reader)
+	if err != nil {
+		return nil, convertStorageError(err, "保存技能文件失败")
+	}
+	return file, nil
+}
+
+// OpenFile 打开技能文件用于下载，调用方负责关闭
+func (s *SkillFileService) OpenFile(ctx context.Context, skillID uint, relPath string) (*os.File, os.FileInfo, error) {
+	skill, err := s.getSkill(ctx, skillID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, info, err := s.store.Open(skill.ResourceDir, relPath)
+	if err != nil {
+		return nil, nil, convertStorageError(err, "读取技能文件失败")
+	}
+	return file, info, nil
+}
+
+// DeleteFile 删除技能文件
+func (s *SkillFileService) DeleteFile(ctx context.Context, skillID uint, relPath string) error {
+	skill, err := s.getSkill(ctx, skillID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.store.Delete(skill.ResourceDir, relPath); err != nil {
+		return convertStorageError(err, "删除技能文件失败")
+	}
+	return nil
+}
+
+// getSkill 获取未删除的技能
+func (s *SkillFileService) getSkill(ctx context.Context, skillID uint) (*models.Skill, error) {
+	skill, err := s.repo.GetSkillByID(ctx, skillID)
+	if err != nil || skill.DeletedAt > 0 {
+		return nil, errors.NewNotFoundError(errors.ErrCodeSkillNotFound, "技能不存在", err)
+	}
+	return skill, nil
+}
+
+// convertStorageError 将存储层错误转换为业务错误
+func convertStorageError(err error, message string) error {
+	switch {
+	case stderrors.Is(err, storage.ErrInvalidPath):
+		return errors.NewSkillError(errors.ErrCodeSkillFileInvalidPath, err.Error(), err)
+	case stderrors.Is(err, storage.ErrFileTooLarge):
+		return errors.NewSkillError(errors.ErrCodeSkillFileTooLarge, err.Error(), err)
+	case stderrors.Is(err, fs.ErrNotExist):
+		return errors.NewNotFoundError(errors.ErrCodeSkillFileNotFound, "技能文件不存在", err)
+	default:
+		return errors.NewSkillError(errors.ErrCodeSkillFileSave, message, err)
+	}
+}
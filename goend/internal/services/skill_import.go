@@ -0,0 +1,199 @@
+package services
+
+import (
+	"aiflow/internal/models"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ParseSkillMarkdown 解析SKILL.md内容（YAML头 + 详细说明）
+// 返回未保存的技能对象和YAML头中声明的标签名称
+func ParseSkillMarkdown(fileContent []byte) (*models.Skill, []string, error) {
+	// 1. 提取YAML头部信息
+	yamlHeader, content := extractYAMLHeader(string(fileContent))
+	if yamlHeader == "" {
+		return nil, nil, fmt.Errorf("文件中未找到YAML头部信息，请确保文件以---开头和结束")
+	}
+
+	// 2. 解析YAML头部信息（支持多种命名风格）
+	skillData, err := parseSkillYAML(yamlHeader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 3. 构建Skill对象
+	skill := &models.Skill{
+		Name:          strings.TrimSpace(skillData.Name),
+		ResourceDir:   strings.TrimSpace(skillData.ResourceDir),
+		Description:   strings.TrimSpace(skillData.Description),
+		License:       skillData.License,
+		Compatibility: skillData.Compatibility,
+		Metadata:      skillMetadataFromYAML(&skillData.Metadata),
+		AllowedTools:  skillData.AllowedTools,
+		Detail:        strings.Trim(content, "\r\n"), // 去掉YAML头和正文之间、正文末尾的空行，重复导出导入时不累积空行
+		CreatedAt:     time.Now().UnixMilli(),
+		UpdatedAt:     time.Now().UnixMilli(),
+	}
+
+	// 4. 按Agent Skills规范校验
+	if err := ValidateSkill(skill); err != nil {
+		return nil, nil, err
+	}
+
+	// 如果资源目录为空，使用名称生成
+	if skill.ResourceDir == "" {
+		skill.ResourceDir = generateResourceDir(skill.Name)
+	}
+
+	return skill, skillData.Tags, nil
+}
+
+// ImportSkillMarkdown 解析SKILL.md内容并保存技能，同名技能存在时更新
+// 资源目录变更时同步移动技能文件目录，YAML头中声明了标签时替换原有标签
+// 返回保存后的技能以及是否为新建
+func (s *SkillService) ImportSkillMarkdown(ctx context.Context, fileContent []byte) (*models.Skill, bool, error) {
+	skill, tagNames, err := ParseSkillMarkdown(fileContent)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// 保存技能到数据库（同名则更新）
+	existingSkill, err := s.repo.GetSkillByName(ctx, skill.Name)
+	if err == nil && existingSkill != nil {
+		// 技能已存在，更新
+		skill.ID = existingSkill.ID
+		skill.CreatedAt = existingSkill.CreatedAt
+		// 资源目录变更时同步移动技能文件目录
+		if err = s.UpdateSkillAndMoveDir(ctx, skill, existingSkill.ResourceDir); err != nil {
+			return nil, false, err
+		}
+	} else {
+		// 技能不存在，创建新技能
+		err = s.repo.CreateSkill(ctx, skill)
+		if err != nil {
+			return nil, false, fmt.Errorf("保存技能到数据库失败: %v", err)
+		}
+	}
+
+	// 声明了标签时替换原有标签，别名解析到合并后的标签，不存在的标签自动创建
+	if len(tagNames) > 0 {
+		if _, err := s.repo.SetSkillTagsByName(ctx, skill.ID, tagNames); err != nil {
+			return nil, false, err
+		}
+	}
+
+	s.recordRevision(ctx, skill.ID, models.SkillRevisionSourceImport, "")
+	return skill, existingSkill == nil, nil
+}
+
+// skillYAMLData 技能YAML数据结构（支持多种字段命名）
+type skillYAMLData struct {
+	Name          string    `yaml:"name"`
+	ResourceDir   string    `yaml:"resource_dir"`
+	ResourceDir2  string    `yaml:"resourceDir"`
+	ResourceDir3  string    `yaml:"resource-dir"`
+	Description   string    `yaml:"description"`
+	License       string    `yaml:"license"`
+	Compatibility string    `yaml:"compatibility"`
+	Metadata      yaml.Node `yaml:"metadata"` // 规范写法为键值对映射，兼容旧版导出的JSON文本
+	AllowedTools  string    `yaml:"allowed_tools"`
+	AllowedTools2 string    `yaml:"allowedTools"`
+	AllowedTools3 string    `yaml:"allowed-tools"`
+	Tags          []string  `yaml:"tags"`
+}
+
+// parseSkillYAML 解析技能YAML，支持多种字段命名风格
+func parseSkillYAML(yamlHeader string) (*skillYAMLData, error) {
+	var data skillYAMLData
+	err := yaml.Unmarshal([]byte(yamlHeader), &data)
+	if err != nil {
+		return nil, fmt.Errorf("YAML格式错误: %v", err)
+	}
+
+	// 合并多种命名风格的字段
+	if data.ResourceDir == "" && data.ResourceDir2 != "" {
+		data.ResourceDir = data.ResourceDir2
+	}
+	if data.ResourceDir == "" && data.ResourceDir3 != "" {
+		data.ResourceDir = data.ResourceDir3
+	}
+	if data.AllowedTools == "" && data.AllowedTools2 != "" {
+		data.AllowedTools = data.AllowedTools2
+	}
+	if data.AllowedTools == "" && data.AllowedTools3 != "" {
+		data.AllowedTools = data.AllowedTools3
+	}
+
+	return &data, nil
+}
+
+// skillMetadataFromYAML 将YAML头中的metadata字段转换为技能元数据
+// 支持键值对映射和字符串（JSON文本或旧版的任意文本）两种写法，标量值保留原文
+func skillMetadataFromYAML(node *yaml.Node) models.SkillMetadata {
+	if metadata, ok := models.NewSkillMetadataFromYAML(node); ok {
+		return metadata
+	}
+	switch {
+	case node.Kind == 0 || node.ShortTag() == "!!null":
+		return nil
+	case node.Kind == yaml.ScalarNode:
+		return models.ParseSkillMetadata(node.Value)
+	default:
+		// 列表等其他结构整段保存在raw键下
+		data, err := yaml.Marshal(node)
+		if err != nil {
+			return nil
+		}
+		return models.ParseSkillMetadata(string(data))
+	}
+}
+
+// generateResourceDir 根据技能名称生成资源目录
+func generateResourceDir(name string) string {
+	// 将名称转换为小写，替换空格和特殊字符为下划线
+	dir := strings.ToLower(name)
+	dir = strings.ReplaceAll(dir, " ", "_")
+	dir = strings.ReplaceAll(dir, "-", "_")
+	// 移除非字母数字下划线的字符
+	var result strings.Builder
+	for _, r := range dir {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' {
+			result.WriteRune(r)
+		}
+	}
+	return result.String()
+}
+
+// extractYAMLHeader 提取MD文件中的YAML头部信息
+// 返回YAML头部内容和剩余的文件内容
+func extractYAMLHeader(content string) (string, string) {
+	lines := strings.Split(content, "\n")
+	var yamlLines []string
+	inYAML := false
+
+	for i, line := range lines {
+		trimmedLine := strings.TrimSpace(line)
+		if trimmedLine == "---" {
+			if !inYAML {
+				// 开始YAML头部
+				inYAML = true
+			} else {
+				// 结束YAML头部
+				inYAML = false
+				// 拼接YAML头部内容
+				yamlHeader := strings.Join(yamlLines, "\n")
+				// 拼接剩余内容
+				remainingContent := strings.Join(lines[i+1:], "\n")
+				return yamlHeader, remainingContent
+			}
+		} else if inYAML {
+			yamlLines = append(yamlLines, line)
+		}
+	}
+
+	return "", content
+}
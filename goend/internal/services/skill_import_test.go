@@ -0,0 +1,82 @@
+package services
+
+import (
+	"encoding/json"
+	"maps"
+	"testing"
+
+	"aiflow/internal/models"
+)
+
+// TestParseSkillMarkdown_Metadata 测试SKILL.md中元数据的数字、布尔值等标量保留原文
+func TestParseSkillMarkdown_Metadata(t *testing.T) {
+	header := "---\nname: pdf-processing\ndescription: Extract text from PDF files. Use when reading PDFs.\n"
+	tests := []struct {
+		name     string
+		metadata string
+		want     models.SkillMetadata
+	}{
+		{
+			name:     "键值对映射",
+			metadata: "metadata:\n  version: 1.0\n  patch: 1.10\n  build: 007\n  count: 12\n  beta: yes\n  stable: false\n  empty: ~\n  author: alice\n",
+			want: models.SkillMetadata{
+				"version": "1.0", "patch": "1.10", "build": "007", "count": "12",
+				"beta": "yes", "stable": "false", "empty": "", "author": "alice",
+			},
+		},
+		{
+			name:     "流式写法",
+			metadata: "metadata: {version: 2.50, author: bob}\n",
+			want:     models.SkillMetadata{"version": "2.50", "author": "bob"},
+		},
+		{
+			name:     "嵌套结构转为JSON文本",
+			metadata: "metadata:\n  owners: [alice, bob]\n",
+			want:     models.SkillMetadata{"owners": `["alice","bob"]`},
+		},
+		{
+			name:     "旧版导出的JSON文本",
+			metadata: "metadata: '{\"version\": 1.0, \"rate\": 0.10}'\n",
+			want:     models.SkillMetadata{"version": "1.0", "rate": "0.10"},
+		},
+		{
+			name:     "旧版的任意文本",
+			metadata: "metadata: written by alice\n",
+			want:     models.SkillMetadata{models.SkillMetadataRawKey: "written by alice"},
+		},
+		{
+			name:     "没有元数据",
+			metadata: "",
+			want:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			skill, _, err := ParseSkillMarkdown([]byte(header + tt.metadata + "---\n# PDF\n"))
+			if err != nil {
+				t.Fatalf("解析SKILL.md失败: %v", err)
+			}
+			if !maps.Equal(skill.Metadata, tt.want) {
+				t.Errorf("元数据解析结果不符: %v, 期望: %v", skill.Metadata, tt.want)
+			}
+		})
+	}
+
+	// 导出再导入后元数据不变
+	skill := &models.Skill{
+		Name:        "pdf-processing",
+		Description: "Extract text from PDF files. Use when reading PDFs.",
+		Metadata:    models.SkillMetadata{"version": "1.10", "count": "12", "beta": "yes"},
+	}
+	parsed, _, err := ParseSkillMarkdown([]byte(BuildSkillMarkdown(skill, nil)))
+	if err != nil || !maps.Equal(parsed.Metadata, skill.Metadata) {
+		t.Errorf("导出再导入后元数据应不变: %v, %v", parsed.Metadata, err)
+	}
+
+	// 接口请求中的数字同样保留原文
+	var metadata models.SkillMetadata
+	if err := json.Unmarshal([]byte(`{"version": 1.0, "count": 12}`), &metadata); err != nil ||
+		!maps.Equal(metadata, models.SkillMetadata{"version": "1.0", "count": "12"}) {
+		t.Errorf("JSON元数据中的数字应保留原文: %v, %v", metadata, err)
+	}
+}
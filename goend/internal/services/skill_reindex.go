@@ -0,0 +1,170 @@
+package services
+
+import (
+	"aiflow/internal/errors"
+	"aiflow/internal/repositories"
+	"aiflow/internal/utils/logx"
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// 重建索引的批大小，每批技能在一个事务中重建
+const (
+	DefaultReindexBatchSize = 100
+	MaxReindexBatchSize     = 1000
+)
+
+// reindexMaxFailures 重建状态中最多保留的失败记录数
+const reindexMaxFailures = 100
+
+// ReindexFailure 单个技能重建索引失败的信息
+type ReindexFailure struct {
+	SkillID uint   `json:"skillId"`
+	Name    string `json:"name"`
+	Reason  string `json:"reason"`
+}
+
+// ReindexStatus 技能索引重建的进度和结果
+type ReindexStatus struct {
+	Running    bool             `json:"running"`
+	Total      int              `json:"total"`     // 需要重建的技能数（含回收站中的技能）
+	Processed  int              `json:"processed"` // 已处理的技能数（含失败）
+	Failed     int              `json:"failed"`    // 重建失败的技能数
+	BatchSize  int              `json:"batchSize"`
+	FullText   bool             `json:"fullText"` // 是否同时重建了全文检索索引
+	StartedAt  int64            `json:"startedAt"`
+	FinishedAt int64            `json:"finishedAt"`
+	Failures   []ReindexFailure `json:"failures"`        // 失败的技能，最多保留100条
+	Error      string           `json:"error,omitempty"` // 重建中止的原因
+}
+
+// SkillReindexService 技能搜索索引重建服务
+// 分词词典、分词逻辑或向量化方式变化后，后台分批重建所有技能的分词索引、全文检索索引和语义向量
+type SkillReindexService struct {
+	repo *repositories.Repository
+
+	// runMu 保证同一时间只有一个重建在执行
+	runMu sync.Mutex
+
+	// statusMu 保护重建进度
+	statusMu sync.RWMutex
+	status   *ReindexStatus
+}
+
+// NewSkillReindexService 创建技能索引重建服务实例
+func NewSkillReindexService(repo *repositories.Repository) *SkillReindexService {
+	return &SkillReindexService{repo: repo}
+}
+
+// Start 在后台开始重建所有技能的索引，立即返回初始进度
+// batchSize为0时使用默认批大小；已有重建在执行时返回错误
+// 不同进程同时重建时各自逐个技能删除并重建索引，结果相同
+func (s *SkillReindexService) Start(ctx context.Context, batchSize int) (*ReindexStatus, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultReindexBatchSize
+	}
+	batchSize = min(batchSize, MaxReindexBatchSize)
+
+	if !s.runMu.TryLock() {
+		return nil, errors.NewSkillError(errors.ErrCodeSkillReindexRunning, "", nil)
+	}
+
+	s.statusMu.Lock()
+	s.status = &ReindexStatus{
+		Running:   true,
+		BatchSize: batchSize,
+		FullText:  s.repo.FullTextSearchEnabled(),
+		StartedAt: time.Now().UnixMilli(),
+		Failures:  []ReindexFailure{},
+	}
+	s.statusMu.Unlock()
+
+	go func() {
+		defer s.runMu.Unlock()
+		s.run(ctx, batchSize)
+	}()
+	return s.Status(), nil
+}
+
+// Status 获取最近一次重建的进度，从未重建过时返回nil
+func (s *SkillReindexService) Status() *ReindexStatus {
+	s.statusMu.RLock()
+	defer s.statusMu.RUnlock()
+	if s.status == nil {
+		return nil
+	}
+	status := *s.status
+	status.Failures = slices.Clone(s.status.Failures)
+	return &status
+}
+
+// run 分批重建索引，整批失败时逐个重建找出失败的技能
+func (s *SkillReindexService) run(ctx context.Context, batchSize int) {
+	entries, err := s.repo.ListSkillIndexEntries(ctx)
+	if err != nil {
+		logx.Error("技能索引重建失败: %v", err)
+		s.finish("获取技能列表失败: " + err.Error())
+		return
+	}
+	s.updateStatus(func(status *ReindexStatus) {
+		status.Total = len(entries)
+	})
+	logx.Info("开始重建技能索引: 共%d个技能，每批%d个", len(entries), batchSize)
+
+	for batch := range slices.Chunk(entries, batchSize) {
+		if ctx.Err() != nil {
+			s.finish("索引重建已取消")
+			return
+		}
+
+		ids := make([]uint, 0, len(batch))
+		for _, entry := range batch {
+			ids = append(ids, entry.ID)
+		}
+
+		var failures []ReindexFailure
+		if err := s.repo.ReindexSkills(ctx, ids); err != nil {
+			for _, entry := range batch {
+				if err := s.repo.ReindexSkills(ctx, []uint{entry.ID}); err != nil {
+					failures = append(failures, ReindexFailure{SkillID: entry.ID, Name: entry.Name, Reason: err.Error()})
+				}
+			}
+		}
+
+		s.updateStatus(func(status *ReindexStatus) {
+			status.Processed += len(batch)
+			status.Failed += len(failures)
+			room := reindexMaxFailures - len(status.Failures)
+			status.Failures = append(status.Failures, failures[:min(len(failures), max(room, 0))]...)
+		})
+	}
+
+	s.finish("")
+	status := s.Status()
+	logx.Info("技能索引重建完成: 共%d个技能，失败%d个", status.Total, status.Failed)
+
+	// 全部成功后记录当前分词配置，有失败时下次启动会再次重建
+	if status.Failed == 0 {
+		if err := s.repo.MarkSearchIndexCurrent(ctx); err != nil {
+			logx.Warn("记录技能索引分词配置失败: %v", err)
+		}
+	}
+}
+
+// finish 标记重建结束
+func (s *SkillReindexService) finish(errMsg string) {
+	s.updateStatus(func(status *ReindexStatus) {
+		status.Running = false
+		status.FinishedAt = time.Now().UnixMilli()
+		status.Error = errMsg
+	})
+}
+
+// updateStatus 在锁内修改重建进度
+func (s *SkillReindexService) updateStatus(fn func(status *ReindexStatus)) {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	fn(s.status)
+}
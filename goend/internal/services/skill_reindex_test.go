@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"aiflow/internal/errors"
+	"aiflow/internal/models"
+	"aiflow/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+// waitReindex 等待索引重建结束并返回最终进度
+func waitReindex(t *testing.T, reindexService *SkillReindexService) *ReindexStatus {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		if status := reindexService.Status(); status != nil && !status.Running {
+			return status
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("等待索引重建结束超时: %+v", reindexService.Status())
+	return nil
+}
+
+// countSkillTokens 获取技能的分词索引条数
+func countSkillTokens(t *testing.T, repo *repositories.Repository, skillID uint) int64 {
+	t.Helper()
+	var count int64
+	if err := repo.GetDB().Model(&models.SkillToken{}).Where("skill_id = ?", skillID).Count(&count).Error; err != nil {
+		t.Fatalf("统计分词索引失败: %v", err)
+	}
+	return count
+}
+
+// TestSkillReindexService 测试重建索引的并发限制、进度、失败重试和索引配置记录
+func TestSkillReindexService(t *testing.T) {
+	repo, _ := newTestSkillService(t)
+	ctx := context.Background()
+
+	var skills []*models.Skill
+	for i := range 5 {
+		skills = append(skills, createSkill(t, repo, &models.Skill{
+			Name:        fmt.Sprintf("skill-%d", i),
+			Description: fmt.Sprintf("Test skill %d. Use when testing reindex.", i),
+		}))
+	}
+	// 回收站中的技能同样重建
+	if err := repo.DeleteSkill(ctx, skills[4].ID); err != nil {
+		t.Fatalf("删除技能失败: %v", err)
+	}
+	if err := repo.SetSetting(ctx, repositories.SettingSearchAnalyzer, "outdated"); err != nil {
+		t.Fatalf("设置索引配置失败: %v", err)
+	}
+	if err := repo.GetDB().Where("1 = 1").Delete(&models.SkillToken{}).Error; err != nil {
+		t.Fatalf("清空分词索引失败: %v", err)
+	}
+
+	// 写入分词索引时按技能注入失败，第一次写入时阻塞直到放行
+	var (
+		mu       sync.Mutex
+		failID   = skills[2].ID
+		started  = make(chan struct{})
+		release  = make(chan struct{})
+		blocking sync.Once
+	)
+	err := repo.GetDB().Callback().Create().Before("gorm:create").Register("test:reindex_failure", func(tx *gorm.DB) {
+		token, ok := tx.Statement.Dest.(*models.SkillToken)
+		if !ok {
+			return
+		}
+		blocking.Do(func() {
+			close(started)
+			<-release
+		})
+		mu.Lock()
+		defer mu.Unlock()
+		if token.SkillID == failID {
+			tx.AddError(stderrors.New("模拟写入分词索引失败"))
+		}
+	})
+	if err != nil {
+		t.Fatalf("注册测试回调失败: %v", err)
+	}
+
+	reindexService := NewSkillReindexService(repo)
+	status, err := reindexService.Start(ctx, 2)
+	if err != nil || !status.Running || status.BatchSize != 2 {
+		t.Fatalf("开始重建失败: %+v, %v", status, err)
+	}
+
+	<-started
+	_, err = reindexService.Start(ctx, 2)
+	var appErr *errors.AppError
+	if !stderrors.As(err, &appErr) || appErr.Code != errors.ErrCodeSkillReindexRunning {
+		t.Errorf("重建进行中再次开始应返回%s，实际: %v", errors.ErrCodeSkillReindexRunning, err)
+	}
+	close(release)
+
+	status = waitReindex(t, reindexService)
+	if status.Total != 5 || status.Processed != 5 || status.Failed != 1 || status.Error != "" {
+		t.Errorf("重建进度不符: %+v", status)
+	}
+	if len(status.Failures) != 1 || status.Failures[0].SkillID != failID || status.Failures[0].Name != "skill-2" {
+		t.Errorf("应记录失败的技能: %+v", status.Failures)
+	}
+	// 整批失败后逐个重建，同批的其他技能不受影响
+	for _, skill := range skills {
+		count := countSkillTokens(t, repo, skill.ID)
+		if skill.ID == failID && count != 0 || skill.ID != failID && count == 0 {
+			t.Errorf("技能%s重建后的分词索引条数不符: %d", skill.Name, count)
+		}
+	}
+	if !repo.SearchIndexOutdated(ctx) {
+		t.Error("有技能重建失败时不应记录当前索引配置")
+	}
+
+	// 失败原因消除后重新重建
+	mu.Lock()
+	failID = 0
+	mu.Unlock()
+	if _, err := reindexService.Start(ctx, 0); err != nil {
+		t.Fatalf("再次开始重建失败: %v", err)
+	}
+	status = waitReindex(t, reindexService)
+	if status.Processed != 5 || status.Failed != 0 || len(status.Failures) != 0 || status.BatchSize != DefaultReindexBatchSize {
+		t.Errorf("重建进度不符: %+v", status)
+	}
+	if countSkillTokens(t, repo, skills[2].ID) == 0 {
+		t.Error("失败的技能应在再次重建后有分词索引")
+	}
+	if repo.SearchIndexOutdated(ctx) {
+		t.Error("全部成功后应记录当前索引配置")
+	}
+}
@@ -0,0 +1,303 @@
+package services
+
+import (
+	"aiflow/internal/errors"
+	"aiflow/internal/models"
+	"aiflow/internal/utils/logx"
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// maxDiffCells 逐行对比的最大计算量（行数乘积），超过时整体显示为删除旧内容、新增新内容
+const maxDiffCells = 4000000
+
+// diffContextLines 差异前后保留的上下文行数
+const diffContextLines = 3
+
+// SkillFieldDiff 技能单个字段的差异
+// 详细说明只给出统一diff格式的逐行差异，其他字段给出新旧值
+type SkillFieldDiff struct {
+	Field string `json:"field"`
+	From  string `json:"from,omitempty"`
+	To    string `json:"to,omitempty"`
+	Diff  string `json:"diff,omitempty"`
+}
+
+// SkillRevisionDiff 两个修订之间的差异
+type SkillRevisionDiff struct {
+	SkillID uint                  `json:"skillId"`
+	From    *models.SkillRevision `json:"from"`
+	To      *models.SkillRevision `json:"to"`
+	Changes []SkillFieldDiff      `json:"changes"`
+}
+
+// recordRevision 保存技能当前内容的修订快照
+// 修订只是辅助记录，保存失败不影响技能本身的保存
+func (s *SkillService) recordRevision(ctx context.Context, skillID uint, source, note string) {
+	if _, _, err := s.repo.CreateSkillRevision(ctx, skillID, source, note); err != nil {
+		logx.Warn("保存技能修订失败: skill=%d, source=%s, %v", skillID, source, err)
+	}
+}
+
+// ListSkillRevisions 获取技能的修订列表（不含详细说明），按修订号倒序
+func (s *SkillService) ListSkillRevisions(ctx context.Context, skillID uint) ([]models.SkillRevision, error) {
+	if _, err := s.repo.GetSkillByID(ctx, skillID); err != nil {
+		return nil, errors.NewNotFoundError(errors.ErrCodeSkillNotFound, "技能不存在", err)
+	}
+	revisions, err := s.repo.ListSkillRevisions(ctx, skillID)
+	if err != nil {
+		return nil, errors.NewSkillError(errors.ErrCodeSkillRevision, "获取技能修订列表失败", err)
+	}
+	return revisions, nil
+}
+
+// GetSkillRevision 获取技能的指定修订，revision为0时返回最新修订
+func (s *SkillService) GetSkillRevision(ctx context.Context, skillID uint, revision int) (*models.SkillRevision, error) {
+	var result *models.SkillRevision
+	var err error
+	if revision == 0 {
+		result, err = s.repo.GetLatestSkillRevision(ctx, skillID)
+	} else {
+		result, err = s.repo.GetSkillRevision(ctx, skillID, revision)
+	}
+	if err != nil {
+		if stderrors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.NewNotFoundError(errors.ErrCodeSkillRevisionNotFound, fmt.Sprintf("技能修订 %d 不存在", revision), err)
+		}
+		return nil, errors.NewSkillError(errors.ErrCodeSkillRevision, "获取技能修订失败", err)
+	}
+	return result, nil
+}
+
+// DiffSkillRevisions 对比技能的两个修订
+// to为0时对比最新修订，from为0时对比to的上一个修订
+func (s *SkillService) DiffSkillRevisions(ctx context.Context, skillID uint, from, to int) (*SkillRevisionDiff, error) {
+	toRevision, err := s.GetSkillRevision(ctx, skillID, to)
+	if err != nil {
+		return nil, err
+	}
+	if from == 0 {
+		from = toRevision.Revision - 1
+	}
+	if from <= 0 {
+		return nil, errors.NewInvalidParamError(errors.ErrCodeBadRequestParam, fmt.Sprintf("修订 %d 没有更早的修订可对比", toRevision.Revision), nil)
+	}
+	fromRevision, err := s.GetSkillRevision(ctx, skillID, from)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SkillRevisionDiff{
+		SkillID: skillID,
+		From:    fromRevision,
+		To:      toRevision,
+		Changes: DiffSkillRevisionFields(fromRevision, toRevision),
+	}, nil
+}
+
+// RollbackSkill 将技能回滚到指定修订
+// 恢复修订中的全部字段和标签（标签按名称恢复，回收站中的标签会被恢复，已彻底删除的标签重新创建），
+// 字段和标签在同一事务中更新，资源目录变更时同步移动技能文件目录
+// 回收站中的技能需要先恢复，修订内容不符合Agent Skills规范时不能回滚
+// 回滚本身记为一个新修订，可以再次回滚
+func (s *SkillService) RollbackSkill(ctx context.Context, skillID uint, revision int) (*SkillResponse, error) {
+	target, err := s.GetSkillRevision(ctx, skillID, revision)
+	if err != nil {
+		return nil, err
+	}
+	skill, err := s.repo.GetSkillByID(ctx, skillID)
+	if err != nil {
+		return nil, errors.NewNotFoundError(errors.ErrCodeSkillNotFound, "技能不存在", err)
+	}
+	if skill.DeletedAt > 0 {
+		return nil, errors.NewSkillError(errors.ErrCodeSkillRevision, "技能在回收站中，请先恢复后再回滚", nil)
+	}
+
+	oldResourceDir := skill.ResourceDir
+	skill.Name = target.Name
+	skill.ResourceDir = target.ResourceDir
+	skill.Description = target.Description
+	skill.License = target.License
+	skill.Version = target.Version
+	skill.Compatibility = target.Compatibility
+	skill.Metadata = target.Metadata
+	skill.AllowedTools = target.AllowedTools
+	skill.Detail = target.Detail
+	skill.Tags = nil
+	if err := ValidateSkill(skill); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.RenameSkillDir(oldResourceDir, target.ResourceDir); err != nil {
+		return nil, errors.NewSkillError(errors.ErrCodeSkillRevision, "移动技能文件目录失败", err)
+	}
+	if err := s.repo.UpdateSkillWithTags(ctx, skill, target.Tags); err != nil {
+		// 数据库更新失败时还原技能文件目录，还原失败时文件仍在新目录中，需要人工处理
+		if rollbackErr := s.store.RenameSkillDir(target.ResourceDir, oldResourceDir); rollbackErr != nil {
+			logx.Error("技能 %d 回滚失败后还原文件目录 %s → %s 失败: %v", skill.ID, target.ResourceDir, oldResourceDir, rollbackErr)
+			return nil, errors.NewSkillError(errors.ErrCodeSkillRevision,
+				"回滚技能失败，且技能文件目录未能还原到 "+oldResourceDir, stderrors.Join(err, rollbackErr))
+		}
+		return nil, errors.NewSkillError(errors.ErrCodeSkillRevision, "回滚技能失败", err)
+	}
+
+	s.recordRevision(ctx, skill.ID, models.SkillRevisionSourceRollback, fmt.Sprintf("回滚到修订 %d", target.Revision))
+	return s.GetSkill(ctx, skill.ID)
+}
+
+// DiffSkillRevisionFields 对比两个修订的各字段，返回有变化的字段
+func DiffSkillRevisionFields(from, to *models.SkillRevision) []SkillFieldDiff {
+	changes := []SkillFieldDiff{}
+	fields := []struct {
+		name     string
+		from, to string
+	}{
+		{"name", from.Name, to.Name},
+		{"resourceDir", from.ResourceDir, to.ResourceDir},
+		{"description", from.Description, to.Description},
+		{"license", from.License, to.License},
+		{"version", from.Version, to.Version},
+		{"compatibility", from.Compatibility, to.Compatibility},
+		{"metadata", from.Metadata.String(), to.Metadata.String()},
+		{"allowedTools", from.AllowedTools, to.AllowedTools},
+		{"tags", strings.Join(from.Tags, ", "), strings.Join(to.Tags, ", ")},
+	}
+	for _, field := range fields {
+		if field.from != field.to {
+			changes = append(changes, SkillFieldDiff{Field: field.name, From: field.from, To: field.to})
+		}
+	}
+	if from.Detail != to.Detail {
+		changes = append(changes, SkillFieldDiff{Field: "detail", Diff: UnifiedLineDiff(from.Detail, to.Detail)})
+	}
+	return changes
+}
+
+// UnifiedLineDiff 逐行对比两段文本，返回统一diff格式的差异（不含文件头）
+// 以" "开头的行未变化，"-"开头的行被删除，"+"开头的行为新增，每段差异前后保留3行上下文
+func UnifiedLineDiff(a, b string) string {
+	if a == b {
+		return ""
+	}
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	var sb strings.Builder
+	for start := 0; start < len(ops); {
+		// 找到下一处变化
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+		// 向后扩展，两处变化之间的未变化行不超过上下文行数的两倍时合并为一段
+		end := start
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			next := end
+			for next < len(ops) && ops[next].kind == ' ' {
+				next++
+			}
+			if next == len(ops) || next-end > 2*diffContextLines {
+				break
+			}
+			end = next
+		}
+
+		hunkStart := max(start-diffContextLines, 0)
+		hunkEnd := min(end+diffContextLines, len(ops))
+		hunk := ops[hunkStart:hunkEnd]
+
+		aStart, bStart := hunk[0].aLine, hunk[0].bLine
+		var aCount, bCount int
+		for _, op := range hunk {
+			if op.kind != '+' {
+				aCount++
+			}
+			if op.kind != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", aStart, aCount, bStart, bCount)
+		for _, op := range hunk {
+			sb.WriteByte(op.kind)
+			sb.WriteString(op.text)
+			sb.WriteByte('\n')
+		}
+		start = hunkEnd
+	}
+	return sb.String()
+}
+
+// lineOp 逐行对比的一个操作
+type lineOp struct {
+	kind  byte   // ' ' 未变化，'-' 删除，'+' 新增
+	text  string // 行内容
+	aLine int    // 该行之前（含）在旧文本中的行号，从1开始
+	bLine int    // 该行之前（含）在新文本中的行号，从1开始
+}
+
+// diffLines 基于最长公共子序列逐行对比
+func diffLines(a, b []string) []lineOp {
+	n, m := len(a), len(b)
+	ops := make([]lineOp, 0, n+m)
+
+	// 计算量过大时不做逐行对比
+	if n*m > maxDiffCells {
+		for i, line := range a {
+			ops = append(ops, lineOp{kind: '-', text: line, aLine: i + 1, bLine: 1})
+		}
+		for j, line := range b {
+			ops = append(ops, lineOp{kind: '+', text: line, aLine: n + 1, bLine: j + 1})
+		}
+		return ops
+	}
+
+	// lcs[i][j] 为 a[i:] 和 b[j:] 的最长公共子序列长度
+	lcs := make([][]int32, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && a[i] == b[j]:
+			ops = append(ops, lineOp{kind: ' ', text: a[i], aLine: i + 1, bLine: j + 1})
+			i++
+			j++
+		case i < n && (j == m || lcs[i+1][j] >= lcs[i][j+1]):
+			// 同一处变化先输出删除行再输出新增行
+			ops = append(ops, lineOp{kind: '-', text: a[i], aLine: i + 1, bLine: j + 1})
+			i++
+		default:
+			ops = append(ops, lineOp{kind: '+', text: b[j], aLine: i + 1, bLine: j + 1})
+			j++
+		}
+	}
+	return ops
+}
+
+// splitLines 按行拆分文本，忽略末尾换行
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"aiflow/internal/errors"
+	"aiflow/internal/models"
+)
+
+// TestRollbackSkill 测试回滚恢复字段、标签和技能文件目录，以及回收站中的技能和不合规修订的拒绝
+func TestRollbackSkill(t *testing.T) {
+	repo, skillService := newTestSkillService(t)
+	ctx := context.Background()
+
+	skill := createSkill(t, repo, &models.Skill{
+		Name:        "pdf-processing",
+		Description: "Extract text from PDF files. Use when reading PDFs.",
+		Detail:      "# PDF v1",
+		Metadata:    models.SkillMetadata{"version": "1.0"},
+		ResourceDir: "pdf",
+	})
+	if _, err := repo.SetSkillTagsByName(ctx, skill.ID, []string{"pdf", "docs"}); err != nil {
+		t.Fatalf("设置标签失败: %v", err)
+	}
+	if _, err := skillService.store.Save("pdf", "scripts/extract.py", strings.NewReader("print('extract')")); err != nil {
+		t.Fatalf("保存技能文件失败: %v", err)
+	}
+	skillService.recordRevision(ctx, skill.ID, models.SkillRevisionSourceWeb, "")
+
+	if _, err := skillService.UpdateSkill(ctx, UpdateSkillRequest{
+		ID:          skill.ID,
+		Name:        "pdf-tools",
+		ResourceDir: "pdf-tools",
+		Description: "PDF tools. Use when editing PDFs.",
+		Detail:      "# PDF v2",
+	}); err != nil {
+		t.Fatalf("更新技能失败: %v", err)
+	}
+
+	response, err := skillService.RollbackSkill(ctx, skill.ID, 1)
+	if err != nil {
+		t.Fatalf("回滚技能失败: %v", err)
+	}
+	if response.Name != "pdf-processing" || response.ResourceDir != "pdf" || response.Detail != "# PDF v1" || response.Metadata["version"] != "1.0" {
+		t.Errorf("回滚后字段未恢复: %+v", response)
+	}
+	var tags []string
+	for _, tag := range response.Tags {
+		tags = append(tags, tag.Name)
+	}
+	slices.Sort(tags)
+	if !slices.Equal(tags, []string{"docs", "pdf"}) {
+		t.Errorf("回滚后标签未恢复: %v", tags)
+	}
+	if data, err := skillService.store.Read("pdf", "scripts/extract.py", 1<<20); err != nil || string(data) != "print('extract')" {
+		t.Errorf("回滚后技能文件应移回原目录: %q, %v", data, err)
+	}
+	revisions, err := skillService.ListSkillRevisions(ctx, skill.ID)
+	if err != nil || len(revisions) != 3 || revisions[0].Source != models.SkillRevisionSourceRollback {
+		t.Errorf("回滚应记为新修订: %+v, %v", revisions, err)
+	}
+
+	t.Run("修订内容不符合规范", func(t *testing.T) {
+		legacy := createSkill(t, repo, &models.Skill{Name: "Legacy Tool", Description: "Legacy tool.", ResourceDir: "legacy"})
+		skillService.recordRevision(ctx, legacy.ID, models.SkillRevisionSourceWeb, "")
+		legacy.Name = "legacy-tool"
+		if err := repo.UpdateSkill(ctx, legacy); err != nil {
+			t.Fatalf("更新技能失败: %v", err)
+		}
+
+		_, err := skillService.RollbackSkill(ctx, legacy.ID, 1)
+		var appErr *errors.AppError
+		if !stderrors.As(err, &appErr) || appErr.Code != errors.ErrCodeSkillValidate {
+			t.Fatalf("不合规的修订应返回校验错误，实际: %v", err)
+		}
+		if current, _ := repo.GetSkillByID(ctx, legacy.ID); current.Name != "legacy-tool" {
+			t.Errorf("校验失败时不应修改技能: %s", current.Name)
+		}
+	})
+
+	t.Run("回收站中的技能", func(t *testing.T) {
+		if err := repo.DeleteSkill(ctx, skill.ID); err != nil {
+			t.Fatalf("删除技能失败: %v", err)
+		}
+		_, err := skillService.RollbackSkill(ctx, skill.ID, 2)
+		var appErr *errors.AppError
+		if !stderrors.As(err, &appErr) || appErr.Code != errors.ErrCodeSkillRevision || !strings.Contains(appErr.Message, "回收站") {
+			t.Fatalf("回收站中的技能应拒绝回滚，实际: %v", err)
+		}
+		if current, _ := repo.GetSkillByID(ctx, skill.ID); current.Name != "pdf-processing" {
+			t.Errorf("拒绝回滚时不应修改技能: %s", current.Name)
+		}
+	})
+}
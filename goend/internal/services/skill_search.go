@@ -0,0 +1,101 @@
+package services
+
+import (
+	"aiflow/internal/errors"
+	"aiflow/internal/repositories"
+	"context"
+	"strings"
+)
+
+// 技能搜索方式
+const (
+	SkillSearchModeFTS   = "fts"   // FTS5全文检索，按BM25相关度排序并返回摘要
+	SkillSearchModeToken = "token" // 分词索引（SQLite不支持FTS5时使用），按命中分词数排序
+	SkillSearchModeFuzzy = "fuzzy" // 精确搜索无结果时的近似匹配（拼写错误、前缀、拼音），按相似度排序
+)
+
+// SkillSearchMaxLimit 技能搜索单次最多返回的条数
+const SkillSearchMaxLimit = 100
+
+// SkillSearchResult 技能搜索结果
+type SkillSearchResult struct {
+	SkillResponse
+	Score   float64 `json:"score"`             // 相关度得分，越大越相关，仅全文检索时有值
+	Snippet string  `json:"snippet,omitempty"` // 命中位置的摘要，命中的词用**包围，仅全文检索时有值
+	// Matches 近似匹配时各查询词命中的分词和匹配方式，仅近似匹配时有值
+	Matches []repositories.FuzzyMatch `json:"matches,omitempty"`
+}
+
+// SkillSearchResponse 技能搜索响应
+type SkillSearchResponse struct {
+	Mode  string              `json:"mode"`
+	Items []SkillSearchResult `json:"items"`
+}
+
+// SearchSkills 按关键词搜索未删除的技能
+// 优先使用覆盖名称、描述和详细说明的全文检索，不可用时退回只覆盖名称和描述的分词索引
+// 精确搜索没有结果时，在分词索引上近似匹配拼写错误、前缀和拼音
+func (s *SkillService) SearchSkills(ctx context.Context, keyword string, limit int) (*SkillSearchResponse, error) {
+	keyword = strings.TrimSpace(keyword)
+	if keyword == "" {
+		return nil, errors.NewInvalidParamError(errors.ErrCodeBadRequestParam, "搜索关键词不能为空", nil)
+	}
+	if limit <= 0 || limit > SkillSearchMaxLimit {
+		limit = SkillSearchMaxLimit
+	}
+
+	response, err := s.searchSkillsExact(ctx, keyword, limit)
+	if err != nil || len(response.Items) > 0 {
+		return response, err
+	}
+
+	hits, err := s.repo.SearchSkillsFuzzy(ctx, keyword)
+	if err != nil {
+		return nil, errors.NewSkillError(errors.ErrCodeInternalError, "近似搜索技能失败", err)
+	}
+	response.Mode = SkillSearchModeFuzzy
+	for i := range hits[:min(len(hits), limit)] {
+		response.Items = append(response.Items, SkillSearchResult{
+			SkillResponse: convertToSkillResponse(&hits[i].Skill),
+			Score:         hits[i].Score,
+			Matches:       hits[i].Matches,
+		})
+	}
+	return response, nil
+}
+
+// searchSkillsExact 使用全文检索或分词索引精确搜索技能
+func (s *SkillService) searchSkillsExact(ctx context.Context, keyword string, limit int) (*SkillSearchResponse, error) {
+	response := &SkillSearchResponse{Items: []SkillSearchResult{}}
+	if s.repo.FullTextSearchEnabled() {
+		hits, err := s.repo.SearchSkillsFullText(ctx, keyword, limit)
+		if err != nil {
+			return nil, errors.NewSkillError(errors.ErrCodeInternalError, "全文检索技能失败", err)
+		}
+		response.Mode = SkillSearchModeFTS
+		for i := range hits {
+			response.Items = append(response.Items, SkillSearchResult{
+				SkillResponse: convertToSkillResponse(&hits[i].Skill),
+				Score:         hits[i].Score,
+				Snippet:       hits[i].Snippet,
+			})
+		}
+		return response, nil
+	}
+
+	skills, err := s.repo.SearchSkillsByTokens(ctx, keyword)
+	if err != nil {
+		return nil, errors.NewSkillError(errors.ErrCodeInternalError, "搜索技能失败", err)
+	}
+	response.Mode = SkillSearchModeToken
+	for i := range skills {
+		if skills[i].DeletedAt > 0 {
+			continue
+		}
+		if len(response.Items) == limit {
+			break
+		}
+		response.Items = append(response.Items, SkillSearchResult{SkillResponse: convertToSkillResponse(&skills[i])})
+	}
+	return response, nil
+}
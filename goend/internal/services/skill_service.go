@@ -1,49 +1,62 @@
 package services
 
 import (
+	"aiflow/internal/errors"
 	"aiflow/internal/models"
 	"aiflow/internal/repositories"
+	"aiflow/internal/storage"
+	"aiflow/internal/utils/logx"
 	"context"
+	"encoding/json"
+	stderrors "errors"
 	"strconv"
 	"strings"
 	"time"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
 )
 
 // SkillService 技能服务层
 // 处理技能相关的业务逻辑，将业务逻辑从handler中分离
 type SkillService struct {
-	repo *repositories.Repository
+	repo  *repositories.Repository
+	store *storage.SkillFileStore
 }
 
 // SkillResponse 技能响应结构
 type SkillResponse struct {
-	ID            uint         `json:"id"`
-	Name          string       `json:"name"`
-	ResourceDir   string       `json:"resourceDir"`
-	Description   string       `json:"description"`
-	Version       string       `json:"version"`
-	Detail        string       `json:"detail"`
-	License       string       `json:"license"`
-	Compatibility string       `json:"compatibility"`
-	Metadata      string       `json:"metadata"`
-	AllowedTools  string       `json:"allowedTools"`
-	Tags          []models.Tag `json:"tags"`
-	CreatedAt     int64        `json:"createdAt"`
-	UpdatedAt     int64        `json:"updatedAt"`
+	ID            uint                 `json:"id"`
+	Name          string               `json:"name"`
+	ResourceDir   string               `json:"resourceDir"`
+	Description   string               `json:"description"`
+	Version       string               `json:"version"`
+	Detail        string               `json:"detail"`
+	License       string               `json:"license"`
+	Compatibility string               `json:"compatibility"`
+	Metadata      models.SkillMetadata `json:"metadata"`
+	AllowedTools  string               `json:"allowedTools"`
+	Tags          []models.Tag         `json:"tags"`
+	CreatedAt     int64                `json:"createdAt"`
+	UpdatedAt     int64                `json:"updatedAt"`
 }
 
 // NewSkillService 创建技能服务实例
-func NewSkillService(repo *repositories.Repository) *SkillService {
-	return &SkillService{repo: repo}
+func NewSkillService(repo *repositories.Repository, store *storage.SkillFileStore) *SkillService {
+	return &SkillService{repo: repo, store: store}
 }
 
 // ListSkillsRequest 获取技能列表请求参数
 type ListSkillsRequest struct {
-	TagID     uint
-	Page      int
-	PageSize  int
-	StartDate int64
-	EndDate   int64
+	TagID uint
+	// IncludeDescendants 按标签筛选时是否包含所有子标签的技能
+	IncludeDescendants bool
+	Page               int
+	PageSize           int
+	StartDate          int64
+	EndDate            int64
+	// Metadata 元数据筛选条件，技能需满足全部条件
+	Metadata []models.SkillMetadataFilter
 }
 
 // ListSkillsResponse 获取技能列表响应
@@ -52,7 +65,7 @@ type ListSkillsResponse struct {
 	Pagination map[string]interface{} `json:"pagination"`
 }
 
-// ListSkills 获取技能列表（支持分页、标签筛选和日期范围筛选）
+// ListSkills 获取技能列表（支持分页、标签筛选、日期范围筛选和元数据筛选）
 func (s *SkillService) ListSkills(ctx context.Context, req ListSkillsRequest) (*ListSkillsResponse, error) {
 	offset := (req.Page - 1) * req.PageSize
 
@@ -70,36 +83,32 @@ func (s *SkillService) ListSkills(ctx context.Context, req ListSkillsRequest) (*
 		baseQuery = baseQuery.Where("created_at <= ?", req.EndDate)
 	}
 
+	// 添加元数据筛选条件
+	baseQuery = repositories.WhereSkillMetadata(baseQuery, req.Metadata)
+
+	// 按标签筛选，可包含所有子标签
 	if req.TagID > 0 {
-		// 按标签筛选 - 先计算总数
-		countQuery := s.repo.GetDB().WithContext(ctx).Model(&models.Skill{}).
-			Joins("JOIN skill_tags ON skill_tags.skill_id = skills.id").
-			Where("skills.deleted_at = ?", 0).
-			Where("skill_tags.tag_id = ?", req.TagID)
-		countQuery.Count(&total)
-
-		// 再获取数据列表
-		err := s.repo.GetDB().WithContext(ctx).
-			Joins("JOIN skill_tags ON skill_tags.skill_id = skills.id").
-			Where("skills.deleted_at = ?", 0).
-			Where("skill_tags.tag_id = ?", req.TagID).
-			Offset(offset).Limit(req.PageSize).
-			Preload("Tags").
-			Find(&skills).Error
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		// 计算总数
-		baseQuery.Count(&total)
-		// 获取所有技能
-		err := baseQuery.
-			Offset(offset).Limit(req.PageSize).
-			Preload("Tags").
-			Find(&skills).Error
-		if err != nil {
-			return nil, err
+		tagIDs := []uint{req.TagID}
+		if req.IncludeDescendants {
+			descendantIDs, err := s.repo.ListTagDescendantIDs(ctx, req.TagID)
+			if err != nil {
+				return nil, err
+			}
+			tagIDs = descendantIDs
 		}
+		baseQuery = baseQuery.Where("id IN (?)",
+			s.repo.GetDB().Model(&models.SkillTag{}).Select("skill_id").Where("tag_id IN ?", tagIDs))
+	}
+
+	// 计算总数
+	baseQuery.Count(&total)
+	// 获取技能列表
+	err := baseQuery.
+		Offset(offset).Limit(req.PageSize).
+		Preload("Tags", repositories.LiveTags).
+		Find(&skills).Error
+	if err != nil {
+		return nil, err
 	}
 
 	// 转换响应格式
@@ -123,16 +132,16 @@ func (s *SkillService) ListSkills(ctx context.Context, req ListSkillsRequest) (*
 
 // CreateSkillRequest 创建技能请求参数
 type CreateSkillRequest struct {
-	Name          string `json:"name"`
-	ResourceDir   string `json:"resourceDir"`
-	Description   string `json:"description"`
-	Version       string `json:"version"`
-	Detail        string `json:"detail"`
-	License       string `json:"license"`
-	Compatibility string `json:"compatibility"`
-	Metadata      string `json:"metadata"`
-	AllowedTools  string `json:"allowedTools"`
-	Tags          []uint `json:"tags"`
+	Name          string               `json:"name"`
+	ResourceDir   string               `json:"resourceDir"`
+	Description   string               `json:"description"`
+	Version       string               `json:"version"`
+	Detail        string               `json:"detail"`
+	License       string               `json:"license"`
+	Compatibility string               `json:"compatibility"`
+	Metadata      models.SkillMetadata `json:"metadata"`
+	AllowedTools  string               `json:"allowedTools"`
+	Tags          []uint               `json:"tags"`
 }
 
 // CreateSkill 创建技能
@@ -152,6 +161,10 @@ func (s *SkillService) CreateSkill(ctx context.Context, req CreateSkillRequest)
 		UpdatedAt:     timestamp,
 	}
 
+	if err := ValidateSkill(skill); err != nil {
+		return nil, err
+	}
+
 	if err := s.repo.CreateSkill(ctx, skill); err != nil {
 		return nil, err
 	}
@@ -163,6 +176,8 @@ func (s *SkillService) CreateSkill(ctx context.Context, req CreateSkillRequest)
 		}
 	}
 
+	s.recordRevision(ctx, skill.ID, models.SkillRevisionSourceWeb, "")
+
 	// 获取技能详情
 	createdSkill, err := s.repo.GetSkillByID(ctx, skill.ID)
 	if err != nil {
@@ -186,26 +201,31 @@ func (s *SkillService) GetSkill(ctx context.Context, id uint) (*SkillResponse, e
 // UpdateSkillRequest 更新技能请求参数
 type UpdateSkillRequest struct {
 	ID            uint
-	Name          string `json:"name"`
-	ResourceDir   string `json:"resourceDir"`
-	Description   string `json:"description"`
-	Version       string `json:"version"`
-	Detail        string `json:"detail"`
-	License       string `json:"license"`
-	Compatibility string `json:"compatibility"`
-	Metadata      string `json:"metadata"`
-	AllowedTools  string `json:"allowedTools"`
-	Tags          []uint `json:"tags"`
+	Name          string               `json:"name"`
+	ResourceDir   string               `json:"resourceDir"`
+	Description   string               `json:"description"`
+	Version       string               `json:"version"`
+	Detail        string               `json:"detail"`
+	License       string               `json:"license"`
+	Compatibility string               `json:"compatibility"`
+	Metadata      models.SkillMetadata `json:"metadata"`
+	AllowedTools  string               `json:"allowedTools"`
+	Tags          []uint               `json:"tags"`
 }
 
 // UpdateSkill 更新技能
 func (s *SkillService) UpdateSkill(ctx context.Context, req UpdateSkillRequest) (*SkillResponse, error) {
+	if err := ValidateSkill(&models.Skill{Name: req.Name, Description: req.Description, Compatibility: req.Compatibility}); err != nil {
+		return nil, err
+	}
+
 	skill, err := s.repo.GetSkillByID(ctx, req.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	// 更新技能信息
+	// 更新技能信息，资源目录变更时同步移动技能文件目录
+	oldResourceDir := skill.ResourceDir
 	skill.Name = req.Name
 	skill.ResourceDir = req.ResourceDir
 	skill.Description = req.Description
@@ -217,7 +237,7 @@ func (s *SkillService) UpdateSkill(ctx context.Context, req UpdateSkillRequest)
 	skill.AllowedTools = req.AllowedTools
 	skill.UpdatedAt = time.Now().UnixMilli()
 
-	if err := s.repo.UpdateSkill(ctx, skill); err != nil {
+	if err := s.UpdateSkillAndMoveDir(ctx, skill, oldResourceDir); err != nil {
 		return nil, err
 	}
 
@@ -231,6 +251,8 @@ func (s *SkillService) UpdateSkill(ctx context.Context, req UpdateSkillRequest)
 		}
 	}
 
+	s.recordRevision(ctx, skill.ID, models.SkillRevisionSourceWeb, "")
+
 	// 获取更新后的技能详情
 	updatedSkill, err := s.repo.GetSkillByID(ctx, skill.ID)
 	if err != nil {
@@ -241,6 +263,23 @@ func (s *SkillService) UpdateSkill(ctx context.Context, req UpdateSkillRequest)
 	return &response, nil
 }
 
+// UpdateSkillAndMoveDir 保存已存在技能的修改，资源目录从oldResourceDir变更时同步移动技能文件目录
+// 数据库更新失败时还原技能文件目录，还原失败时文件仍在新目录中，记录日志并在返回的错误中说明，需要人工处理
+func (s *SkillService) UpdateSkillAndMoveDir(ctx context.Context, skill *models.Skill, oldResourceDir string) error {
+	if err := s.store.RenameSkillDir(oldResourceDir, skill.ResourceDir); err != nil {
+		return errors.NewSkillError(errors.ErrCodeSkillUpdate, "移动技能文件目录失败", err)
+	}
+	if err := s.repo.UpdateSkill(ctx, skill); err != nil {
+		if rollbackErr := s.store.RenameSkillDir(skill.ResourceDir, oldResourceDir); rollbackErr != nil {
+			logx.Error("技能 %d 更新失败后还原文件目录 %s → %s 失败: %v", skill.ID, skill.ResourceDir, oldResourceDir, rollbackErr)
+			return errors.NewSkillError(errors.ErrCodeSkillUpdate,
+				"更新技能失败，且技能文件目录未能还原到 "+oldResourceDir, stderrors.Join(err, rollbackErr))
+		}
+		return errors.NewSkillError(errors.ErrCodeSkillUpdate, "更新技能失败", err)
+	}
+	return nil
+}
+
 // DeleteSkill 删除技能（伪删除）
 func (s *SkillService) DeleteSkill(ctx context.Context, id uint) error {
 	return s.repo.DeleteSkill(ctx, id)
@@ -258,7 +297,7 @@ func (s *SkillService) ListDeletedSkills(ctx context.Context, page, pageSize int
 	baseQuery.Count(&total)
 	err := baseQuery.
 		Offset(offset).Limit(pageSize).
-		Preload("Tags").
+		Preload("Tags", repositories.LiveTags).
 		Find(&skills).Error
 
 	if err != nil {
@@ -288,11 +327,28 @@ func (s *SkillService) RestoreSkill(ctx context.Context, id uint) error {
 	return s.repo.RestoreSkill(ctx, id)
 }
 
-// PermanentDeleteSkill 彻底删除技能
+// PermanentDeleteSkill 彻底删除回收站中的技能及其文件目录，技能不存在或不在回收站中时返回 gorm.ErrRecordNotFound
+// 先在事务中删除数据库记录再删除文件目录：记录删除失败时技能和文件都留在回收站中，可以重试或恢复；
+// 目录删除失败时只记录日志，留下的孤立目录需要人工清理
 func (s *SkillService) PermanentDeleteSkill(ctx context.Context, id uint) error {
-	// 先删除标签关联关系
-	s.repo.GetDB().WithContext(ctx).Where("skill_id = ?", id).Delete(&models.SkillTag{})
-	return s.repo.PermanentDeleteSkill(ctx, id)
+	skill, err := s.repo.GetSkillByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if skill.DeletedAt == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	if err := s.repo.PermanentDeleteSkill(ctx, id); err != nil {
+		return errors.NewSkillError(errors.ErrCodeSkillDelete, "彻底删除技能失败", err)
+	}
+
+	if skill.ResourceDir != "" {
+		if err := s.store.RemoveSkillDir(skill.ResourceDir); err != nil {
+			logx.Error("彻底删除技能 %d 后删除文件目录 %s 失败，需要人工清理: %v", id, skill.ResourceDir, err)
+		}
+	}
+	return nil
 }
 
 // ExportSkill 导出技能为MD格式
@@ -309,46 +365,69 @@ func (s *SkillService) ExportSkill(ctx context.Context, id uint) (string, string
 		tagNames = append(tagNames, tag.Name)
 	}
 
-	// 生成MD格式内容
+	filename := "SKILL.md"
+	return BuildSkillMarkdown(skill, tagNames), filename, nil
+}
+
+// emptySkillDetail 技能没有详细说明时SKILL.md中写入的占位文本
+const emptySkillDetail = "暂无详细说明"
+
+// BuildSkillMarkdown 生成技能的SKILL.md内容（YAML头 + 详细说明）
+// 导出接口和MCP资源共用该格式，YAML头中的字段值都经过yamlScalar转义，保证能被ParseSkillMarkdown重新解析
+func BuildSkillMarkdown(skill *models.Skill, tagNames []string) string {
 	var mdContent strings.Builder
 
 	mdContent.WriteString("---\n")
-	mdContent.WriteString("name: " + skill.Name + "\n")
-	mdContent.WriteString("resource_dir: " + skill.ResourceDir + "\n")
-	mdContent.WriteString("description: " + skill.Description + "\n")
+	mdContent.WriteString("name: " + yamlScalar(skill.Name) + "\n")
+	mdContent.WriteString("resource_dir: " + yamlScalar(skill.ResourceDir) + "\n")
+	mdContent.WriteString("description: " + yamlScalar(skill.Description) + "\n")
 
 	if len(tagNames) > 0 {
 		mdContent.WriteString("tags:\n")
 		for _, tagName := range tagNames {
-			mdContent.WriteString("  - " + tagName + "\n")
+			mdContent.WriteString("  - " + yamlScalar(tagName) + "\n")
 		}
 	}
 
 	if skill.License != "" {
-		mdContent.WriteString("license: " + skill.License + "\n")
+		mdContent.WriteString("license: " + yamlScalar(skill.License) + "\n")
 	}
 
 	if skill.Compatibility != "" {
-		mdContent.WriteString("compatibility: " + skill.Compatibility + "\n")
+		mdContent.WriteString("compatibility: " + yamlScalar(skill.Compatibility) + "\n")
 	}
 
-	if skill.Metadata != "" {
-		mdContent.WriteString("metadata: " + skill.Metadata + "\n")
+	if len(skill.Metadata) > 0 {
+		mdContent.WriteString("metadata:\n")
+		for _, key := range skill.Metadata.Keys() {
+			mdContent.WriteString("  " + yamlScalar(key) + ": " + yamlScalar(skill.Metadata[key]) + "\n")
+		}
 	}
 
 	if skill.AllowedTools != "" {
-		mdContent.WriteString("allowed_tools: " + skill.AllowedTools + "\n")
+		mdContent.WriteString("allowed_tools: " + yamlScalar(skill.AllowedTools) + "\n")
 	}
 
 	mdContent.WriteString("\n---\n\n")
 	if skill.Detail != "" {
 		mdContent.WriteString(skill.Detail + "\n")
 	} else {
-		mdContent.WriteString("暂无详细说明\n")
+		mdContent.WriteString(emptySkillDetail + "\n")
 	}
 
-	filename := "SKILL.md"
-	return mdContent.String(), filename, nil
+	return mdContent.String()
+}
+
+// yamlScalar 将字符串转换为单行YAML标量，包含特殊字符或会被解析为其他类型时加引号
+// 多行文本使用JSON字符串写法（也是合法的YAML双引号字符串），避免块标量破坏缩进
+func yamlScalar(value string) string {
+	if !strings.ContainsAny(value, "\r\n") {
+		if data, err := yaml.Marshal(value); err == nil {
+			return strings.TrimSuffix(string(data), "\n")
+		}
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
 }
 
 // convertToSkillResponse 将模型转换为响应结构
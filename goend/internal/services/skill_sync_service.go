@@ -0,0 +1,593 @@
+package services
+
+import (
+	"aiflow/internal/config"
+	"aiflow/internal/errors"
+	"aiflow/internal/models"
+	"aiflow/internal/repositories"
+	"aiflow/internal/utils/logx"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	stderrors "errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 同步冲突处理方式
+const (
+	SyncKeepFile = "file" // 以SKILL.md文件为准更新数据库
+	SyncKeepDB   = "db"   // 以数据库为准写回SKILL.md文件
+)
+
+// SkillSyncService 技能目录同步服务
+// 同步目录结构为 dir/<技能名>/SKILL.md，启动时扫描一次，配置轮询间隔后定时扫描
+// 每个技能文件夹记录上次同步时文件和数据库内容的哈希：只有一方变化时同步到另一方，双方都变化时记为冲突等待人工处理
+// 判断双方内容是否一致时按解析出的字段比较，手写的SKILL.md不需要与生成的内容逐字节相同
+type SkillSyncService struct {
+	repo         *repositories.Repository
+	skillService *SkillService
+	cfg          config.SyncConfig
+	dir          string
+
+	// runMu 保证同一时间只有一个同步在执行
+	runMu sync.Mutex
+
+	// statusMu 保护上次同步结果
+	statusMu   sync.RWMutex
+	lastResult *SyncResult
+	lastError  string
+}
+
+// SyncFailure 单个技能文件夹的同步失败信息
+type SyncFailure struct {
+	Folder string `json:"folder"`
+	Reason string `json:"reason"`
+}
+
+// SyncResult 一次同步的结果
+type SyncResult struct {
+	StartedAt  int64         `json:"startedAt"`
+	FinishedAt int64         `json:"finishedAt"`
+	Created    []string      `json:"created"`   // 从文件新建的技能
+	Updated    []string      `json:"updated"`   // 按文件更新的技能
+	Written    []string      `json:"written"`   // 写回文件的技能
+	Conflicts  []string      `json:"conflicts"` // 检测到冲突的技能文件夹
+	Failed     []SyncFailure `json:"failed"`    // 同步失败的技能文件夹
+}
+
+// SyncStatusResponse 同步状态响应
+type SyncStatusResponse struct {
+	Enabled      bool        `json:"enabled"`
+	Dir          string      `json:"dir"`
+	PollInterval int         `json:"pollInterval"`
+	WriteBack    bool        `json:"writeBack"`
+	Running      bool        `json:"running"`
+	Conflicts    int         `json:"conflicts"`
+	LastResult   *SyncResult `json:"lastResult"`
+	LastError    string      `json:"lastError,omitempty"`
+}
+
+// NewSkillSyncService 创建技能目录同步服务实例
+// 同步目录为空时同步服务不启用
+func NewSkillSyncService(repo *repositories.Repository, skillService *SkillService, cfg config.SyncConfig) *SkillSyncService {
+	dir := cfg.Dir
+	if dir != "" {
+		if absDir, err := filepath.Abs(dir); err == nil {
+			dir = absDir
+		}
+	}
+	return &SkillSyncService{repo: repo, skillService: skillService, cfg: cfg, dir: dir}
+}
+
+// Enabled 是否启用了目录同步
+func (s *SkillSyncService) Enabled() bool {
+	return s.dir != ""
+}
+
+// Start 启动后台同步：立即同步一次，配置了轮询间隔时按间隔定时同步，ctx取消后停止
+func (s *SkillSyncService) Start(ctx context.Context) {
+	if !s.Enabled() {
+		return
+	}
+
+	go func() {
+		s.runInBackground(ctx)
+		if s.cfg.PollInterval <= 0 {
+			return
+		}
+
+		ticker := time.NewTicker(time.Duration(s.cfg.PollInterval) * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.runInBackground(ctx)
+			}
+		}
+	}()
+}
+
+// runInBackground 后台执行一次同步，上一次同步未结束时跳过
+func (s *SkillSyncService) runInBackground(ctx context.Context) {
+	result, err := s.Sync(ctx)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok && appErr.Code == errors.ErrCodeSkillSyncRunning {
+			return
+		}
+		logx.Error("技能目录同步失败: %v", err)
+		return
+	}
+	if len(result.Created)+len(result.Updated)+len(result.Written)+len(result.Conflicts)+len(result.Failed) > 0 {
+		logx.Info("技能目录同步完成: 新建%d 更新%d 写回%d 冲突%d 失败%d",
+			len(result.Created), len(result.Updated), len(result.Written), len(result.Conflicts), len(result.Failed))
+	}
+}
+
+// Sync 立即执行一次同步
+func (s *SkillSyncService) Sync(ctx context.Context) (*SyncResult, error) {
+	if !s.Enabled() {
+		return nil, errors.NewSkillError(errors.ErrCodeSkillSyncDisabled, "", nil)
+	}
+	if !s.runMu.TryLock() {
+		return nil, errors.NewSkillError(errors.ErrCodeSkillSyncRunning, "", nil)
+	}
+	defer s.runMu.Unlock()
+
+	result, err := s.syncAll(ctx)
+
+	s.statusMu.Lock()
+	s.lastResult = result
+	s.lastError = ""
+	if err != nil {
+		s.lastError = err.Error()
+	}
+	s.statusMu.Unlock()
+
+	if err != nil {
+		return nil, errors.NewSkillError(errors.ErrCodeSkillSync, "技能目录同步失败: "+err.Error(), err)
+	}
+	return result, nil
+}
+
+// Status 获取同步配置和上次同步结果
+func (s *SkillSyncService) Status(ctx context.Context) *SyncStatusResponse {
+	status := &SyncStatusResponse{
+		Enabled:      s.Enabled(),
+		Dir:          s.dir,
+		PollInterval: s.cfg.PollInterval,
+		WriteBack:    s.cfg.WriteBack,
+	}
+
+	if s.runMu.TryLock() {
+		s.runMu.Unlock()
+	} else {
+		status.Running = true
+	}
+
+	if conflicts, err := s.repo.ListSkillSyncStates(ctx, models.SkillSyncStatusConflict); err == nil {
+		status.Conflicts = len(conflicts)
+	}
+
+	s.statusMu.RLock()
+	status.LastResult = s.lastResult
+	status.LastError = s.lastError
+	s.statusMu.RUnlock()
+	return status
+}
+
+// ListConflicts 获取冲突列表
+func (s *SkillSyncService) ListConflicts(ctx context.Context) ([]models.SkillSyncState, error) {
+	return s.repo.ListSkillSyncStates(ctx, models.SkillSyncStatusConflict)
+}
+
+// ResolveConflict 处理同步冲突
+// keep为file时以SKILL.md文件为准更新数据库，为db时以数据库为准覆盖SKILL.md文件
+func (s *SkillSyncService) ResolveConflict(ctx context.Context, id uint, keep string) (*models.SkillSyncState, error) {
+	if !s.Enabled() {
+		return nil, errors.NewSkillError(errors.ErrCodeSkillSyncDisabled, "", nil)
+	}
+	if keep != SyncKeepFile && keep != SyncKeepDB {
+		return nil, errors.NewInvalidParamError(errors.ErrCodeBadRequestParam, fmt.Sprintf("无效的冲突处理方式 '%s'，有效值: %s, %s", keep, SyncKeepFile, SyncKeepDB), nil)
+	}
+
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	state, err := s.repo.GetSkillSyncStateByID(ctx, id)
+	if err != nil || state.Status != models.SkillSyncStatusConflict {
+		return nil, errors.NewNotFoundError(errors.ErrCodeSkillSyncConflict, "", err)
+	}
+
+	if keep == SyncKeepFile {
+		content, err := os.ReadFile(s.skillFilePath(state.Folder))
+		if err != nil {
+			return nil, errors.NewSkillError(errors.ErrCodeSkillSync, "读取SKILL.md失败", err)
+		}
+		if err := s.importFile(ctx, state, content); err != nil {
+			return nil, errors.NewSkillError(errors.ErrCodeSkillSync, err.Error(), err)
+		}
+	} else {
+		skill, err := s.stateSkill(ctx, state, "")
+		if err != nil || skill == nil || skill.DeletedAt > 0 {
+			return nil, errors.NewNotFoundError(errors.ErrCodeSkillNotFound, "数据库中技能不存在或已删除", err)
+		}
+		if err := s.writeFile(ctx, state, skill); err != nil {
+			return nil, errors.NewSkillError(errors.ErrCodeSkillSync, err.Error(), err)
+		}
+	}
+	return state, nil
+}
+
+// syncAll 扫描同步目录并逐个同步技能文件夹，开启写回时再将尚未同步过的技能写成文件夹
+func (s *SkillSyncService) syncAll(ctx context.Context) (*SyncResult, error) {
+	result := &SyncResult{
+		StartedAt: time.Now().UnixMilli(),
+		Created:   []string{},
+		Updated:   []string{},
+		Written:   []string{},
+		Conflicts: []string{},
+		Failed:    []SyncFailure{},
+	}
+	defer func() {
+		result.FinishedAt = time.Now().UnixMilli()
+	}()
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return result, err
+	}
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return result, err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		content, err := os.ReadFile(s.skillFilePath(entry.Name()))
+		if err != nil {
+			if !stderrors.Is(err, fs.ErrNotExist) {
+				result.Failed = append(result.Failed, SyncFailure{Folder: entry.Name(), Reason: err.Error()})
+			}
+			continue
+		}
+		if err := s.syncFolder(ctx, entry.Name(), content, result); err != nil {
+			result.Failed = append(result.Failed, SyncFailure{Folder: entry.Name(), Reason: err.Error()})
+		}
+	}
+
+	if s.cfg.WriteBack {
+		if err := s.writeNewSkills(ctx, result); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// syncFolder 同步单个技能文件夹
+func (s *SkillSyncService) syncFolder(ctx context.Context, folder string, content []byte, result *SyncResult) error {
+	parsed, tagNames, err := ParseSkillMarkdown(content)
+	if err != nil {
+		return err
+	}
+	fileHash := hashContent(content)
+
+	state, err := s.repo.GetSkillSyncStateByFolder(ctx, folder)
+	if err != nil && !stderrors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	// 首次同步该文件夹
+	if state == nil {
+		state = &models.SkillSyncState{Folder: folder}
+		skill, err := s.findSkill(ctx, parsed.Name)
+		if err != nil {
+			return err
+		}
+		switch {
+		case skill == nil:
+			if err := s.importFile(ctx, state, content); err != nil {
+				return err
+			}
+			result.Created = append(result.Created, state.SkillName)
+		case skill.DeletedAt > 0:
+			return s.markConflict(ctx, state, skill, fileHash, "", "数据库中的同名技能在回收站中", result)
+		default:
+			dbHash := hashSkill(skill)
+			same, err := s.sameContent(ctx, parsed, tagNames, skill)
+			if err != nil {
+				return err
+			}
+			if !same {
+				return s.markConflict(ctx, state, skill, fileHash, dbHash, "首次同步时数据库中已存在内容不同的同名技能", result)
+			}
+			return s.markSynced(ctx, state, skill, fileHash, dbHash)
+		}
+		return nil
+	}
+
+	skill, err := s.stateSkill(ctx, state, parsed.Name)
+	if err != nil {
+		return err
+	}
+	dbHash := ""
+	same := false
+	if skill != nil && skill.DeletedAt == 0 {
+		dbHash = hashSkill(skill)
+		if same, err = s.sameContent(ctx, parsed, tagNames, skill); err != nil {
+			return err
+		}
+	}
+
+	// 双方内容一致时直接记为已同步（包括冲突被手工消除的情况）
+	if same {
+		if state.Status != models.SkillSyncStatusSynced || state.FileHash != fileHash || state.DBHash != dbHash {
+			return s.markSynced(ctx, state, skill, fileHash, dbHash)
+		}
+		return nil
+	}
+	if state.Status == models.SkillSyncStatusConflict {
+		result.Conflicts = append(result.Conflicts, folder)
+		return nil
+	}
+
+	fileChanged := fileHash != state.FileHash
+	dbChanged := dbHash != state.DBHash
+	switch {
+	case fileChanged && dbChanged:
+		reason := "文件和数据库在上次同步后都有修改"
+		if dbHash == "" {
+			reason = "技能已在数据库中删除，但文件在上次同步后有修改"
+		}
+		return s.markConflict(ctx, state, skill, fileHash, dbHash, reason, result)
+	case fileChanged:
+		if err := s.importFile(ctx, state, content); err != nil {
+			return err
+		}
+		result.Updated = append(result.Updated, state.SkillName)
+	case dbChanged && dbHash != "" && s.cfg.WriteBack:
+		if err := s.writeFile(ctx, state, skill); err != nil {
+			return err
+		}
+		result.Written = append(result.Written, state.SkillName)
+	}
+	// 数据库中的技能被删除时不恢复技能也不删除文件
+	return nil
+}
+
+// writeNewSkills 将从未同步过的技能写成 dir/<技能名>/SKILL.md
+func (s *SkillSyncService) writeNewSkills(ctx context.Context, result *SyncResult) error {
+	states, err := s.repo.ListSkillSyncStates(ctx, "")
+	if err != nil {
+		return err
+	}
+	synced := make(map[uint]bool, len(states))
+	folders := make(map[string]bool, len(states))
+	for _, state := range states {
+		synced[state.SkillID] = true
+		folders[state.Folder] = true
+	}
+
+	skills, err := s.repo.ListAllSkills(ctx)
+	if err != nil {
+		return err
+	}
+	for _, skill := range skills {
+		if skill.DeletedAt > 0 || synced[skill.ID] {
+			continue
+		}
+		folder := skill.Name
+		if !isValidSyncFolder(folder) || folders[folder] {
+			result.Failed = append(result.Failed, SyncFailure{Folder: folder, Reason: "技能名称不能作为文件夹名或文件夹已被占用"})
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(s.dir, folder)); err == nil {
+			// 文件夹已存在但没有SKILL.md，不覆盖用户目录
+			continue
+		}
+
+		fullSkill, err := s.repo.GetSkillByID(ctx, skill.ID)
+		if err != nil {
+			return err
+		}
+		if err := s.writeFile(ctx, &models.SkillSyncState{Folder: folder}, fullSkill); err != nil {
+			result.Failed = append(result.Failed, SyncFailure{Folder: folder, Reason: err.Error()})
+			continue
+		}
+		result.Written = append(result.Written, skill.Name)
+	}
+	return nil
+}
+
+// importFile 以SKILL.md内容更新数据库，并记录同步状态
+func (s *SkillSyncService) importFile(ctx context.Context, state *models.SkillSyncState, content []byte) error {
+	skill, _, err := s.skillService.ImportSkillMarkdown(ctx, content)
+	if err != nil {
+		return err
+	}
+	skill, err = s.repo.GetSkillByID(ctx, skill.ID)
+	if err != nil {
+		return err
+	}
+	return s.markSynced(ctx, state, skill, hashContent(content), hashSkill(skill))
+}
+
+// writeFile 将数据库中的技能写回SKILL.md，并记录同步状态
+// 先写入临时文件再重命名，避免写入中断留下不完整的文件
+func (s *SkillSyncService) writeFile(ctx context.Context, state *models.SkillSyncState, skill *models.Skill) error {
+	content := []byte(buildSkillMarkdown(skill))
+	path := s.skillFilePath(state.Folder)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), ".SKILL.md-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+
+	_, err = tmpFile.Write(content)
+	closeErr := tmpFile.Close()
+	if err != nil {
+		return err
+	}
+	if closeErr != nil {
+		return closeErr
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+
+	hash := hashContent(content)
+	return s.markSynced(ctx, state, skill, hash, hash)
+}
+
+// markSynced 记录同步完成
+func (s *SkillSyncService) markSynced(ctx context.Context, state *models.SkillSyncState, skill *models.Skill, fileHash, dbHash string) error {
+	state.SkillID = skill.ID
+	state.SkillName = skill.Name
+	state.FileHash = fileHash
+	state.DBHash = dbHash
+	state.Status = models.SkillSyncStatusSynced
+	state.ConflictReason = ""
+	state.SyncedAt = time.Now().UnixMilli()
+	return s.repo.SaveSkillSyncState(ctx, state)
+}
+
+// markConflict 记录同步冲突，保留上次同步时的哈希，不修改文件和数据库
+// 首次同步时没有上次同步的哈希，记录当前哈希
+func (s *SkillSyncService) markConflict(ctx context.Context, state *models.SkillSyncState, skill *models.Skill, fileHash, dbHash, reason string, result *SyncResult) error {
+	if state.ID == 0 {
+		state.FileHash = fileHash
+		state.DBHash = dbHash
+	}
+	if skill != nil {
+		state.SkillID = skill.ID
+		state.SkillName = skill.Name
+	}
+	state.Status = models.SkillSyncStatusConflict
+	state.ConflictReason = reason
+	if err := s.repo.SaveSkillSyncState(ctx, state); err != nil {
+		return err
+	}
+	result.Conflicts = append(result.Conflicts, state.Folder)
+	logx.Warn("技能目录同步冲突: %s, %s", state.Folder, reason)
+	return nil
+}
+
+// sameContent 判断SKILL.md解析出的内容与数据库中的技能是否一致
+// 按字段比较名称、描述、详细说明、许可证、兼容性、元数据、允许的工具和标签，忽略格式、字段顺序和首尾空白；
+// 文件中的标签按名称或别名解析后比较，文件没有声明标签时导入不会修改标签，不比较标签
+func (s *SkillSyncService) sameContent(ctx context.Context, parsed *models.Skill, tagNames []string, skill *models.Skill) (bool, error) {
+	if parsed.Name != skill.Name ||
+		parsed.Description != skill.Description ||
+		normalizeSkillDetail(parsed.Detail) != normalizeSkillDetail(skill.Detail) ||
+		strings.TrimSpace(parsed.License) != strings.TrimSpace(skill.License) ||
+		strings.TrimSpace(parsed.Compatibility) != strings.TrimSpace(skill.Compatibility) ||
+		strings.TrimSpace(parsed.AllowedTools) != strings.TrimSpace(skill.AllowedTools) ||
+		!maps.Equal(parsed.Metadata, skill.Metadata) {
+		return false, nil
+	}
+	if len(tagNames) == 0 {
+		return true, nil
+	}
+
+	tags, missing, err := s.repo.ResolveTagNames(ctx, tagNames)
+	if err != nil {
+		return false, err
+	}
+	if len(missing) > 0 {
+		return false, nil
+	}
+	fileTagIDs := make([]uint, 0, len(tags))
+	for _, tag := range tags {
+		fileTagIDs = append(fileTagIDs, tag.ID)
+	}
+	dbTagIDs := make([]uint, 0, len(skill.Tags))
+	for _, tag := range skill.Tags {
+		dbTagIDs = append(dbTagIDs, tag.ID)
+	}
+	slices.Sort(fileTagIDs)
+	slices.Sort(dbTagIDs)
+	return slices.Equal(slices.Compact(fileTagIDs), slices.Compact(dbTagIDs)), nil
+}
+
+// normalizeSkillDetail 规范化详细说明用于比较：去除首尾空白，生成SKILL.md时空说明写作的占位文本视为空
+func normalizeSkillDetail(detail string) string {
+	detail = strings.TrimSpace(detail)
+	if detail == emptySkillDetail {
+		return ""
+	}
+	return detail
+}
+
+// findSkill 按名称查找技能（包括回收站中的技能），不存在时返回nil
+func (s *SkillSyncService) findSkill(ctx context.Context, name string) (*models.Skill, error) {
+	skill, err := s.repo.GetSkillByName(ctx, name)
+	if stderrors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return skill, err
+}
+
+// stateSkill 获取同步状态关联的技能，关联技能已被彻底删除时按名称查找
+func (s *SkillSyncService) stateSkill(ctx context.Context, state *models.SkillSyncState, name string) (*models.Skill, error) {
+	skill, err := s.repo.GetSkillByID(ctx, state.SkillID)
+	if err == nil {
+		return skill, nil
+	}
+	if !stderrors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if name == "" {
+		name = state.SkillName
+	}
+	return s.findSkill(ctx, name)
+}
+
+// skillFilePath 获取技能文件夹下SKILL.md的路径
+func (s *SkillSyncService) skillFilePath(folder string) string {
+	return filepath.Join(s.dir, folder, "SKILL.md")
+}
+
+// isValidSyncFolder 判断技能名称能否直接作为同步目录下的文件夹名
+func isValidSyncFolder(name string) bool {
+	return name != "" && !strings.HasPrefix(name, ".") && !strings.ContainsAny(name, `/\:*?"<>|`)
+}
+
+// buildSkillMarkdown 按技能当前标签生成SKILL.md内容
+// 标签按名称排序，保证同一技能生成的内容和哈希稳定
+func buildSkillMarkdown(skill *models.Skill) string {
+	tagNames := make([]string, 0, len(skill.Tags))
+	for _, tag := range skill.Tags {
+		tagNames = append(tagNames, tag.Name)
+	}
+	sort.Strings(tagNames)
+	return BuildSkillMarkdown(skill, tagNames)
+}
+
+// hashSkill 计算数据库技能导出为SKILL.md后的内容哈希
+func hashSkill(skill *models.Skill) string {
+	return hashContent([]byte(buildSkillMarkdown(skill)))
+}
+
+// hashContent 计算内容的SHA-256哈希
+func hashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,297 @@
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"aiflow/internal/config"
+	"aiflow/internal/errors"
+	"aiflow/internal/models"
+	"aiflow/internal/repositories"
+	"aiflow/internal/storage"
+)
+
+// newTestSkillService 创建使用临时数据库和技能目录的技能服务
+func newTestSkillService(t *testing.T) (*repositories.Repository, *SkillService) {
+	t.Helper()
+	repo, err := repositories.NewRepository(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("创建测试仓库失败: %v", err)
+	}
+	store := storage.NewSkillFileStore(filepath.Join(t.TempDir(), "skills"), config.DefaultSkillMaxFileSize)
+	return repo, NewSkillService(repo, store)
+}
+
+// createSkill 在数据库中创建技能
+func createSkill(t *testing.T, repo *repositories.Repository, skill *models.Skill) *models.Skill {
+	t.Helper()
+	if err := repo.CreateSkill(context.Background(), skill); err != nil {
+		t.Fatalf("创建技能%s失败: %v", skill.Name, err)
+	}
+	return skill
+}
+
+// writeSyncFile 写入同步目录下技能文件夹的SKILL.md
+func writeSyncFile(t *testing.T, dir, folder, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, folder), 0755); err != nil {
+		t.Fatalf("创建技能文件夹失败: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, folder, "SKILL.md"), []byte(content), 0644); err != nil {
+		t.Fatalf("写入SKILL.md失败: %v", err)
+	}
+}
+
+// readSyncFile 读取同步目录下技能文件夹的SKILL.md
+func readSyncFile(t *testing.T, dir, folder string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, folder, "SKILL.md"))
+	if err != nil {
+		t.Fatalf("读取SKILL.md失败: %v", err)
+	}
+	return string(data)
+}
+
+// syncStatus 获取技能文件夹的同步状态
+func syncStatus(t *testing.T, repo *repositories.Repository, folder string) *models.SkillSyncState {
+	t.Helper()
+	state, err := repo.GetSkillSyncStateByFolder(context.Background(), folder)
+	if err != nil {
+		t.Fatalf("获取%s的同步状态失败: %v", folder, err)
+	}
+	return state
+}
+
+// mustSync 执行一次同步
+func mustSync(t *testing.T, syncService *SkillSyncService) *SyncResult {
+	t.Helper()
+	result, err := syncService.Sync(context.Background())
+	if err != nil {
+		t.Fatalf("同步失败: %v", err)
+	}
+	return result
+}
+
+// TestSkillSync_FirstSync 测试首次同步：手写的SKILL.md与数据库内容相同时不算冲突，不同或同名技能在回收站中时记为冲突
+func TestSkillSync_FirstSync(t *testing.T) {
+	repo, skillService := newTestSkillService(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	pdf := createSkill(t, repo, &models.Skill{
+		Name:        "pdf-processing",
+		Description: "Extract text from PDF files. Use when reading PDFs.",
+		Detail:      "# PDF\n\nUse pdftotext.",
+		Metadata:    models.SkillMetadata{"author": "alice", "version": "1.0"},
+	})
+	if _, err := repo.SetSkillTagsByName(ctx, pdf.ID, []string{"pdf", "docs"}); err != nil {
+		t.Fatalf("设置标签失败: %v", err)
+	}
+	createSkill(t, repo, &models.Skill{Name: "pdf-merge", Description: "Merge PDF files. Use when combining PDFs."})
+	trashed := createSkill(t, repo, &models.Skill{Name: "old-skill", Description: "Old skill. Use never."})
+	if err := repo.DeleteSkill(ctx, trashed.ID); err != nil {
+		t.Fatalf("删除技能失败: %v", err)
+	}
+
+	// 字段顺序、缩进、空行和标签顺序都与生成的SKILL.md不同，但内容相同
+	writeSyncFile(t, dir, "pdf-processing", "---\n"+
+		"description:   Extract text from PDF files. Use when reading PDFs.\n"+
+		"name: pdf-processing\n"+
+		"tags: [docs, pdf]\n"+
+		"metadata:\n    version: \"1.0\"\n    author: alice\n"+
+		"---\n\n\n# PDF\n\nUse pdftotext.\n\n")
+	writeSyncFile(t, dir, "pdf-merge", "---\nname: pdf-merge\ndescription: Merge PDF files with bookmarks. Use when combining PDFs.\n---\n")
+	writeSyncFile(t, dir, "old-skill", "---\nname: old-skill\ndescription: Old skill. Use never.\n---\n")
+	writeSyncFile(t, dir, "image-resize", "---\nname: image-resize\ndescription: Resize images. Use when scaling images.\n---\n# Resize\n")
+
+	syncService := NewSkillSyncService(repo, skillService, config.SyncConfig{Dir: dir})
+	result := mustSync(t, syncService)
+
+	if !slices.Equal(result.Created, []string{"image-resize"}) {
+		t.Errorf("应从文件新建image-resize: %+v", result.Created)
+	}
+	if !slices.Equal(result.Conflicts, []string{"old-skill", "pdf-merge"}) {
+		t.Errorf("内容不同和回收站中的同名技能应记为冲突: %+v", result.Conflicts)
+	}
+	if state := syncStatus(t, repo, "pdf-processing"); state.Status != models.SkillSyncStatusSynced || state.SkillID != pdf.ID {
+		t.Errorf("内容相同的手写SKILL.md应直接记为已同步: %+v", state)
+	}
+	if state := syncStatus(t, repo, "old-skill"); !strings.Contains(state.ConflictReason, "回收站") {
+		t.Errorf("冲突原因应说明同名技能在回收站中: %+v", state)
+	}
+	if skill, _ := repo.GetSkillByName(ctx, "pdf-merge"); skill.Description != "Merge PDF files. Use when combining PDFs." {
+		t.Errorf("冲突时不应修改数据库: %s", skill.Description)
+	}
+
+	// 冲突未处理前再次同步保持冲突，已同步的文件夹没有变化
+	result = mustSync(t, syncService)
+	if len(result.Created)+len(result.Updated)+len(result.Written) != 0 || len(result.Conflicts) != 2 {
+		t.Errorf("再次同步不应有变化: %+v", result)
+	}
+
+	// 回收站中的技能不能以数据库为准处理冲突
+	state := syncStatus(t, repo, "old-skill")
+	_, err := syncService.ResolveConflict(ctx, state.ID, SyncKeepDB)
+	var appErr *errors.AppError
+	if !stderrors.As(err, &appErr) || appErr.Code != errors.ErrCodeSkillNotFound {
+		t.Errorf("回收站中的技能以数据库为准应返回技能不存在，实际: %v", err)
+	}
+}
+
+// TestSkillSync_Changes 测试文件修改、数据库修改写回、双方修改冲突以及冲突处理
+func TestSkillSync_Changes(t *testing.T) {
+	repo, skillService := newTestSkillService(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	for _, name := range []string{"pdf-processing", "pdf-merge"} {
+		writeSyncFile(t, dir, name, "---\nname: "+name+"\ndescription: Original "+name+". Use when testing.\n---\n# "+name+"\n")
+	}
+	syncService := NewSkillSyncService(repo, skillService, config.SyncConfig{Dir: dir, WriteBack: true})
+	if result := mustSync(t, syncService); len(result.Created) != 2 {
+		t.Fatalf("应从文件新建2个技能: %+v", result)
+	}
+
+	// updateDB 直接修改数据库中的技能描述
+	updateDB := func(name, description string) {
+		skill, err := repo.GetSkillByName(ctx, name)
+		if err != nil {
+			t.Fatalf("获取技能失败: %v", err)
+		}
+		skill.Description = description
+		if err := repo.UpdateSkill(ctx, skill); err != nil {
+			t.Fatalf("更新技能失败: %v", err)
+		}
+	}
+	// dbDescription 获取数据库中的技能描述
+	dbDescription := func(name string) string {
+		skill, err := repo.GetSkillByName(ctx, name)
+		if err != nil {
+			t.Fatalf("获取技能失败: %v", err)
+		}
+		return skill.Description
+	}
+
+	t.Run("文件修改后更新数据库", func(t *testing.T) {
+		writeSyncFile(t, dir, "pdf-processing", "---\nname: pdf-processing\ndescription: File edit. Use when testing.\n---\n# pdf-processing\n")
+		result := mustSync(t, syncService)
+		if !slices.Equal(result.Updated, []string{"pdf-processing"}) || dbDescription("pdf-processing") != "File edit. Use when testing." {
+			t.Errorf("文件修改后应更新数据库: %+v", result)
+		}
+	})
+
+	t.Run("数据库修改后写回文件", func(t *testing.T) {
+		updateDB("pdf-merge", "DB edit. Use when testing.")
+		result := mustSync(t, syncService)
+		if !slices.Equal(result.Written, []string{"pdf-merge"}) || !strings.Contains(readSyncFile(t, dir, "pdf-merge"), "description: DB edit. Use when testing.") {
+			t.Errorf("数据库修改后应写回SKILL.md: %+v", result)
+		}
+		if result := mustSync(t, syncService); len(result.Updated)+len(result.Written) != 0 {
+			t.Errorf("写回后再次同步不应有变化: %+v", result)
+		}
+	})
+
+	t.Run("写回含YAML特殊字符的内容后能再次解析", func(t *testing.T) {
+		description := "Excel analysis: summarize sheets.\n[beta] #1 tool, Use when: it's a spreadsheet"
+		updateDB("pdf-merge", description)
+		skill, err := repo.GetSkillByName(ctx, "pdf-merge")
+		if err != nil {
+			t.Fatalf("获取技能失败: %v", err)
+		}
+		if _, err := repo.SetSkillTagsByName(ctx, skill.ID, []string{"key: value", "[beta]"}); err != nil {
+			t.Fatalf("设置标签失败: %v", err)
+		}
+		if result := mustSync(t, syncService); !slices.Equal(result.Written, []string{"pdf-merge"}) {
+			t.Fatalf("数据库修改后应写回SKILL.md: %+v", result)
+		}
+		parsed, tags, err := ParseSkillMarkdown([]byte(readSyncFile(t, dir, "pdf-merge")))
+		if err != nil || parsed.Description != description || !slices.Equal(tags, []string{"[beta]", "key: value"}) {
+			t.Fatalf("写回的SKILL.md应能解析出原内容: %+v, %v, %v", parsed, tags, err)
+		}
+		result := mustSync(t, syncService)
+		if len(result.Updated)+len(result.Written)+len(result.Conflicts)+len(result.Failed) != 0 ||
+			syncStatus(t, repo, "pdf-merge").Status != models.SkillSyncStatusSynced {
+			t.Errorf("写回后再次同步不应有变化: %+v", result)
+		}
+
+		// 恢复数据库内容，后续用例从一致的状态开始
+		if _, err := repo.SetSkillTagsByName(ctx, skill.ID, nil); err != nil {
+			t.Fatalf("清空标签失败: %v", err)
+		}
+		updateDB("pdf-merge", "DB edit. Use when testing.")
+		mustSync(t, syncService)
+	})
+
+	t.Run("未开启写回时不修改文件", func(t *testing.T) {
+		readOnly := NewSkillSyncService(repo, skillService, config.SyncConfig{Dir: dir})
+		updateDB("pdf-merge", "DB only edit. Use when testing.")
+		result := mustSync(t, readOnly)
+		if len(result.Written) != 0 || strings.Contains(readSyncFile(t, dir, "pdf-merge"), "DB only edit") {
+			t.Errorf("未开启写回时不应写文件: %+v", result)
+		}
+		// 恢复数据库内容，后续用例从一致的状态开始
+		updateDB("pdf-merge", "DB edit. Use when testing.")
+		mustSync(t, syncService)
+	})
+
+	t.Run("双方都修改时记为冲突并以文件为准处理", func(t *testing.T) {
+		writeSyncFile(t, dir, "pdf-processing", "---\nname: pdf-processing\ndescription: Both file. Use when testing.\n---\n")
+		updateDB("pdf-processing", "Both db. Use when testing.")
+		result := mustSync(t, syncService)
+		if !slices.Equal(result.Conflicts, []string{"pdf-processing"}) || dbDescription("pdf-processing") != "Both db. Use when testing." {
+			t.Fatalf("双方都修改时应记为冲突且不修改任何一方: %+v", result)
+		}
+		conflicts, err := syncService.ListConflicts(ctx)
+		if err != nil || len(conflicts) != 1 {
+			t.Fatalf("应有1个冲突: %+v, %v", conflicts, err)
+		}
+
+		if _, err := syncService.ResolveConflict(ctx, conflicts[0].ID, "both"); err == nil {
+			t.Error("无效的处理方式应报错")
+		}
+		state, err := syncService.ResolveConflict(ctx, conflicts[0].ID, SyncKeepFile)
+		if err != nil || state.Status != models.SkillSyncStatusSynced {
+			t.Fatalf("以文件为准处理冲突失败: %+v, %v", state, err)
+		}
+		if dbDescription("pdf-processing") != "Both file. Use when testing." {
+			t.Errorf("以文件为准后数据库应更新")
+		}
+		if _, err := syncService.ResolveConflict(ctx, conflicts[0].ID, SyncKeepFile); err == nil {
+			t.Error("已处理的冲突不能再次处理")
+		}
+	})
+
+	t.Run("双方都修改时以数据库为准处理", func(t *testing.T) {
+		writeSyncFile(t, dir, "pdf-merge", "---\nname: pdf-merge\ndescription: Both file. Use when testing.\n---\n")
+		updateDB("pdf-merge", "Both db. Use when testing.")
+		if result := mustSync(t, syncService); !slices.Equal(result.Conflicts, []string{"pdf-merge"}) {
+			t.Fatalf("双方都修改时应记为冲突: %+v", result)
+		}
+		state, err := syncService.ResolveConflict(ctx, syncStatus(t, repo, "pdf-merge").ID, SyncKeepDB)
+		if err != nil || state.Status != models.SkillSyncStatusSynced {
+			t.Fatalf("以数据库为准处理冲突失败: %+v, %v", state, err)
+		}
+		if !strings.Contains(readSyncFile(t, dir, "pdf-merge"), "description: Both db. Use when testing.") {
+			t.Errorf("以数据库为准后SKILL.md应被覆盖")
+		}
+		if result := mustSync(t, syncService); len(result.Conflicts)+len(result.Updated)+len(result.Written) != 0 {
+			t.Errorf("处理冲突后再次同步不应有变化: %+v", result)
+		}
+	})
+
+	t.Run("手工改成一致后自动消除冲突", func(t *testing.T) {
+		writeSyncFile(t, dir, "pdf-merge", "---\nname: pdf-merge\ndescription: Manual file. Use when testing.\n---\n# pdf-merge\n")
+		updateDB("pdf-merge", "Manual db. Use when testing.")
+		mustSync(t, syncService)
+		updateDB("pdf-merge", "Manual file. Use when testing.")
+		result := mustSync(t, syncService)
+		if len(result.Conflicts) != 0 || syncStatus(t, repo, "pdf-merge").Status != models.SkillSyncStatusSynced {
+			t.Errorf("双方内容一致后应记为已同步: %+v", result)
+		}
+	})
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"aiflow/internal/errors"
+	"aiflow/internal/models"
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Agent Skills规范的字段长度限制（按字符计）
+const (
+	SkillNameMaxLength          = 64
+	SkillDescriptionMaxLength   = 1024
+	SkillCompatibilityMaxLength = 500
+)
+
+// skillNamePattern 技能名称格式：小写字母、数字，用单个连字符分隔，不以连字符开头或结尾
+var skillNamePattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// skillNameInvalidChars 规范化技能名称时替换为连字符的字符
+var skillNameInvalidChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// ValidateSkill 按Agent Skills规范校验技能，不符合时返回带字段级错误的AppError
+// 技能的所有写入入口（REST创建更新、MCP保存、SKILL.md导入）共用该校验
+func ValidateSkill(skill *models.Skill) error {
+	fields := skillFieldErrors(skill, true)
+	if len(fields) == 0 {
+		return nil
+	}
+	return errors.NewValidationError(errors.ErrCodeSkillValidate, fields)
+}
+
+// skillFieldErrors 逐字段校验技能，返回所有不符合规范的字段
+// suggestName为true时在名称错误中附带规范化后的建议名称
+func skillFieldErrors(skill *models.Skill, suggestName bool) []errors.FieldError {
+	var fields []errors.FieldError
+	suggestion := ""
+	if suggestName {
+		suggestion = nameSuggestion(skill.Name)
+	}
+
+	switch nameLength := utf8.RuneCountInString(skill.Name); {
+	case nameLength == 0:
+		fields = append(fields, errors.FieldError{Field: "name", Message: "不能为空"})
+	case nameLength > SkillNameMaxLength:
+		fields = append(fields, errors.FieldError{Field: "name", Message: fmt.Sprintf("不能超过%d个字符%s", SkillNameMaxLength, suggestion)})
+	case !skillNamePattern.MatchString(skill.Name):
+		fields = append(fields, errors.FieldError{Field: "name", Message: "只能包含小写字母、数字和连字符，且不能以连字符开头或结尾、不能有连续连字符" + suggestion})
+	}
+
+	if strings.TrimSpace(skill.Description) == "" {
+		fields = append(fields, errors.FieldError{Field: "description", Message: "不能为空"})
+	} else if utf8.RuneCountInString(skill.Description) > SkillDescriptionMaxLength {
+		fields = append(fields, errors.FieldError{Field: "description", Message: fmt.Sprintf("不能超过%d个字符", SkillDescriptionMaxLength)})
+	}
+
+	if utf8.RuneCountInString(skill.Compatibility) > SkillCompatibilityMaxLength {
+		fields = append(fields, errors.FieldError{Field: "compatibility", Message: fmt.Sprintf("不能超过%d个字符", SkillCompatibilityMaxLength)})
+	}
+
+	return fields
+}
+
+// nameSuggestion 生成名称建议的提示语，无法规范化时返回空字符串
+func nameSuggestion(name string) string {
+	if normalized := NormalizeSkillName(name); normalized != "" {
+		return "，建议使用 " + normalized
+	}
+	return ""
+}
+
+// NormalizeSkillName 将技能名称规范化为符合规范的形式
+// 转小写，非字母数字的连续字符替换为单个连字符，去掉首尾连字符并截断到64个字符
+// 名称中没有任何字母数字时返回空字符串
+func NormalizeSkillName(name string) string {
+	normalized := skillNameInvalidChars.ReplaceAllString(strings.ToLower(name), "-")
+	normalized = strings.Trim(normalized, "-")
+	if len(normalized) > SkillNameMaxLength {
+		normalized = strings.TrimRight(normalized[:SkillNameMaxLength], "-")
+	}
+	return normalized
+}
+
+// SkillLintIssue 技能不符合规范的一项问题及修复建议
+type SkillLintIssue struct {
+	Field      string `json:"field"`
+	Message    string `json:"message"`
+	Value      string `json:"value,omitempty"`      // 当前值，较长时截断
+	Suggestion string `json:"suggestion,omitempty"` // 建议的修复值
+}
+
+// SkillLintItem 单个技能的检查结果
+type SkillLintItem struct {
+	ID     uint             `json:"id"`
+	Name   string           `json:"name"`
+	Issues []SkillLintIssue `json:"issues"`
+}
+
+// SkillLintReport 技能规范检查报告
+type SkillLintReport struct {
+	Total   int             `json:"total"`   // 检查的技能数
+	Invalid int             `json:"invalid"` // 不符合规范的技能数
+	Items   []SkillLintItem `json:"items"`   // 不符合规范的技能，按ID排序
+}
+
+// lintValuePreviewLength 检查报告中字段当前值的最大展示长度
+const lintValuePreviewLength = 100
+
+// LintSkills 检查所有未删除的技能是否符合Agent Skills规范，并给出修复建议
+// 名称建议为规范化后的名称，与其他技能重名时追加技能ID；过长的字段建议截断后的值
+func (s *SkillService) LintSkills(ctx context.Context) (*SkillLintReport, error) {
+	skills, err := s.repo.ListAllSkills(ctx)
+	if err != nil {
+		return nil, errors.NewInternalError(errors.ErrCodeInternalError, "获取技能失败", err)
+	}
+	sort.Slice(skills, func(i, j int) bool {
+		return skills[i].ID < skills[j].ID
+	})
+
+	usedNames := map[string]bool{}
+	for _, skill := range skills {
+		usedNames[skill.Name] = true
+	}
+
+	report := &SkillLintReport{Items: []SkillLintItem{}}
+	for i := range skills {
+		skill := &skills[i]
+		if skill.DeletedAt > 0 {
+			continue
+		}
+		report.Total++
+
+		fields := skillFieldErrors(skill, false)
+		if len(fields) == 0 {
+			continue
+		}
+		item := SkillLintItem{ID: skill.ID, Name: skill.Name}
+		for _, field := range fields {
+			item.Issues = append(item.Issues, lintIssue(skill, field, usedNames))
+		}
+		report.Items = append(report.Items, item)
+	}
+	report.Invalid = len(report.Items)
+	return report, nil
+}
+
+// lintIssue 根据字段错误生成检查问题和修复建议
+func lintIssue(skill *models.Skill, field errors.FieldError, usedNames map[string]bool) SkillLintIssue {
+	issue := SkillLintIssue{Field: field.Field, Message: field.Message}
+	switch field.Field {
+	case "name":
+		issue.Value = skill.Name
+		suggestion := NormalizeSkillName(skill.Name)
+		if suggestion == "" || (suggestion != skill.Name && usedNames[suggestion]) {
+			suffix := fmt.Sprintf("-%d", skill.ID)
+			if suggestion == "" {
+				suggestion = "skill"
+			}
+			if len(suggestion)+len(suffix) > SkillNameMaxLength {
+				suggestion = strings.TrimRight(suggestion[:SkillNameMaxLength-len(suffix)], "-")
+			}
+			suggestion += suffix
+		}
+		usedNames[suggestion] = true
+		issue.Suggestion = suggestion
+	case "description":
+		issue.Value = truncateRunes(skill.Description, lintValuePreviewLength)
+		if strings.TrimSpace(skill.Description) == "" {
+			// 描述为空时建议使用详细说明的第一行
+			issue.Suggestion = truncateRunes(firstNonEmptyLine(skill.Detail), SkillDescriptionMaxLength)
+		} else {
+			issue.Suggestion = truncateRunes(skill.Description, SkillDescriptionMaxLength)
+		}
+	case "compatibility":
+		issue.Value = truncateRunes(skill.Compatibility, lintValuePreviewLength)
+		issue.Suggestion = truncateRunes(skill.Compatibility, SkillCompatibilityMaxLength)
+	}
+	return issue
+}
+
+// truncateRunes 按字符截断字符串
+func truncateRunes(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit])
+}
+
+// firstNonEmptyLine 获取文本的第一个非空行，去掉Markdown标题符号
+func firstNonEmptyLine(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#"))
+		if line != "" {
+			return line
+		}
+	}
+	return ""
+}
@@ -1,7 +1,12 @@
 package services
 
 import (
+	"cmp"
 	"context"
+	stderrors "errors"
+	"fmt"
+	"maps"
+	"slices"
 
 	"aiflow/internal/errors"
 	"aiflow/internal/models"
@@ -16,11 +21,14 @@ type TagService struct {
 
 // TagResponse 标签响应结构
 type TagResponse struct {
-	ID        uint         `json:"id"`
-	Name      string       `json:"name"`
+	ID       uint   `json:"id"`
+	Name     string `json:"name"`
+	ParentID uint   `json:"parentId"`
+	// Aliases 合并到该标签的原标签名称，只在获取单个标签和合并标签时返回
+	Aliases   []string       `json:"aliases,omitempty"`
 	Skills    []models.Skill `json:"skills,omitempty"`
-	CreatedAt int64        `json:"createdAt"`
-	UpdatedAt int64        `json:"updatedAt"`
+	CreatedAt int64          `json:"createdAt"`
+	UpdatedAt int64          `json:"updatedAt"`
 }
 
 // NewTagService 创建标签服务实例
@@ -77,6 +85,8 @@ func (s *TagService) ListTags(ctx context.Context, req ListTagsRequest) (*ListTa
 // CreateTagRequest 创建标签请求参数
 type CreateTagRequest struct {
 	Name string `json:"name"`
+	// ParentID 父标签ID，0表示顶层标签
+	ParentID uint `json:"parentId"`
 }
 
 // CreateTag 创建标签
@@ -86,9 +96,20 @@ func (s *TagService) CreateTag(ctx context.Context, req CreateTagRequest) (*TagR
 	if existingTag != nil {
 		return nil, errors.NewTagError(errors.ErrCodeTagCreate, "标签名已存在", nil)
 	}
+	if trashedTag, _ := s.repo.GetTrashedTagByName(ctx, req.Name); trashedTag != nil {
+		return nil, errors.NewTagError(errors.ErrCodeTagCreate, "同名标签在回收站中，请恢复或彻底删除后再创建", nil)
+	}
+
+	// 检查父标签是否存在
+	if req.ParentID > 0 {
+		if _, err := s.repo.GetTagByID(ctx, req.ParentID); err != nil {
+			return nil, errors.NewTagError(errors.ErrCodeTagNotFound, "父标签不存在", err)
+		}
+	}
 
 	tag := &models.Tag{
-		Name: req.Name,
+		Name:     req.Name,
+		ParentID: req.ParentID,
 	}
 
 	if err := s.repo.CreateTag(ctx, tag); err != nil {
@@ -107,6 +128,9 @@ func (s *TagService) GetTag(ctx context.Context, id uint) (*TagResponse, error)
 	}
 
 	response := convertToTagResponse(tag)
+	if response.Aliases, err = s.repo.ListTagAliases(ctx, id); err != nil {
+		return nil, errors.NewInternalError(errors.ErrCodeInternalError, "获取标签别名失败", err)
+	}
 	return &response, nil
 }
 
@@ -130,6 +154,9 @@ func (s *TagService) UpdateTag(ctx context.Context, req UpdateTagRequest) (*TagR
 		if existingTag != nil && existingTag.ID != req.ID {
 			return nil, errors.NewTagError(errors.ErrCodeTagUpdate, "标签名已存在", nil)
 		}
+		if trashedTag, _ := s.repo.GetTrashedTagByName(ctx, req.Name); trashedTag != nil {
+			return nil, errors.NewTagError(errors.ErrCodeTagUpdate, "同名标签在回收站中，请恢复或彻底删除后再修改", nil)
+		}
 	}
 
 	// 更新标签信息
@@ -149,7 +176,7 @@ func (s *TagService) UpdateTag(ctx context.Context, req UpdateTagRequest) (*TagR
 	return &response, nil
 }
 
-// DeleteTag 删除标签
+// DeleteTag 删除标签（伪删除，进入回收站）
 func (s *TagService) DeleteTag(ctx context.Context, id uint) error {
 	// 检查标签是否存在
 	_, err := s.repo.GetTagByID(ctx, id)
@@ -164,11 +191,171 @@ func (s *TagService) DeleteTag(ctx context.Context, id uint) error {
 	return nil
 }
 
+// MoveTagRequest 移动标签请求参数
+type MoveTagRequest struct {
+	ID uint `json:"id"`
+	// ParentID 新的父标签ID，0表示移到顶层
+	ParentID uint `json:"parentId"`
+}
+
+// MoveTag 将标签连同其子标签移动到新的父标签下
+// 不能移动到标签自身或其子标签下，技能与标签的关联保持不变
+func (s *TagService) MoveTag(ctx context.Context, req MoveTagRequest) (*TagResponse, error) {
+	if _, err := s.repo.GetTagByID(ctx, req.ID); err != nil {
+		return nil, errors.NewTagError(errors.ErrCodeTagNotFound, "标签不存在", err)
+	}
+
+	if req.ParentID > 0 {
+		if _, err := s.repo.GetTagByID(ctx, req.ParentID); err != nil {
+			return nil, errors.NewTagError(errors.ErrCodeTagNotFound, "父标签不存在", err)
+		}
+	}
+
+	// 新的父标签不能是标签自身或其后代，否则会形成循环，由仓库在移动的事务中检查
+	if err := s.repo.MoveTag(ctx, req.ID, req.ParentID); err != nil {
+		if stderrors.Is(err, repositories.ErrTagCycle) {
+			return nil, errors.NewTagError(errors.ErrCodeTagCycle, "", err)
+		}
+		return nil, errors.NewTagError(errors.ErrCodeTagMove, "移动标签失败", err)
+	}
+
+	movedTag, err := s.repo.GetTagByID(ctx, req.ID)
+	if err != nil {
+		return nil, errors.NewTagError(errors.ErrCodeTagMove, "获取移动后的标签失败", err)
+	}
+
+	response := convertToTagResponse(movedTag)
+	return &response, nil
+}
+
+// MergeTagsRequest 合并标签请求参数
+type MergeTagsRequest struct {
+	// TargetID 保留的目标标签ID
+	TargetID uint `json:"targetId"`
+	// SourceIDs 被合并的标签ID
+	SourceIDs []uint `json:"sourceIds"`
+}
+
+// MergeTags 将多个标签合并到目标标签
+// 被合并标签的技能改为关联目标标签，名称成为目标标签的别名，之后按原名称查找会得到目标标签
+func (s *TagService) MergeTags(ctx context.Context, req MergeTagsRequest) (*TagResponse, error) {
+	sourceIDs := slices.Compact(slices.Sorted(slices.Values(req.SourceIDs)))
+	if len(sourceIDs) == 0 {
+		return nil, errors.NewInvalidParamError(errors.ErrCodeBadRequestParam, "请指定要合并的标签", nil)
+	}
+	if slices.Contains(sourceIDs, req.TargetID) {
+		return nil, errors.NewInvalidParamError(errors.ErrCodeBadRequestParam, "不能将标签合并到自身", nil)
+	}
+
+	if _, err := s.repo.GetTagByID(ctx, req.TargetID); err != nil {
+		return nil, errors.NewTagError(errors.ErrCodeTagNotFound, "目标标签不存在", err)
+	}
+	for _, id := range sourceIDs {
+		if _, err := s.repo.GetTagByID(ctx, id); err != nil {
+			return nil, errors.NewTagError(errors.ErrCodeTagNotFound, fmt.Sprintf("标签%d不存在", id), err)
+		}
+	}
+
+	if err := s.repo.MergeTags(ctx, req.TargetID, sourceIDs); err != nil {
+		return nil, errors.NewTagError(errors.ErrCodeTagMerge, "合并标签失败", err)
+	}
+
+	return s.GetTag(ctx, req.TargetID)
+}
+
+// TagTreeNode 标签树节点
+type TagTreeNode struct {
+	ID       uint   `json:"id"`
+	Name     string `json:"name"`
+	ParentID uint   `json:"parentId"`
+	// Path 从顶层标签到当前标签的名称，用 / 连接，如 frontend/react/testing
+	Path string `json:"path"`
+	// SkillCount 直接关联该标签的技能数
+	SkillCount int64 `json:"skillCount"`
+	// TotalSkillCount 关联该标签或其任一后代标签的技能数，同一技能只计一次
+	TotalSkillCount int64          `json:"totalSkillCount"`
+	Children        []*TagTreeNode `json:"children"`
+}
+
+// GetTagTree 获取标签树，同级标签按名称排序
+// rootID为0时返回所有顶层标签及其子树，否则只返回该标签的子树
+// 父标签已不存在的标签视为顶层标签
+func (s *TagService) GetTagTree(ctx context.Context, rootID uint) ([]*TagTreeNode, error) {
+	tags, err := s.repo.ListTags(ctx)
+	if err != nil {
+		return nil, errors.NewInternalError(errors.ErrCodeInternalError, "获取标签列表失败", err)
+	}
+	skillIDs, err := s.repo.ListSkillIDsByTag(ctx)
+	if err != nil {
+		return nil, errors.NewInternalError(errors.ErrCodeInternalError, "统计标签技能数失败", err)
+	}
+
+	nodes := make(map[uint]*TagTreeNode, len(tags))
+	for _, tag := range tags {
+		nodes[tag.ID] = &TagTreeNode{
+			ID:         tag.ID,
+			Name:       tag.Name,
+			ParentID:   tag.ParentID,
+			SkillCount: int64(len(skillIDs[tag.ID])),
+			Children:   []*TagTreeNode{},
+		}
+	}
+
+	roots := []*TagTreeNode{}
+	for _, tag := range tags {
+		node := nodes[tag.ID]
+		if parent, ok := nodes[tag.ParentID]; ok {
+			parent.Children = append(parent.Children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+	for _, root := range roots {
+		finishTagTree(root, "", skillIDs)
+	}
+
+	if rootID > 0 {
+		root, ok := nodes[rootID]
+		if !ok {
+			return nil, errors.NewTagError(errors.ErrCodeTagNotFound, "标签不存在", nil)
+		}
+		return []*TagTreeNode{root}, nil
+	}
+	slices.SortFunc(roots, compareTagTreeNode)
+	return roots, nil
+}
+
+// finishTagTree 填充子树的路径和技能总数，并按名称排序同级标签
+// 返回子树关联的技能ID集合
+func finishTagTree(node *TagTreeNode, prefix string, skillIDs map[uint][]uint) map[uint]struct{} {
+	node.Path = node.Name
+	if prefix != "" {
+		node.Path = prefix + "/" + node.Name
+	}
+
+	subtreeSkills := make(map[uint]struct{})
+	for _, id := range skillIDs[node.ID] {
+		subtreeSkills[id] = struct{}{}
+	}
+	slices.SortFunc(node.Children, compareTagTreeNode)
+	for _, child := range node.Children {
+		maps.Copy(subtreeSkills, finishTagTree(child, node.Path, skillIDs))
+	}
+	node.TotalSkillCount = int64(len(subtreeSkills))
+	return subtreeSkills
+}
+
+// compareTagTreeNode 按名称排序标签树节点，名称相同时按ID排序
+func compareTagTreeNode(a, b *TagTreeNode) int {
+	return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+}
+
 // convertToTagResponse 将模型转换为响应结构
 func convertToTagResponse(tag *models.Tag) TagResponse {
 	return TagResponse{
 		ID:        tag.ID,
 		Name:      tag.Name,
+		ParentID:  tag.ParentID,
 		Skills:    tag.Skills,
 		CreatedAt: tag.CreatedAt,
 		UpdatedAt: tag.UpdatedAt,
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"slices"
+
+	"aiflow/internal/errors"
+	"aiflow/internal/models"
+	"aiflow/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+// TagSuggestionService 标签建议服务
+// 根据已打标签技能的分词与标签的共现关系，以及标签名称匹配，为技能建议标签，建议经采纳后才关联到技能
+type TagSuggestionService struct {
+	repo *repositories.Repository
+}
+
+// NewTagSuggestionService 创建标签建议服务实例
+func NewTagSuggestionService(repo *repositories.Repository) *TagSuggestionService {
+	return &TagSuggestionService{repo: repo}
+}
+
+// TagSuggestionResponse 标签建议响应结构
+type TagSuggestionResponse struct {
+	ID        uint    `json:"id"`
+	SkillID   uint    `json:"skillId"`
+	SkillName string  `json:"skillName"`
+	TagID     uint    `json:"tagId"`
+	TagName   string  `json:"tagName"`
+	Score     float64 `json:"score"`
+	Reason    string  `json:"reason"`
+	Status    string  `json:"status"`
+	CreatedAt int64   `json:"createdAt"`
+	UpdatedAt int64   `json:"updatedAt"`
+}
+
+// GenerateTagSuggestionsRequest 生成标签建议请求参数
+type GenerateTagSuggestionsRequest struct {
+	// SkillIDs 要生成建议的技能ID，为空时为所有没有标签的技能生成
+	SkillIDs []uint `json:"skillIds"`
+}
+
+// GenerateTagSuggestionsResponse 生成标签建议响应
+type GenerateTagSuggestionsResponse struct {
+	// Skills 计算建议的技能数
+	Skills int `json:"skills"`
+	// Items 这些技能的待处理建议
+	Items []TagSuggestionResponse `json:"items"`
+}
+
+// GenerateSuggestions 为技能生成标签建议
+// 新的建议为待处理状态，已采纳或已拒绝的建议不会重新生成
+func (s *TagSuggestionService) GenerateSuggestions(ctx context.Context, req GenerateTagSuggestionsRequest) (*GenerateTagSuggestionsResponse, error) {
+	candidates, err := s.repo.SuggestSkillTags(ctx, req.SkillIDs)
+	if err != nil {
+		return nil, errors.NewTagError(errors.ErrCodeTagSuggestion, "计算标签建议失败", err)
+	}
+	if err := s.repo.SaveTagSuggestions(ctx, candidates); err != nil {
+		return nil, errors.NewTagError(errors.ErrCodeTagSuggestion, "保存标签建议失败", err)
+	}
+
+	response := &GenerateTagSuggestionsResponse{Skills: len(candidates), Items: []TagSuggestionResponse{}}
+	if len(candidates) == 0 {
+		return response, nil
+	}
+	skillIDs := make([]uint, 0, len(candidates))
+	for skillID := range candidates {
+		skillIDs = append(skillIDs, skillID)
+	}
+	views, _, err := s.repo.ListTagSuggestions(ctx, models.TagSuggestionStatusPending, skillIDs, 1, 0)
+	if err != nil {
+		return nil, errors.NewTagError(errors.ErrCodeTagSuggestion, "获取标签建议失败", err)
+	}
+	for _, view := range views {
+		response.Items = append(response.Items, convertToTagSuggestionResponse(&view))
+	}
+	return response, nil
+}
+
+// ListTagSuggestionsRequest 获取标签建议列表请求参数
+type ListTagSuggestionsRequest struct {
+	// Status 按状态筛选：pending、accepted、rejected，为空时不筛选
+	Status string
+	// SkillID 按技能筛选，0表示不筛选
+	SkillID  uint
+	Page     int
+	PageSize int
+}
+
+// ListTagSuggestionsResponse 获取标签建议列表响应
+type ListTagSuggestionsResponse struct {
+	Items      []TagSuggestionResponse `json:"items"`
+	Pagination map[string]interface{}  `json:"pagination"`
+}
+
+// ListSuggestions 获取标签建议列表（支持分页、状态和技能筛选）
+func (s *TagSuggestionService) ListSuggestions(ctx context.Context, req ListTagSuggestionsRequest) (*ListTagSuggestionsResponse, error) {
+	if req.Status != "" && !slices.Contains([]string{
+		models.TagSuggestionStatusPending, models.TagSuggestionStatusAccepted, models.TagSuggestionStatusRejected,
+	}, req.Status) {
+		return nil, errors.NewInvalidParamError(errors.ErrCodeBadRequestParam, "无效的建议状态，有效值: pending, accepted, rejected", nil)
+	}
+	// 设置默认分页参数
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 10
+	}
+
+	var skillIDs []uint
+	if req.SkillID > 0 {
+		skillIDs = []uint{req.SkillID}
+	}
+	views, total, err := s.repo.ListTagSuggestions(ctx, req.Status, skillIDs, req.Page, req.PageSize)
+	if err != nil {
+		return nil, errors.NewTagError(errors.ErrCodeTagSuggestion, "获取标签建议失败", err)
+	}
+
+	items := make([]TagSuggestionResponse, 0, len(views))
+	for _, view := range views {
+		items = append(items, convertToTagSuggestionResponse(&view))
+	}
+
+	pagination := map[string]interface{}{
+		"total":     total,
+		"page":      req.Page,
+		"pageSize":  req.PageSize,
+		"totalPage": (total + int64(req.PageSize) - 1) / int64(req.PageSize),
+	}
+
+	return &ListTagSuggestionsResponse{
+		Items:      items,
+		Pagination: pagination,
+	}, nil
+}
+
+// AcceptSuggestion 采纳标签建议，将标签关联到技能
+func (s *TagSuggestionService) AcceptSuggestion(ctx context.Context, id uint) (*TagSuggestionResponse, error) {
+	return s.resolveSuggestion(ctx, id, true)
+}
+
+// RejectSuggestion 拒绝标签建议，之后不再为该技能建议这个标签
+func (s *TagSuggestionService) RejectSuggestion(ctx context.Context, id uint) (*TagSuggestionResponse, error) {
+	return s.resolveSuggestion(ctx, id, false)
+}
+
+// resolveSuggestion 采纳或拒绝待处理的标签建议
+func (s *TagSuggestionService) resolveSuggestion(ctx context.Context, id uint, accept bool) (*TagSuggestionResponse, error) {
+	suggestion, err := s.repo.GetTagSuggestion(ctx, id)
+	if err == gorm.ErrRecordNotFound {
+		return nil, errors.NewTagError(errors.ErrCodeTagSuggestionNotFound, "", err)
+	}
+	if err != nil {
+		return nil, errors.NewTagError(errors.ErrCodeTagSuggestion, "获取标签建议失败", err)
+	}
+	if suggestion.Status != models.TagSuggestionStatusPending {
+		return nil, errors.NewTagError(errors.ErrCodeTagSuggestionHandled, "", nil)
+	}
+
+	if err := s.repo.ResolveTagSuggestion(ctx, id, accept); err != nil {
+		return nil, errors.NewTagError(errors.ErrCodeTagSuggestion, "处理标签建议失败", err)
+	}
+
+	suggestion, err = s.repo.GetTagSuggestion(ctx, id)
+	if err != nil {
+		return nil, errors.NewTagError(errors.ErrCodeTagSuggestion, "获取标签建议失败", err)
+	}
+	response := convertToTagSuggestionResponse(suggestion)
+	return &response, nil
+}
+
+// convertToTagSuggestionResponse 将标签建议转换为响应结构
+func convertToTagSuggestionResponse(view *repositories.TagSuggestionView) TagSuggestionResponse {
+	return TagSuggestionResponse{
+		ID:        view.ID,
+		SkillID:   view.SkillID,
+		SkillName: view.SkillName,
+		TagID:     view.TagID,
+		TagName:   view.TagName,
+		Score:     view.Score,
+		Reason:    view.Reason,
+		Status:    view.Status,
+		CreatedAt: view.CreatedAt,
+		UpdatedAt: view.UpdatedAt,
+	}
+}
@@ -0,0 +1,293 @@
+package services
+
+import (
+	"aiflow/internal/config"
+	"aiflow/internal/errors"
+	"aiflow/internal/repositories"
+	"aiflow/internal/utils/logx"
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TrashService 回收站服务
+// 统一管理删除后进入回收站的技能、任务和标签：列表、批量恢复和彻底删除，并按保留天数在后台自动清理过期项
+type TrashService struct {
+	repo         *repositories.Repository
+	skillService *SkillService
+	cfg          config.TrashConfig
+	// sweepEvery 后台清理的间隔
+	sweepEvery time.Duration
+}
+
+// NewTrashService 创建回收站服务实例
+// 保留天数不大于0（未配置）时不自动清理，避免升级后回收站中已有的数据被立即清理
+func NewTrashService(repo *repositories.Repository, skillService *SkillService, cfg config.TrashConfig) *TrashService {
+	if cfg.RetentionDays < 0 {
+		cfg.RetentionDays = 0
+	}
+	if cfg.SweepInterval <= 0 {
+		cfg.SweepInterval = config.DefaultTrashSweepInterval
+	}
+	return &TrashService{
+		repo:         repo,
+		skillService: skillService,
+		cfg:          cfg,
+		sweepEvery:   time.Duration(cfg.SweepInterval) * time.Second,
+	}
+}
+
+// TrashItemResponse 回收站项响应结构
+type TrashItemResponse struct {
+	Type        string `json:"type"`
+	ID          uint   `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	DeletedAt   int64  `json:"deletedAt"`
+	// ExpiresAt 自动彻底删除的时间，不自动清理时为0
+	ExpiresAt int64 `json:"expiresAt"`
+}
+
+// ListTrashRequest 获取回收站列表请求参数
+type ListTrashRequest struct {
+	// Type 按类型筛选：skill、jobtask、tag，为空时不筛选
+	Type     string
+	Page     int
+	PageSize int
+}
+
+// ListTrashResponse 获取回收站列表响应
+type ListTrashResponse struct {
+	Items      []TrashItemResponse    `json:"items"`
+	Pagination map[string]interface{} `json:"pagination"`
+	// RetentionDays 回收站保留天数，0表示不自动清理
+	RetentionDays int `json:"retentionDays"`
+}
+
+// TrashRef 回收站中的一项的引用
+type TrashRef struct {
+	Type string `json:"type"`
+	ID   uint   `json:"id"`
+}
+
+// TrashBatchRequest 批量恢复或彻底删除请求参数
+type TrashBatchRequest struct {
+	Items []TrashRef `json:"items"`
+}
+
+// TrashFailure 单项处理失败信息
+type TrashFailure struct {
+	Type   string `json:"type"`
+	ID     uint   `json:"id"`
+	Reason string `json:"reason"`
+}
+
+// TrashBatchResult 批量处理结果，每项单独处理，部分失败不影响其他项
+type TrashBatchResult struct {
+	Succeeded []TrashRef     `json:"succeeded"`
+	Failed    []TrashFailure `json:"failed"`
+}
+
+// ListTrash 获取回收站列表（支持分页和类型筛选），按删除时间倒序排列
+func (s *TrashService) ListTrash(ctx context.Context, req ListTrashRequest) (*ListTrashResponse, error) {
+	var types []string
+	if req.Type != "" {
+		if !slices.Contains(repositories.TrashTypes, req.Type) {
+			return nil, invalidTrashTypeError()
+		}
+		types = []string{req.Type}
+	}
+	// 设置默认分页参数
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 10
+	}
+
+	items, total, err := s.repo.ListTrash(ctx, types, 0, req.Page, req.PageSize)
+	if err != nil {
+		return nil, errors.NewTrashError(errors.ErrCodeTrash, "获取回收站列表失败", err)
+	}
+
+	responseItems := make([]TrashItemResponse, 0, len(items))
+	for _, item := range items {
+		responseItems = append(responseItems, TrashItemResponse{
+			Type:        item.Type,
+			ID:          item.ID,
+			Name:        item.Name,
+			Description: item.Description,
+			DeletedAt:   item.DeletedAt,
+			ExpiresAt:   s.expiresAt(item.DeletedAt),
+		})
+	}
+
+	pagination := map[string]interface{}{
+		"total":     total,
+		"page":      req.Page,
+		"pageSize":  req.PageSize,
+		"totalPage": (total + int64(req.PageSize) - 1) / int64(req.PageSize),
+	}
+
+	return &ListTrashResponse{
+		Items:         responseItems,
+		Pagination:    pagination,
+		RetentionDays: s.cfg.RetentionDays,
+	}, nil
+}
+
+// Restore 批量恢复回收站中的项
+func (s *TrashService) Restore(ctx context.Context, req TrashBatchRequest) (*TrashBatchResult, error) {
+	if err := validateTrashRefs(req.Items); err != nil {
+		return nil, err
+	}
+	return s.batch(ctx, req.Items, s.restoreItem), nil
+}
+
+// Purge 批量彻底删除回收站中的项
+// 技能同时删除其标签关联、分词索引、全文检索索引、语义向量、修订历史、标签建议和文件目录，
+// 任务同时删除其执行记录，标签同时删除其技能关联、别名和标签建议
+func (s *TrashService) Purge(ctx context.Context, req TrashBatchRequest) (*TrashBatchResult, error) {
+	if err := validateTrashRefs(req.Items); err != nil {
+		return nil, err
+	}
+	return s.batch(ctx, req.Items, s.purgeItem), nil
+}
+
+// PurgeExpired 彻底删除在回收站中超过保留天数的项，不自动清理时不做处理
+func (s *TrashService) PurgeExpired(ctx context.Context) (*TrashBatchResult, error) {
+	result := &TrashBatchResult{Succeeded: []TrashRef{}, Failed: []TrashFailure{}}
+	if !s.autoPurge() {
+		return result, nil
+	}
+
+	cutoff := time.Now().Add(-s.retention()).UnixMilli()
+	items, _, err := s.repo.ListTrash(ctx, nil, cutoff, 1, 0)
+	if err != nil {
+		return nil, errors.NewTrashError(errors.ErrCodeTrash, "获取过期回收站项失败", err)
+	}
+	refs := make([]TrashRef, 0, len(items))
+	for _, item := range items {
+		refs = append(refs, TrashRef{Type: item.Type, ID: item.ID})
+	}
+	return s.batch(ctx, refs, s.purgeItem), nil
+}
+
+// Start 启动回收站后台清理：立即清理一次过期项，之后按清理间隔定时清理，ctx取消后停止
+// 不自动清理时不启动
+func (s *TrashService) Start(ctx context.Context) {
+	if !s.autoPurge() {
+		return
+	}
+
+	go func() {
+		s.sweep(ctx)
+
+		ticker := time.NewTicker(s.sweepEvery)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.sweep(ctx)
+			}
+		}
+	}()
+}
+
+// sweep 后台执行一次过期项清理
+func (s *TrashService) sweep(ctx context.Context) {
+	result, err := s.PurgeExpired(ctx)
+	if err != nil {
+		logx.Error("回收站清理失败: %v", err)
+		return
+	}
+	for _, failure := range result.Failed {
+		logx.Warn("回收站清理 %s %d 失败: %s", failure.Type, failure.ID, failure.Reason)
+	}
+	if len(result.Succeeded)+len(result.Failed) > 0 {
+		logx.Info("回收站清理完成: 彻底删除%d 失败%d", len(result.Succeeded), len(result.Failed))
+	}
+}
+
+// batch 逐项处理，汇总成功和失败的项
+func (s *TrashService) batch(ctx context.Context, refs []TrashRef, handle func(context.Context, TrashRef) error) *TrashBatchResult {
+	result := &TrashBatchResult{Succeeded: []TrashRef{}, Failed: []TrashFailure{}}
+	for _, ref := range refs {
+		if err := handle(ctx, ref); err != nil {
+			reason := err.Error()
+			if err == gorm.ErrRecordNotFound {
+				reason = errors.NewTrashError(errors.ErrCodeTrashNotFound, "", nil).Message
+			}
+			result.Failed = append(result.Failed, TrashFailure{Type: ref.Type, ID: ref.ID, Reason: reason})
+			continue
+		}
+		result.Succeeded = append(result.Succeeded, ref)
+	}
+	return result
+}
+
+// restoreItem 恢复一项，不在回收站中时返回 gorm.ErrRecordNotFound
+func (s *TrashService) restoreItem(ctx context.Context, ref TrashRef) error {
+	switch ref.Type {
+	case repositories.TrashTypeSkill:
+		return s.skillService.RestoreSkill(ctx, ref.ID)
+	case repositories.TrashTypeJobTask:
+		return s.repo.RestoreJobTask(ctx, ref.ID)
+	default:
+		return s.repo.RestoreTag(ctx, ref.ID)
+	}
+}
+
+// purgeItem 彻底删除一项，不在回收站中时返回 gorm.ErrRecordNotFound
+func (s *TrashService) purgeItem(ctx context.Context, ref TrashRef) error {
+	switch ref.Type {
+	case repositories.TrashTypeSkill:
+		return s.skillService.PermanentDeleteSkill(ctx, ref.ID)
+	case repositories.TrashTypeJobTask:
+		return s.repo.PermanentDeleteJobTask(ctx, ref.ID)
+	default:
+		return s.repo.PermanentDeleteTag(ctx, ref.ID)
+	}
+}
+
+// autoPurge 是否按保留天数自动清理，只有配置了正的保留天数时才清理
+func (s *TrashService) autoPurge() bool {
+	return s.cfg.RetentionDays > 0
+}
+
+// retention 回收站保留时长
+func (s *TrashService) retention() time.Duration {
+	return time.Duration(s.cfg.RetentionDays) * 24 * time.Hour
+}
+
+// expiresAt 删除时间为deletedAt的项自动彻底删除的时间，不自动清理时为0
+func (s *TrashService) expiresAt(deletedAt int64) int64 {
+	if !s.autoPurge() {
+		return 0
+	}
+	return deletedAt + s.retention().Milliseconds()
+}
+
+// validateTrashRefs 校验批量处理的项不为空且类型有效
+func validateTrashRefs(refs []TrashRef) error {
+	if len(refs) == 0 {
+		return errors.NewInvalidParamError(errors.ErrCodeBadRequestParam, "请选择要处理的项", nil)
+	}
+	for _, ref := range refs {
+		if !slices.Contains(repositories.TrashTypes, ref.Type) {
+			return invalidTrashTypeError()
+		}
+	}
+	return nil
+}
+
+// invalidTrashTypeError 无效回收站类型错误
+func invalidTrashTypeError() error {
+	return errors.NewInvalidParamError(errors.ErrCodeBadRequestParam,
+		"无效的类型，有效值: "+strings.Join(repositories.TrashTypes, ", "), nil)
+}
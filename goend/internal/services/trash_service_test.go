@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"aiflow/internal/config"
+	"aiflow/internal/models"
+	"aiflow/internal/repositories"
+)
+
+// createTrashedJobTask 创建任务并放入回收站，删除时间为deletedAt
+func createTrashedJobTask(t *testing.T, repo *repositories.Repository, jobNo string, deletedAt time.Time) *models.JobTask {
+	t.Helper()
+	job := &models.JobTask{JobNo: jobNo, Project: "a", Type: models.JobTaskTypeBugFix, Goal: jobNo, Status: "已创建"}
+	if err := repo.CreateJobTask(context.Background(), job); err != nil {
+		t.Fatalf("创建任务%s失败: %v", jobNo, err)
+	}
+	setJobTaskDeletedAt(t, repo, job.ID, deletedAt)
+	return job
+}
+
+// setJobTaskDeletedAt 修改任务的删除时间
+func setJobTaskDeletedAt(t *testing.T, repo *repositories.Repository, id uint, deletedAt time.Time) {
+	t.Helper()
+	err := repo.GetDB().Model(&models.JobTask{}).Where("id = ?", id).Update("deleted_at", deletedAt.UnixMilli()).Error
+	if err != nil {
+		t.Fatalf("修改任务删除时间失败: %v", err)
+	}
+}
+
+// jobTaskExists 任务是否仍在数据库中（含回收站）
+func jobTaskExists(t *testing.T, repo *repositories.Repository, id uint) bool {
+	t.Helper()
+	var count int64
+	if err := repo.GetDB().Model(&models.JobTask{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		t.Fatalf("查询任务失败: %v", err)
+	}
+	return count > 0
+}
+
+// waitJobTaskPurged 等待后台清理彻底删除任务
+func waitJobTaskPurged(t *testing.T, repo *repositories.Repository, id uint) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if !jobTaskExists(t, repo, id) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("等待后台清理任务%d超时", id)
+}
+
+// TestTrashService_Start 测试后台清理：启动时立即清理一次，之后按间隔定时清理，ctx取消后停止
+func TestTrashService_Start(t *testing.T) {
+	repo, skillService := newTestSkillService(t)
+	now := time.Now()
+
+	expired := createTrashedJobTask(t, repo, "JT-A-1", now.AddDate(0, 0, -2))
+	later := createTrashedJobTask(t, repo, "JT-A-2", now)
+	kept := createTrashedJobTask(t, repo, "JT-A-3", now)
+
+	trashService := NewTrashService(repo, skillService, config.TrashConfig{RetentionDays: 1})
+	if trashService.sweepEvery != time.Duration(config.DefaultTrashSweepInterval)*time.Second {
+		t.Errorf("未配置清理间隔时应使用默认间隔: %v", trashService.sweepEvery)
+	}
+	trashService.sweepEvery = 20 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	trashService.Start(ctx)
+
+	// 启动时立即清理已过期的项
+	waitJobTaskPurged(t, repo, expired.ID)
+	if !jobTaskExists(t, repo, later.ID) || !jobTaskExists(t, repo, kept.ID) {
+		t.Fatal("未过期的任务不应被清理")
+	}
+
+	// 启动后过期的项由定时清理
+	setJobTaskDeletedAt(t, repo, later.ID, now.AddDate(0, 0, -2))
+	waitJobTaskPurged(t, repo, later.ID)
+
+	// ctx取消后不再清理
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	setJobTaskDeletedAt(t, repo, kept.ID, now.AddDate(0, 0, -2))
+	time.Sleep(100 * time.Millisecond)
+	if !jobTaskExists(t, repo, kept.ID) {
+		t.Error("ctx取消后不应再清理")
+	}
+}
+
+// TestTrashService_NoRetention 测试未配置保留天数时不自动清理回收站中已有的项
+func TestTrashService_NoRetention(t *testing.T) {
+	repo, skillService := newTestSkillService(t)
+	ctx := context.Background()
+	old := createTrashedJobTask(t, repo, "JT-A-1", time.Now().AddDate(-1, 0, 0))
+
+	for _, retentionDays := range []int{0, -1} {
+		trashService := NewTrashService(repo, skillService, config.TrashConfig{RetentionDays: retentionDays})
+		trashService.sweepEvery = 10 * time.Millisecond
+		sweepCtx, cancel := context.WithCancel(ctx)
+		trashService.Start(sweepCtx)
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+
+		if !jobTaskExists(t, repo, old.ID) {
+			t.Fatalf("保留天数为%d时不应自动清理", retentionDays)
+		}
+		result, err := trashService.PurgeExpired(ctx)
+		if err != nil || len(result.Succeeded) != 0 {
+			t.Errorf("保留天数为%d时PurgeExpired不应清理: %+v, %v", retentionDays, result, err)
+		}
+		list, err := trashService.ListTrash(ctx, ListTrashRequest{})
+		if err != nil || len(list.Items) != 1 || list.Items[0].ExpiresAt != 0 || list.RetentionDays != 0 {
+			t.Errorf("不自动清理时过期时间和保留天数应为0: %+v, %v", list, err)
+		}
+	}
+}
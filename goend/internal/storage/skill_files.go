@@ -0,0 +1,312 @@
+// Package storage 提供技能文件的磁盘存储
+// 每个技能的脚本、模板、参考资料等文件存放在 基准目录/<资源目录>/ 下，所有路径都会校验不能越出技能目录
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// 存储错误定义
+var (
+	// ErrInvalidPath 路径非法（绝对路径、包含..越出技能目录等）
+	ErrInvalidPath = errors.New("非法的文件路径")
+	// ErrFileTooLarge 文件超过大小上限
+	ErrFileTooLarge = errors.New("文件超过大小上限")
+)
+
+// SkillFile 技能文件信息
+type SkillFile struct {
+	Path      string `json:"path"`      // 相对技能目录的路径，使用/分隔
+	Size      int64  `json:"size"`      // 文件大小（字节）
+	UpdatedAt int64  `json:"updatedAt"` // 修改时间（毫秒级时间戳）
+}
+
+// SkillFileStore 技能文件存储
+type SkillFileStore struct {
+	baseDir     string
+	maxFileSize int64
+}
+
+// NewSkillFileStore 创建技能文件存储
+// 参数:
+//   - baseDir: 基准目录，相对路径按当前工作目录解析为绝对路径
+//   - maxFileSize: 单个文件大小上限（字节），小于等于0表示不限制
+//
+// 返回:
+//   - *SkillFileStore: 技能文件存储实例
+func NewSkillFileStore(baseDir string, maxFileSize int64) *SkillFileStore {
+	if absDir, err := filepath.Abs(baseDir); err == nil {
+		baseDir = absDir
+	}
+	return &SkillFileStore{baseDir: filepath.Clean(baseDir), maxFileSize: maxFileSize}
+}
+
+// BaseDir 获取基准目录的绝对路径
+func (s *SkillFileStore) BaseDir() string {
+	return s.baseDir
+}
+
+// MaxFileSize 获取单个文件大小上限
+func (s *SkillFileStore) MaxFileSize() int64 {
+	return s.maxFileSize
+}
+
+// SkillDir 获取技能目录的绝对路径
+// 资源目录只能是单级目录名，不能包含路径分隔符或以.开头
+func (s *SkillFileStore) SkillDir(resourceDir string) (string, error) {
+	if resourceDir == "" || strings.HasPrefix(resourceDir, ".") || strings.ContainsAny(resourceDir, `/\:`) {
+		return "", fmt.Errorf("%w: 资源目录 %q", ErrInvalidPath, resourceDir)
+	}
+	return filepath.Join(s.baseDir, resourceDir), nil
+}
+
+// ResolvePath 将技能目录内的相对路径解析为绝对路径
+// 拒绝绝对路径、越出技能目录的路径，以及通过符号链接指向技能目录之外的路径
+func (s *SkillFileStore) ResolvePath(resourceDir, relPath string) (string, error) {
+	skillDir, err := s.SkillDir(resourceDir)
+	if err != nil {
+		return "", err
+	}
+
+	cleaned, err := CleanRelPath(relPath)
+	if err != nil {
+		return "", err
+	}
+	fullPath := filepath.Join(skillDir, filepath.FromSlash(cleaned))
+
+	// 解析路径中已存在部分的符号链接后再次校验，防止通过符号链接越出技能目录
+	realSkillDir, err := filepath.EvalSymlinks(skillDir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fullPath, nil
+		}
+		return "", err
+	}
+	existing := fullPath
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+		existing = filepath.Dir(existing)
+	}
+	realPath, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return "", err
+	}
+	if !isWithin(realSkillDir, realPath) {
+		return "", fmt.Errorf("%w: %s", ErrInvalidPath, relPath)
+	}
+	return fullPath, nil
+}
+
+// CleanRelPath 规范化相对路径并校验不会越出所在目录
+// 返回使用/分隔的路径
+func CleanRelPath(relPath string) (string, error) {
+	relPath = strings.ReplaceAll(strings.TrimSpace(relPath), `\`, "/")
+	if relPath == "" || strings.HasPrefix(relPath, "/") || filepath.IsAbs(relPath) || filepath.VolumeName(relPath) != "" {
+		return "", fmt.Errorf("%w: %s", ErrInvalidPath, relPath)
+	}
+
+	cleaned := filepath.ToSlash(filepath.Clean(filepath.FromSlash(relPath)))
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("%w: %s", ErrInvalidPath, relPath)
+	}
+	return cleaned, nil
+}
+
+// List 列出技能目录下的所有文件（递归，不含目录），按路径排序
+// 技能目录不存在时返回空列表
+func (s *SkillFileStore) List(resourceDir string) ([]SkillFile, error) {
+	skillDir, err := s.SkillDir(resourceDir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []SkillFile{}
+	err = filepath.WalkDir(skillDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(skillDir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, SkillFile{
+			Path:      filepath.ToSlash(rel),
+			Size:      info.Size(),
+			UpdatedAt: info.ModTime().UnixMilli(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+	return files, nil
+}
+
+// Open 打开技能文件用于读取，调用方负责关闭
+func (s *SkillFileStore) Open(resourceDir, relPath string) (*os.File, os.FileInfo, error) {
+	fullPath, err := s.ResolvePath(resourceDir, relPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, nil, fmt.Errorf("%w: %s 不是文件", ErrInvalidPath, relPath)
+	}
+
+	file, err := os.Open(fullPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, info, nil
+}
+
+// Read 读取技能文件内容，超过limit字节时返回ErrFileTooLarge
+func (s *SkillFileStore) Read(resourceDir, relPath string, limit int64) ([]byte, error) {
+	file, info, err := s.Open(resourceDir, relPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if limit > 0 && info.Size() > limit {
+		return nil, fmt.Errorf("%w: %s 大小 %d 字节，上限 %d 字节", ErrFileTooLarge, relPath, info.Size(), limit)
+	}
+	return io.ReadAll(file)
+}
+
+// Save 保存技能文件，已存在时覆盖
+// 先写入临时文件再重命名，避免写入中断留下不完整的文件
+func (s *SkillFileStore) Save(resourceDir, relPath string, reader io.Reader) (*SkillFile, error) {
+	fullPath, err := s.ResolvePath(resourceDir, relPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return nil, err
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(fullPath), ".upload-*")
+	if err != nil {
+		return nil, err
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+
+	// 多读一个字节用于判断是否超出上限
+	src := reader
+	if s.maxFileSize > 0 {
+		src = io.LimitReader(reader, s.maxFileSize+1)
+	}
+	size, err := io.Copy(tmpFile, src)
+	closeErr := tmpFile.Close()
+	if err != nil {
+		return nil, err
+	}
+	if closeErr != nil {
+		return nil, closeErr
+	}
+	if s.maxFileSize > 0 && size > s.maxFileSize {
+		return nil, fmt.Errorf("%w: %s 超过 %d 字节", ErrFileTooLarge, relPath, s.maxFileSize)
+	}
+
+	if err := os.Rename(tmpPath, fullPath); err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return nil, err
+	}
+	cleaned, _ := CleanRelPath(relPath)
+	return &SkillFile{Path: cleaned, Size: info.Size(), UpdatedAt: info.ModTime().UnixMilli()}, nil
+}
+
+// Delete 删除技能文件
+func (s *SkillFileStore) Delete(resourceDir, relPath string) error {
+	fullPath, err := s.ResolvePath(resourceDir, relPath)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Lstat(fullPath)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%w: %s 不是文件", ErrInvalidPath, relPath)
+	}
+	return os.Remove(fullPath)
+}
+
+// RenameSkillDir 技能资源目录变更时移动技能目录
+// 新旧目录任一为空或原目录不存在时不做处理，目标目录已存在时返回错误
+func (s *SkillFileStore) RenameSkillDir(oldResourceDir, newResourceDir string) error {
+	if oldResourceDir == newResourceDir || oldResourceDir == "" || newResourceDir == "" {
+		return nil
+	}
+
+	oldDir, err := s.SkillDir(oldResourceDir)
+	if err != nil {
+		return err
+	}
+	newDir, err := s.SkillDir(newResourceDir)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(oldDir); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if _, err := os.Stat(newDir); err == nil {
+		return fmt.Errorf("资源目录 %s 已存在", newResourceDir)
+	}
+	if err := os.MkdirAll(s.baseDir, 0755); err != nil {
+		return err
+	}
+	return os.Rename(oldDir, newDir)
+}
+
+// RemoveSkillDir 删除技能目录及其全部文件
+func (s *SkillFileStore) RemoveSkillDir(resourceDir string) error {
+	skillDir, err := s.SkillDir(resourceDir)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(skillDir)
+}
+
+// isWithin 判断path是否位于dir目录内（包含dir本身）
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
@@ -18,6 +18,9 @@ const (
 	LogLevelError = "error"
 )
 
+// stdWriter 标准输出形式下非错误级别日志的输出目标，默认为标准输出
+var stdWriter io.Writer = os.Stdout
+
 // 全局日志实例
 var (
 	DebugLogger *log.Logger = log.New(os.Stdout, "[DEBUG] ", log.Ldate|log.Ltime|log.Lmicroseconds)
@@ -26,13 +29,22 @@ var (
 	ErrorLogger *log.Logger = log.New(os.Stderr, "[ERROR] ", log.Ldate|log.Ltime|log.Lmicroseconds)
 )
 
+// SetStdOutput 设置标准输出形式下非错误级别日志的输出目标
+// 以stdio方式提供MCP服务时标准输出用于协议通信，需在InitLogger之前调用，将日志改写到标准错误
+func SetStdOutput(w io.Writer) {
+	stdWriter = w
+	DebugLogger.SetOutput(w)
+	InfoLogger.SetOutput(w)
+	WarnLogger.SetOutput(w)
+}
+
 // InitLogger 初始化日志系统，根据配置的日志等级和输出形式设置日志输出
 // logLevel: 日志等级，可选值：debug、info、warn、error
 // outputType: 输出形式，可选值：std（标准输出）或 file（文件输出），默认std
 // logDirPath: 当outputType为file时，指定日志文件夹路径，日志文件名为main.log
 func InitLogger(logLevel string, outputType string, logDirPath string) {
 	// 初始化输出目标
-	var stdout io.Writer = os.Stdout
+	var stdout io.Writer = stdWriter
 	var stderr io.Writer = os.Stderr
 
 	// 处理输出形式
@@ -123,28 +135,28 @@ func formatWithRequestID(requestID, format string, v ...interface{}) string {
 // 自动从上下文中提取请求ID并添加到日志前缀
 func DebugCtx(ctx context.Context, format string, v ...interface{}) {
 	requestID := getRequestIDFromContext(ctx)
-	DebugLogger.Printf(formatWithRequestID(requestID, format, v...))
+	DebugLogger.Print(formatWithRequestID(requestID, format, v...))
 }
 
 // InfoCtx 输出带上下文的信息级别日志
 // 自动从上下文中提取请求ID并添加到日志前缀
 func InfoCtx(ctx context.Context, format string, v ...interface{}) {
 	requestID := getRequestIDFromContext(ctx)
-	InfoLogger.Printf(formatWithRequestID(requestID, format, v...))
+	InfoLogger.Print(formatWithRequestID(requestID, format, v...))
 }
 
 // WarnCtx 输出带上下文的警告级别日志
 // 自动从上下文中提取请求ID并添加到日志前缀
 func WarnCtx(ctx context.Context, format string, v ...interface{}) {
 	requestID := getRequestIDFromContext(ctx)
-	WarnLogger.Printf(formatWithRequestID(requestID, format, v...))
+	WarnLogger.Print(formatWithRequestID(requestID, format, v...))
 }
 
 // ErrorCtx 输出带上下文的错误级别日志
 // 自动从上下文中提取请求ID并添加到日志前缀
 func ErrorCtx(ctx context.Context, format string, v ...interface{}) {
 	requestID := getRequestIDFromContext(ctx)
-	ErrorLogger.Printf(formatWithRequestID(requestID, format, v...))
+	ErrorLogger.Print(formatWithRequestID(requestID, format, v...))
 }
 
 // ctxKey 上下文键类型（与middleware包保持一致）
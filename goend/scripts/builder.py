@@ -391,7 +391,7 @@ def build_standard(env, output_exe):
 
         print_info(f"执行Go构建命令，输出: {output_exe}")
         result = subprocess.run(
-            ["go", "build", f"-ldflags={ldflags}", "-o", output_exe, "./cmd/api"],
+            ["go", "build", "-tags", "sqlite_fts5", f"-ldflags={ldflags}", "-o", output_exe, "./cmd/api"],
             cwd=GOEND_DIR,
             capture_output=True,
             text=True,